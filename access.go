@@ -0,0 +1,117 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// AccessTokenType is the typ header of JWT access tokens as profiled by RFC
+// 9068
+const AccessTokenType = "at+jwt"
+
+// accessTokenClaims are the claims RFC 9068 requires of access tokens
+var accessTokenClaims = []string{"iss", "exp", "aud", "sub", "client_id", "iat", "jti"}
+
+// AccessTokenClaims are the claims of a JWT access token as profiled by RFC
+// 9068.
+type AccessTokenClaims struct {
+	Issuer         string       `json:"iss,omitempty"`
+	Subject        string       `json:"sub,omitempty"`
+	Audience       Audience     `json:"aud,omitempty"`
+	ExpirationTime *NumericDate `json:"exp,omitempty"`
+	IssuedAt       *NumericDate `json:"iat,omitempty"`
+	JWTId          string       `json:"jti,omitempty"`
+	ClientID       string       `json:"client_id,omitempty"`
+	// Scope is the space separated list of scopes granted
+	Scope        string       `json:"scope,omitempty"`
+	AuthTime     *NumericDate `json:"auth_time,omitempty"`
+	ACR          string       `json:"acr,omitempty"`
+	AMR          []string     `json:"amr,omitempty"`
+	Groups       []string     `json:"groups,omitempty"`
+	Roles        []string     `json:"roles,omitempty"`
+	Entitlements []string     `json:"entitlements,omitempty"`
+	// Confirmation binds the token to a client certificate or key
+	Confirmation *Confirmation `json:"cnf,omitempty"`
+}
+
+// Scopes returns the scopes granted by the token.
+func (c *AccessTokenClaims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// HasScope reports whether the token grants a scope.
+func (c *AccessTokenClaims) HasScope(scope string) bool {
+	return containsString(c.Scopes(), scope)
+}
+
+// HasRole reports whether the token names a role.
+func (c *AccessTokenClaims) HasRole(role string) bool {
+	return containsString(c.Roles, role)
+}
+
+// Authorize asserts the token grants every one of the given scopes and returns
+// a *ScopeError listing them otherwise.
+func (c *AccessTokenClaims) Authorize(scopes ...string) error {
+	granted := c.Scopes()
+
+	for _, scope := range scopes {
+		if !containsString(granted, scope) {
+			return &ScopeError{Scopes: scopes}
+		}
+	}
+
+	return nil
+}
+
+// NewAccessTokenDecoder constructs a Decoder validating access tokens of an
+// authorization server for a resource server as RFC 9068 requires: tokens must
+// have an at+jwt typ header, the issuer and audience given and each of the iss,
+// exp, aud, sub, client_id, iat and jti claims. Scopes may be required with
+// RequireScopes.
+func NewAccessTokenDecoder(v Validator, issuer, audience string) *Decoder {
+	dec := NewDecoder(nil, v)
+	dec.Types = []string{AccessTokenType}
+	dec.Issuers = []string{issuer}
+	dec.Audience = audience
+
+	dec.AddValidation(func(claims RawClaims) error {
+		for _, name := range accessTokenClaims {
+			if _, ok := claims[name]; !ok {
+				return fmt.Errorf("%w: %s", ErrMissingClaim, name)
+			}
+		}
+
+		return nil
+	})
+
+	return dec
+}
+
+// RequireScopes requires the space separated scope claim of tokens to grant
+// every one of the given scopes. A *ScopeError is returned otherwise, which
+// NewBearerChallenge turns into an insufficient_scope challenge. Like
+// AddValidation it must be called before the Decoder is used.
+func (dec *Decoder) RequireScopes(scopes ...string) {
+	dec.AddValidation(func(claims RawClaims) error {
+		var c AccessTokenClaims
+		if err := claims.Decode("scope", &c.Scope); err != nil && !errors.Is(err, ErrMissingClaim) {
+			return ErrMalformedToken
+		}
+
+		return c.Authorize(scopes...)
+	})
+}
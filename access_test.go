@@ -0,0 +1,91 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAccessTokenDecoder(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	exp := NewNumericDate(time.Now().Add(time.Hour))
+	iat := NewNumericDate(time.Now())
+	claims := func(scope string) *AccessTokenClaims {
+		return &AccessTokenClaims{
+			Issuer:         "https://as.example.com",
+			Subject:        "user",
+			Audience:       Audience{"https://rs.example.com"},
+			ExpirationTime: exp,
+			IssuedAt:       iat,
+			JWTId:          "1",
+			ClientID:       "client",
+			Scope:          scope,
+		}
+	}
+
+	withoutClientID := claims("read")
+	withoutClientID.ClientID = ""
+
+	otherAudience := claims("read")
+	otherAudience.Audience = Audience{"https://other.example.com"}
+
+	cases := []struct {
+		ExpectedError error
+		Reason        string
+		Type          string
+		Claims        *AccessTokenClaims
+	}{
+		{nil, "the token follows the profile", "at+jwt", claims("read write")},
+		{nil, "the typ has the application/ prefix", "application/at+jwt", claims("read")},
+		{ErrInvalidType, "the token is an ID token", "JWT", claims("read")},
+		{ErrMissingClaim, "the token has no client_id", "at+jwt", withoutClientID},
+		{ErrInvalidAudience, "the token is meant for another resource server", "at+jwt", otherAudience},
+		{ErrInsufficientScope, "the token lacks a required scope", "at+jwt", claims("write")},
+	}
+
+	for _, c := range cases {
+		token := signTestToken(t, v, Header{Type: c.Type}, c.Claims)
+
+		dec := NewAccessTokenDecoder(v, "https://as.example.com", "https://rs.example.com")
+		dec.RequireScopes("read")
+
+		var decoded AccessTokenClaims
+		if err := dec.Verify(token, &decoded); !errors.Is(err, c.ExpectedError) {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		}
+	}
+}
+
+func TestAccessTokenClaimsAuthorize(t *testing.T) {
+	claims := &AccessTokenClaims{Scope: "read write", Roles: []string{"admin"}}
+
+	if !claims.HasScope("write") || claims.HasScope("delete") || !claims.HasRole("admin") {
+		t.Errorf("Expected the scopes and roles of the token; got %#v", claims)
+	}
+
+	err := claims.Authorize("read", "delete")
+
+	var scopeErr *ScopeError
+	if !errors.As(err, &scopeErr) || len(scopeErr.Scopes) != 2 {
+		t.Errorf("Expected a *ScopeError listing the required scopes; got %v", err)
+	}
+
+	if err := claims.Authorize("read", "write"); err != nil {
+		t.Errorf("Didn't expect authorizing granted scopes to return an error: %s", err)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"crypto"
+	"encoding/json"
+)
+
+// A FlattenedJWS is the flattened JSON serialization of a JWS as used in the
+// bodies of ACME requests.
+type FlattenedJWS struct {
+	Protected string `json:"protected"`
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+// An ACMESigner signs the bodies of requests to an ACME server as described by
+// RFC 8555.
+type ACMESigner struct {
+	validator Validator
+	// JWK is the public key of the account. It is embedded in requests when
+	// KeyID is empty, e.g. when creating the account.
+	JWK *JSONWebKey
+	// KeyID is the account URL returned by the server once the account exists
+	KeyID string
+}
+
+// NewACMESigner constructs an ACMESigner for the account whose public key is
+// given. The validator must hold the matching private key.
+func NewACMESigner(v Validator, key crypto.PublicKey) (*ACMESigner, error) {
+	jwk, err := NewJSONWebKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ACMESigner{validator: v, JWK: jwk.Public()}, nil
+}
+
+// Sign composes the body of a request to a given url with a nonce provided by
+// the server. The payload is encoded as JSON.
+func (s *ACMESigner) Sign(url, nonce string, payload interface{}) (*FlattenedJWS, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.sign(url, nonce, rawPayload(raw))
+}
+
+// SignPostAsGet composes the body of a POST-as-GET request, which has an empty
+// payload.
+func (s *ACMESigner) SignPostAsGet(url, nonce string) (*FlattenedJWS, error) {
+	return s.sign(url, nonce, rawPayload(nil))
+}
+
+func (s *ACMESigner) sign(url, nonce string, payload rawPayload) (*FlattenedJWS, error) {
+	h := &Header{Nonce: nonce, URL: url, KeyID: s.KeyID}
+
+	if s.KeyID == "" {
+		h.JWK = s.JWK
+	}
+
+	jwt := &jwt{Header: h, Payload: payload}
+
+	if err := s.validator.sign(jwt); err != nil {
+		return nil, err
+	}
+
+	return &FlattenedJWS{
+		Protected: string(jwt.headerRaw),
+		Payload:   string(jwt.payloadRaw),
+		Signature: string(jwt.Signature),
+	}, nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestACMESigner(t *testing.T) {
+	v := testRSValidator(t)
+
+	signer, err := NewACMESigner(v, v.PublicKey)
+	if err != nil {
+		t.Fatalf("Didn't expect constructing a signer to return an error: %s", err)
+	}
+
+	verify := func(body *FlattenedJWS) *Header {
+		h := &Header{}
+		jwt := &jwt{
+			Header:     h,
+			headerRaw:  []byte(body.Protected),
+			payloadRaw: []byte(body.Payload),
+			Signature:  []byte(body.Signature),
+		}
+
+		protected, _ := parseField(body.Protected)
+		if err := json.Unmarshal(protected, h); err != nil {
+			t.Fatalf("Expected the protected header to be JSON: %s", err)
+		}
+
+		if valid, err := v.validate(jwt); !valid || err != nil {
+			t.Errorf("Expected the request signature to be valid; got %v", err)
+		}
+
+		return h
+	}
+
+	body, err := signer.Sign("https://acme.example/new-account", "n-0001", map[string]bool{"termsOfServiceAgreed": true})
+	if err != nil {
+		t.Fatalf("Didn't expect signing a request to return an error: %s", err)
+	}
+
+	h := verify(body)
+	if h.Algorithm != RS256 || h.Nonce != "n-0001" || h.URL != "https://acme.example/new-account" {
+		t.Errorf("Expected the protected header to carry alg, nonce and url; got %#v", h)
+	}
+
+	if h.JWK == nil || h.KeyID != "" || h.Type != "" {
+		t.Errorf("Expected a request without an account to embed the jwk only; got %#v", h)
+	}
+
+	if payload, _ := parseField(body.Payload); string(payload) != `{"termsOfServiceAgreed":true}` {
+		t.Errorf("Expected the payload to be the JSON request; got %s", payload)
+	}
+
+	signer.KeyID = "https://acme.example/acct/1"
+
+	body, err = signer.SignPostAsGet("https://acme.example/order/1", "n-0002")
+	if err != nil {
+		t.Fatalf("Didn't expect signing a POST-as-GET request to return an error: %s", err)
+	}
+
+	h = verify(body)
+	if h.JWK != nil || h.KeyID != "https://acme.example/acct/1" {
+		t.Errorf("Expected a request from an account to name its kid only; got %#v", h)
+	}
+
+	if body.Payload != "" {
+		t.Errorf("Expected a POST-as-GET request to have an empty payload; got %s", body.Payload)
+	}
+
+	if _, err := signer.Sign("https://acme.example/new-order", "n-0003", map[string]interface{}{"ch": make(chan int)}); err == nil {
+		t.Error("Expected a payload that cannot be encoded to return its JSON error")
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ErrSigningMismatch is returned when a SigningResponse does not answer a
+// SigningRequest, or a validator would sign something other than requested
+var ErrSigningMismatch = errors.New("signing response does not match the request")
+
+// A SigningRequest carries an unsigned token to a signer across an air gap,
+// e.g. as a file or a QR code. It encodes as JSON. The signer answers with a
+// SigningResponse, which is assembled into a token with Complete.
+type SigningRequest struct {
+	// ID pairs the request with its response
+	ID string `json:"id"`
+	// Algorithm is the algorithm the token must be signed with
+	Algorithm Algorithm `json:"alg"`
+	// KeyID is the kid header of the token, if it names its key
+	KeyID string `json:"kid,omitempty"`
+	// SigningInput is the encoded header and payload of the token joined by
+	// a period, which is what is signed
+	SigningInput string `json:"input"`
+	// CreatedAt is when the request was made in seconds since the epoch
+	CreatedAt int64 `json:"iat"`
+}
+
+// A SigningResponse carries the signature of a SigningRequest back across an
+// air gap. It encodes as JSON.
+type SigningResponse struct {
+	// ID is the ID of the request signed
+	ID string `json:"id"`
+	// Signature is the encoded signature segment of the token
+	Signature string `json:"sig"`
+}
+
+// NewSigningRequest returns a request for a token with a given header and
+// payload to be signed by a signer that is not connected, e.g. one holding an
+// offline root key. The algorithm of the header must be set as the validator
+// of the Encoder, which may be nil, does not sign it.
+func (enc *Encoder) NewSigningRequest(h Header, v interface{}) (*SigningRequest, error) {
+	if h.Algorithm == "" {
+		return nil, ErrAlgorithmNotImplemented
+	}
+
+	jwt, encErr := enc.prepare(h, v)
+	if encErr != nil {
+		return nil, encErr.Err
+	}
+
+	jwt.rawEncode()
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	return &SigningRequest{
+		ID:           encodeSegment(id),
+		Algorithm:    jwt.Header.Algorithm,
+		KeyID:        jwt.Header.KeyID,
+		SigningInput: string(jwt.headerRaw) + "." + string(jwt.payloadRaw),
+		CreatedAt:    timeFunc().Unix(),
+	}, nil
+}
+
+// Claims returns the claims of the requested token so that a signer can
+// review them before signing.
+func (r *SigningRequest) Claims() (RawClaims, error) {
+	jwt, err := r.unsigned()
+	if err != nil {
+		return nil, err
+	}
+
+	var claims RawClaims
+	if err := json.Unmarshal(jwt.Payload.(rawPayload), &claims); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	return claims, nil
+}
+
+// Sign signs the request with a validator on the far side of the air gap.
+// ErrSigningMismatch is returned when the validator is not of the requested
+// algorithm.
+func (r *SigningRequest) Sign(v Validator) (*SigningResponse, error) {
+	jwt, err := r.unsigned()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := v.sign(jwt); err != nil {
+		return nil, err
+	}
+
+	if string(jwt.headerRaw)+"."+string(jwt.payloadRaw) != r.SigningInput {
+		return nil, ErrSigningMismatch
+	}
+
+	return &SigningResponse{ID: r.ID, Signature: string(jwt.Signature)}, nil
+}
+
+// Complete assembles the signed token of a request from its response. The
+// token should be verified before it is issued.
+func (r *SigningRequest) Complete(resp *SigningResponse) (SignedToken, error) {
+	if resp == nil || resp.ID != r.ID || resp.Signature == "" || strings.Contains(resp.Signature, ".") {
+		return "", ErrSigningMismatch
+	}
+
+	return SignedToken(r.SigningInput + "." + resp.Signature), nil
+}
+
+// unsigned returns the token of the request without a signature
+func (r *SigningRequest) unsigned() (*jwt, error) {
+	fields := strings.Split(r.SigningInput, ".")
+	if len(fields) != 2 {
+		return nil, ErrMalformedToken
+	}
+
+	jwt := &jwt{Header: &Header{}}
+	if err := jwt.parseHeader(fields[0]); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	payload, err := parseField(fields[1])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	if jwt.Header.Algorithm != r.Algorithm {
+		return nil, ErrSigningMismatch
+	}
+
+	jwt.Payload = rawPayload(payload)
+
+	return jwt, nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAirGappedSigning(t *testing.T) {
+	signer := testRSValidator(t)
+
+	req, err := NewEncoder(nil, nil).NewSigningRequest(Header{Algorithm: RS256, Type: "JWT", KeyID: "root"}, &Payload{Subject: "1234567890"})
+	if err != nil {
+		t.Fatalf("Didn't expect preparing a signing request to return an error: %s", err)
+	}
+
+	b, _ := json.Marshal(req)
+
+	received := &SigningRequest{}
+	if err := json.Unmarshal(b, received); err != nil {
+		t.Fatalf("Didn't expect decoding a signing request to return an error: %s", err)
+	}
+
+	var subject string
+	if claims, err := received.Claims(); err != nil || claims.Decode("sub", &subject) != nil || subject != "1234567890" {
+		t.Errorf("Expected the claims of the request to be reviewable; got %v", err)
+	}
+
+	resp, err := received.Sign(signer)
+	if err != nil {
+		t.Fatalf("Didn't expect signing a request to return an error: %s", err)
+	}
+
+	b, _ = json.Marshal(resp)
+
+	answer := &SigningResponse{}
+	json.Unmarshal(b, answer)
+
+	token, err := req.Complete(answer)
+	if err != nil {
+		t.Fatalf("Didn't expect completing a request to return an error: %s", err)
+	}
+
+	result, err := NewDecoder(nil, signer).verify(string(token), &Payload{})
+	if err != nil || result.KeyID != "root" {
+		t.Errorf("Expected a valid token signed by root; got %#v and %v", result, err)
+	}
+
+	hs := NewHSValidator(HS256)
+	hs.Key = []byte("bogokey")
+
+	if _, err := received.Sign(hs); err != ErrSigningMismatch {
+		t.Errorf("Expected signing with another algorithm to return %s; got %v", ErrSigningMismatch, err)
+	}
+
+	other, _ := NewEncoder(nil, nil).NewSigningRequest(Header{Algorithm: RS256}, &Payload{})
+	if _, err := other.Complete(answer); err != ErrSigningMismatch {
+		t.Errorf("Expected completing another request to return %s; got %v", ErrSigningMismatch, err)
+	}
+
+	if _, err := NewEncoder(nil, nil).NewSigningRequest(Header{}, &Payload{}); err != ErrAlgorithmNotImplemented {
+		t.Errorf("Expected a request without an algorithm to return %s; got %v", ErrAlgorithmNotImplemented, err)
+	}
+}
@@ -16,9 +16,9 @@ package jwt
 
 import (
 	"bytes"
-	"encoding/base64"
+	"crypto/ecdsa"
+	"crypto/rsa"
 	"encoding/json"
-	"strings"
 )
 
 const (
@@ -46,6 +46,9 @@ const (
 
 type nonevalidator struct{}
 
+// A rawPayload is a payload of arbitrary bytes that is not encoded as JSON
+type rawPayload []byte
+
 // An Algorithm describes the signing algorithm as defined by the jwt specficiation
 type Algorithm string
 
@@ -58,6 +61,31 @@ type Validator interface {
 	sign(jwt *jwt) error
 }
 
+// validatorFor constructs a Validator able to verify signatures of the given
+// algorithm with a verification key. The key must be of a type suited to the
+// algorithm so that a token cannot choose how its own key is interpreted.
+func validatorFor(algorithm Algorithm, key interface{}) (Validator, error) {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		v, err := NewRSValidator(algorithm)
+		v.PublicKey = k
+		return v, err
+	case *ecdsa.PublicKey:
+		v, err := NewESValidator(algorithm)
+		v.PublicKey = k
+		return v, err
+	case []byte:
+		switch algorithm {
+		case HS256, HS384, HS512:
+			v := NewHSValidator(algorithm)
+			v.Key = k
+			return v, nil
+		}
+	}
+
+	return nil, ErrAlgorithmNotImplemented
+}
+
 func (v nonevalidator) validate(jwt *jwt) (bool, error) {
 	// NOOP Validation :-1:
 	return true, nil
@@ -78,20 +106,20 @@ func (jwt *jwt) rawEncode() {
 
 	// TODO: Determine if errors here are possible/relevant
 	json.NewEncoder(headerBuf).Encode(jwt.Header)
-	json.NewEncoder(payloadBuf).Encode(jwt.Payload)
 
 	compactHeaderBuf := bytes.NewBuffer(nil)
 	compactPayloadBuf := bytes.NewBuffer(nil)
 
 	json.Compact(compactHeaderBuf, headerBuf.Bytes())
-	json.Compact(compactPayloadBuf, payloadBuf.Bytes())
-
-	jwt.headerRaw = make([]byte, base64.URLEncoding.EncodedLen(len(compactHeaderBuf.Bytes())))
-	jwt.payloadRaw = make([]byte, base64.URLEncoding.EncodedLen(len(compactPayloadBuf.Bytes())))
 
-	base64.URLEncoding.Encode(jwt.headerRaw, compactHeaderBuf.Bytes())
-	base64.URLEncoding.Encode(jwt.payloadRaw, compactPayloadBuf.Bytes())
+	// A rawPayload is signed as is rather than as JSON
+	if raw, ok := jwt.Payload.(rawPayload); ok {
+		compactPayloadBuf.Write(raw)
+	} else {
+		json.NewEncoder(payloadBuf).Encode(jwt.Payload)
+		json.Compact(compactPayloadBuf, payloadBuf.Bytes())
+	}
 
-	jwt.headerRaw = []byte(strings.Trim(string(jwt.headerRaw), "="))
-	jwt.payloadRaw = []byte(strings.Trim(string(jwt.payloadRaw), "="))
+	jwt.headerRaw = []byte(encodeSegment(compactHeaderBuf.Bytes()))
+	jwt.payloadRaw = []byte(encodeSegment(compactPayloadBuf.Bytes()))
 }
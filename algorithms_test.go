@@ -14,16 +14,22 @@
 
 package jwt
 
-import "testing"
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"strings"
+	"testing"
+)
 
 func TestNonevalidate(t *testing.T) {
 
 	nv := nonevalidator{}
 
 	jwt := &jwt{
-		Header: &header{
-			Algorithm:   None,
-			ContentType: "JWT",
+		Header: &Header{
+			Algorithm: None,
+			Type:      "JWT",
 		},
 		Payload: &Payload{
 			Subject: "1234567890",
@@ -46,9 +52,9 @@ func TestNonesign(t *testing.T) {
 	nv := nonevalidator{}
 
 	jwt := &jwt{
-		Header: &header{
-			Algorithm:   None,
-			ContentType: "JWT",
+		Header: &Header{
+			Algorithm: None,
+			Type:      "JWT",
 		},
 		Payload: &Payload{
 			Subject: "1234567890",
@@ -66,3 +72,32 @@ func TestNonesign(t *testing.T) {
 		t.Errorf("Invalid signature from nonevalidator. Got %#v; Expected %#v", jwt.Signature, []byte(""))
 	}
 }
+
+func TestUnpaddedSegments(t *testing.T) {
+	hs := NewHSValidator(HS256)
+	hs.Key = []byte("bogokey")
+
+	es, _ := NewESValidator(ES256)
+	es.PrivateKey, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	cases := []struct {
+		Reason    string
+		Validator Validator
+	}{
+		{"signed with HMAC", hs},
+		{"signed with RSA", testRSValidator(t)},
+		{"signed with ECDSA", es},
+	}
+
+	// Payloads of every length modulo 3 so that padded encodings would end
+	// in one, two or no padding characters
+	for _, c := range cases {
+		for _, subject := range []string{"a", "ab", "abc"} {
+			token := signTestToken(t, c.Validator, Header{Type: "JWT"}, &Payload{Subject: subject})
+
+			if strings.Contains(token, "=") {
+				t.Errorf("Expected a token without padding when %s; got %s", c.Reason, token)
+			}
+		}
+	}
+}
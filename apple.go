@@ -0,0 +1,76 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"fmt"
+	"time"
+)
+
+const (
+	// AppleAudience is the aud claim of the client secrets of Sign in with
+	// Apple
+	AppleAudience = "https://appleid.apple.com"
+	// AppleMaxClientSecretLifetime is the longest Apple accepts a client
+	// secret for, six months
+	AppleMaxClientSecretLifetime = 15777000 * time.Second
+)
+
+// ParseAppleKey parses the .p8 private key file of a Sign in with Apple key
+// and returns the ES256 validator of it.
+func ParseAppleKey(p8 []byte) (ESValidator, error) {
+	signer, err := ParsePrivateKeyPEM(p8, nil)
+	if err != nil {
+		return ESValidator{}, err
+	}
+
+	key, ok := signer.(*ecdsa.PrivateKey)
+	if !ok || key.Curve != elliptic.P256() {
+		return ESValidator{}, ErrInvalidPrivateKey
+	}
+
+	v, _ := NewESValidator(ES256)
+	v.PrivateKey = key
+	v.PublicKey = &key.PublicKey
+
+	return v, nil
+}
+
+// SignAppleClientSecret signs the client secret a client, i.e. the Services ID
+// or App ID, of a team presents to the token endpoint of Sign in with Apple.
+// The secret is signed with ES256 by the key of a given key id and is valid
+// for ttl, which may not exceed AppleMaxClientSecretLifetime.
+func SignAppleClientSecret(v ESValidator, teamID, keyID, clientID string, ttl time.Duration) (SignedToken, error) {
+	if v.algorithm != ES256 {
+		return "", ErrAlgorithmNotImplemented
+	}
+
+	if ttl <= 0 || ttl > AppleMaxClientSecretLifetime {
+		return "", fmt.Errorf("client secret lifetime %s is not within %s", ttl, AppleMaxClientSecretLifetime)
+	}
+
+	now := timeFunc()
+	payload := Payload{
+		Issuer:         teamID,
+		Subject:        clientID,
+		Audience:       Audience{AppleAudience},
+		IssuedAt:       NewNumericDate(now),
+		ExpirationTime: NewNumericDate(now.Add(ttl)),
+	}
+
+	// Apple expects a header of alg and kid only
+	return NewEncoder(nil, v).SignHeader(Header{KeyID: keyID}, payload)
+}
@@ -0,0 +1,80 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignAppleClientSecret(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	timeFunc = func() time.Time { return now }
+	defer func() { timeFunc = time.Now }()
+
+	key, err := GenerateESKey(elliptic.P256())
+	if err != nil {
+		t.Fatalf("Didn't expect generating a key to return an error: %s", err)
+	}
+
+	der, _ := x509.MarshalPKCS8PrivateKey(key.PrivateKey)
+
+	v, err := ParseAppleKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	if err != nil {
+		t.Fatalf("Didn't expect parsing a .p8 key to return an error: %s", err)
+	}
+
+	token, err := SignAppleClientSecret(v, "TEAM123456", "KEY1234567", "com.example.service", 24*time.Hour)
+	if err != nil {
+		t.Fatalf("Didn't expect signing a client secret to return an error: %s", err)
+	}
+
+	header, _ := PeekHeader(string(token))
+	if param, ok := header.Param("typ"); ok || header.Algorithm != ES256 || header.KeyID != "KEY1234567" {
+		t.Errorf("Expected a header of alg and kid only; got %#v and %s", header, param)
+	}
+
+	var payload Payload
+	if err := NewDecoder(strings.NewReader(string(token)), key).Decode(&payload); err != nil {
+		t.Fatalf("Didn't expect decoding a client secret to return an error: %s", err)
+	}
+
+	if payload.Issuer != "TEAM123456" || payload.Subject != "com.example.service" || !payload.Audience.Contains(AppleAudience) || !payload.ExpirationTime.Time.Equal(now.Add(24*time.Hour)) {
+		t.Errorf("Expected the claims of the client secret; got %#v", payload)
+	}
+
+	other, _ := GenerateESKey(elliptic.P384())
+
+	cases := []struct {
+		ExpectError bool
+		Reason      string
+		Validator   ESValidator
+		TTL         time.Duration
+	}{
+		{false, "the lifetime is the maximum", v, AppleMaxClientSecretLifetime},
+		{true, "the lifetime exceeds the maximum", v, AppleMaxClientSecretLifetime + time.Second},
+		{true, "the lifetime is not positive", v, 0},
+		{true, "the key is not ES256", other, time.Hour},
+	}
+
+	for _, c := range cases {
+		if _, err := SignAppleClientSecret(c.Validator, "TEAM123456", "KEY1234567", "com.example.service", c.TTL); (err != nil) != c.ExpectError {
+			t.Errorf("Expected an error to be %v when %s; got %v", c.ExpectError, c.Reason, err)
+		}
+	}
+}
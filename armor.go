@@ -0,0 +1,163 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// An Armor encodes the compact serialization of a token for a transport that
+// does not carry it well, e.g. SMS or the alphanumeric mode of QR codes.
+type Armor string
+
+const (
+	// ArmorHex encodes a token as lower case hexadecimal
+	ArmorHex Armor = "hex"
+	// ArmorBase32 encodes a token as unpadded upper case base32, which QR
+	// codes store more compactly than the compact serialization
+	ArmorBase32 Armor = "base32"
+)
+
+// chunkSeparator separates the index, count and data of a chunk. It is not
+// used by any armor nor the compact serialization and needs no escaping in
+// URLs.
+const chunkSeparator = "~"
+
+// Wrap encodes a token with the armor.
+func (a Armor) Wrap(token string) (string, error) {
+	switch a {
+	case ArmorHex:
+		return hex.EncodeToString([]byte(token)), nil
+	case ArmorBase32:
+		return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte(token)), nil
+	}
+
+	return "", ErrAlgorithmNotImplemented
+}
+
+// ChunkToken splits a token, armored or not, into chunks of at most size
+// bytes of data that can each be embedded in a URL or message, e.g.
+// "1~3~eyJhbGciOi". The chunks are joined again by Unarmor in any order.
+func ChunkToken(token string, size int) []string {
+	if size <= 0 {
+		size = len(token)
+	}
+
+	count := (len(token) + size - 1) / size
+	chunks := make([]string, 0, count)
+
+	for i := 0; i < count; i++ {
+		end := (i + 1) * size
+		if end > len(token) {
+			end = len(token)
+		}
+
+		chunks = append(chunks, fmt.Sprintf("%d~%d~%s", i+1, count, token[i*size:end]))
+	}
+
+	return chunks
+}
+
+// Unarmor returns the compact serialization of a token given as is, wrapped
+// by an Armor, or as the chunks of ChunkToken. The encoding is detected from
+// the alphabet of the token.
+func Unarmor(parts ...string) (string, error) {
+	if len(parts) == 0 {
+		return "", ErrMalformedToken
+	}
+
+	token := strings.TrimSpace(parts[0])
+
+	if len(parts) > 1 || strings.Contains(token, chunkSeparator) {
+		var err error
+		if token, err = joinChunks(parts); err != nil {
+			return "", err
+		}
+	}
+
+	switch {
+	case strings.Contains(token, "."):
+		return token, nil
+	case isHex(token):
+		b, err := hex.DecodeString(token)
+		if err != nil {
+			return "", ErrMalformedToken
+		}
+
+		return string(b), nil
+	default:
+		b, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(token)
+		if err != nil || !strings.Contains(string(b), ".") {
+			return "", ErrMalformedToken
+		}
+
+		return string(b), nil
+	}
+}
+
+// joinChunks joins the chunks of ChunkToken in order of their index
+func joinChunks(parts []string) (string, error) {
+	type chunk struct {
+		index int
+		data  string
+	}
+
+	chunks := make([]chunk, 0, len(parts))
+	seen := map[int]bool{}
+
+	for _, part := range parts {
+		fields := strings.SplitN(strings.TrimSpace(part), chunkSeparator, 3)
+		if len(fields) != 3 {
+			return "", ErrMalformedToken
+		}
+
+		index, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return "", ErrMalformedToken
+		}
+
+		count, err := strconv.Atoi(fields[1])
+		if err != nil || count != len(parts) || index < 1 || index > count || seen[index] {
+			return "", ErrMalformedToken
+		}
+
+		seen[index] = true
+		chunks = append(chunks, chunk{index: index, data: fields[2]})
+	}
+
+	sort.Slice(chunks, func(i, j int) bool { return chunks[i].index < chunks[j].index })
+
+	var b strings.Builder
+	for _, c := range chunks {
+		b.WriteString(c.data)
+	}
+
+	return b.String(), nil
+}
+
+// isHex reports whether s is entirely lower case hexadecimal
+func isHex(s string) bool {
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+
+	return len(s) > 0
+}
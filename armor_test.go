@@ -0,0 +1,94 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestArmor(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	token := signTestToken(t, v, Header{Type: "JWT"}, &Payload{Subject: "1234567890"})
+	hexed, _ := ArmorHex.Wrap(token)
+	based, _ := ArmorBase32.Wrap(token)
+
+	chunks := ChunkToken(based, 40)
+	reversed := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		reversed[len(chunks)-1-i] = chunk
+	}
+
+	cases := []struct {
+		ExpectedError error
+		Reason        string
+		Parts         []string
+	}{
+		{nil, "the token is not armored", []string{token}},
+		{nil, "the token is hex", []string{hexed}},
+		{nil, "the token is base32", []string{based}},
+		{nil, "the token is chunked", chunks},
+		{nil, "the chunks are out of order", reversed},
+		{nil, "the token is a single chunk", ChunkToken(token, 0)},
+		{ErrMalformedToken, "a chunk is missing", chunks[1:]},
+		{ErrMalformedToken, "a chunk is repeated", append([]string{chunks[0]}, chunks[:len(chunks)-1]...)},
+		{ErrMalformedToken, "the token is not a token", []string{"HELLO WORLD"}},
+		{ErrMalformedToken, "there is no token", nil},
+	}
+
+	for _, c := range cases {
+		unarmored, err := Unarmor(c.Parts...)
+
+		if err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		} else if err == nil && unarmored != token {
+			t.Errorf("Expected the token when %s; got %s", c.Reason, unarmored)
+		}
+	}
+
+	if strings.Trim(based, "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567") != "" {
+		t.Errorf("Expected base32 armor to only use the QR alphanumeric alphabet; got %s", based)
+	}
+
+	if _, err := Armor("rot13").Wrap(token); err != ErrAlgorithmNotImplemented {
+		t.Errorf("Expected an unknown armor to return %s; got %v", ErrAlgorithmNotImplemented, err)
+	}
+}
+
+func TestEncodeArmor(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	enc := NewEncoder(nil, v)
+	enc.Armor = ArmorHex
+
+	token, err := enc.Sign(&Payload{Subject: "1234567890"})
+	if err != nil || !isHex(string(token)) {
+		t.Fatalf("Expected a hex token; got %s and %v", token, err)
+	}
+
+	if err := NewDecoder(nil, v).Verify(string(token), &Payload{}); err != ErrMalformedToken {
+		t.Errorf("Expected an armored token to be rejected by default; got %v", err)
+	}
+
+	dec := NewDecoder(nil, v)
+	dec.AcceptArmor = true
+
+	payload := &Payload{}
+	if err := dec.Verify(string(token), payload); err != nil || payload.Subject != "1234567890" {
+		t.Errorf("Expected an armored token to be accepted; got %#v and %v", payload, err)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// ErrInvalidAttestation is returned when a token lacks an attestation that its
+// signing key is protected as a Decoder requires
+var ErrInvalidAttestation = errors.New("invalid key attestation")
+
+// Protections of signing keys
+const (
+	// ProtectionHSM is a key held by a hardware security module
+	ProtectionHSM = "hsm"
+	// ProtectionKMS is a key held by a cloud key management service
+	ProtectionKMS = "kms"
+	// ProtectionSoftware is a key held in memory by the issuer
+	ProtectionSoftware = "software"
+)
+
+// A KeyAttestation is the katt claim, stating the provenance of the key that
+// signed a token so consumers can require that tokens are signed by hardware
+// protected keys. The statement is only as trustworthy as the issuer that
+// signed it.
+type KeyAttestation struct {
+	// KeyID is the kid of the signing key
+	KeyID string `json:"kid"`
+	// Protection is how the key is held, e.g. ProtectionHSM
+	Protection string `json:"prot"`
+	// Resource identifies the key where it is held, e.g. a KMS key ARN
+	Resource string `json:"res,omitempty"`
+	// Statement is evidence of the protection, e.g. an attestation statement
+	// produced by the HSM that generated the key
+	Statement string `json:"stmt,omitempty"`
+}
+
+// embedAttestation adds a katt claim to a compact payload, which must be a
+// JSON object without one.
+func embedAttestation(payload []byte, a *KeyAttestation) ([]byte, error) {
+	var claims map[string]json.RawMessage
+
+	if err := json.Unmarshal(payload, &claims); err != nil || claims == nil {
+		return nil, ErrMalformedToken
+	}
+
+	if _, ok := claims["katt"]; ok {
+		return nil, ErrMalformedToken
+	}
+
+	katt, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bytes.NewBuffer(nil)
+	buf.WriteString(`{"katt":`)
+	buf.Write(katt)
+
+	if len(claims) > 0 {
+		buf.WriteByte(',')
+	}
+
+	buf.Write(payload[1:])
+
+	return buf.Bytes(), nil
+}
+
+// checkAttestation returns the katt claim of a verified token and asserts it
+// attests the signing key when the Decoder requires it.
+func (dec *Decoder) checkAttestation(jwt *jwt) (*KeyAttestation, error) {
+	var claims struct {
+		Attestation *KeyAttestation `json:"katt"`
+	}
+
+	json.NewDecoder(bytes.NewReader(jwt.claimsRaw)).Decode(&claims)
+
+	if len(dec.RequireAttestation) == 0 {
+		return claims.Attestation, nil
+	}
+
+	a := claims.Attestation
+	if a == nil || a.KeyID == "" || a.KeyID != jwt.Header.KeyID || !containsString(dec.RequireAttestation, a.Protection) {
+		return nil, ErrInvalidAttestation
+	}
+
+	return a, nil
+}
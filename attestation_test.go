@@ -0,0 +1,84 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestKeyAttestation(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	hsm := &KeyAttestation{KeyID: "k1", Protection: ProtectionHSM, Resource: "arn:aws:kms:us-east-1:111122223333:key/k1"}
+
+	enc := NewEncoder(nil, v)
+	enc.Attestation = hsm
+
+	token, err := enc.Sign(&Payload{Subject: "1234567890"})
+	if err != nil {
+		t.Fatalf("Didn't expect signing an attested token to return an error: %s", err)
+	}
+
+	dec := NewDecoder(nil, v)
+	dec.RequireAttestation = []string{ProtectionHSM, ProtectionKMS}
+
+	result, err := dec.verify(string(token), &Payload{})
+	if err != nil {
+		t.Fatalf("Didn't expect an attested token to return an error: %s", err)
+	}
+
+	if result.KeyID != "k1" || result.Attestation == nil || *result.Attestation != *hsm {
+		t.Errorf("Expected the attestation of k1 to be reported; got kid %s and %#v", result.KeyID, result.Attestation)
+	}
+
+	software := &KeyAttestation{KeyID: "k1", Protection: ProtectionSoftware}
+
+	cases := []struct {
+		ExpectedError error
+		Reason        string
+		Header        Header
+		Claims        interface{}
+	}{
+		{ErrInvalidAttestation, "the token has no attestation", Header{KeyID: "k1"}, &Payload{}},
+		{ErrInvalidAttestation, "the key is held in software", Header{KeyID: "k1"}, map[string]interface{}{"katt": software}},
+		{ErrInvalidAttestation, "the attestation is for another key", Header{KeyID: "k2"}, map[string]interface{}{"katt": hsm}},
+		{nil, "the attestation is for the signing key", Header{KeyID: "k1"}, map[string]interface{}{"katt": hsm}},
+	}
+
+	for _, c := range cases {
+		err := dec.Verify(signTestToken(t, v, c.Header, c.Claims), &Payload{})
+
+		if err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		}
+	}
+
+	if _, err := enc.Sign(json.RawMessage(`{"katt":{}}`)); err != ErrMalformedToken {
+		t.Errorf("Expected a payload with its own attestation to return %s; got %v", ErrMalformedToken, err)
+	}
+
+	if _, err := enc.Sign(json.RawMessage(`["a"]`)); err != ErrMalformedToken {
+		t.Errorf("Expected a payload that is not an object to return %s; got %v", ErrMalformedToken, err)
+	}
+
+	token, _ = enc.Sign(json.RawMessage(`{}`))
+	payload, _ := parseField(strings.Split(string(token), ".")[1])
+
+	if !json.Valid(payload) {
+		t.Errorf("Expected an empty payload to be attested as valid JSON; got %s", payload)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrMissingToken is returned when a request carries no token
+	ErrMissingToken = errors.New("missing bearer token")
+	// ErrInsufficientScope is returned when a token lacks a scope a resource
+	// requires
+	ErrInsufficientScope = errors.New("insufficient scope")
+)
+
+// Bearer error codes of RFC 6750
+const (
+	BearerInvalidToken      = "invalid_token"
+	BearerInsufficientScope = "insufficient_scope"
+)
+
+// A ScopeError is returned by validations when a token lacks the scopes a
+// resource requires. It matches ErrInsufficientScope with errors.Is.
+type ScopeError struct {
+	// Scopes are the scopes the resource requires
+	Scopes []string
+}
+
+func (e *ScopeError) Error() string {
+	return ErrInsufficientScope.Error() + ": " + strings.Join(e.Scopes, " ")
+}
+
+// Is reports whether target is ErrInsufficientScope.
+func (e *ScopeError) Is(target error) bool {
+	return target == ErrInsufficientScope
+}
+
+// A BearerChallenge is the response to a request whose bearer token is
+// missing or rejected, as described by RFC 6750.
+type BearerChallenge struct {
+	// Status is the HTTP status code of the response
+	Status int
+	// Realm is the protection space of the resource, if any
+	Realm string
+	// Code is the bearer error code, empty when the request had no token
+	Code string
+	// Description explains the error to developers
+	Description string
+	// Scope lists the scopes required when Code is BearerInsufficientScope
+	Scope string
+}
+
+// NewBearerChallenge returns the challenge of a request rejected with a given
+// error by a Decoder, or ErrMissingToken when it had no token. Errors that did
+// not originate in this package, e.g. a key set that cannot be fetched, are
+// failures of the server rather than of the token.
+func NewBearerChallenge(err error, realm string) BearerChallenge {
+	c := BearerChallenge{Status: http.StatusUnauthorized, Realm: realm}
+
+	var scopeErr *ScopeError
+
+	switch {
+	case errors.Is(err, ErrMissingToken):
+	case errors.As(err, &scopeErr):
+		c.Status, c.Code, c.Scope = http.StatusForbidden, BearerInsufficientScope, strings.Join(scopeErr.Scopes, " ")
+	case errors.Is(err, ErrInsufficientScope):
+		c.Status, c.Code = http.StatusForbidden, BearerInsufficientScope
+	case CodeOf(err) == CodeUnknown:
+		c.Status = http.StatusInternalServerError
+		return c
+	default:
+		c.Code = BearerInvalidToken
+	}
+
+	if c.Code != "" {
+		c.Description = err.Error()
+	}
+
+	return c
+}
+
+// Header returns the WWW-Authenticate header of the challenge, or an empty
+// string for failures of the server.
+func (c BearerChallenge) Header() string {
+	if c.Status == http.StatusInternalServerError {
+		return ""
+	}
+
+	var params []string
+
+	for _, p := range []struct{ name, value string }{
+		{"realm", c.Realm},
+		{"error", c.Code},
+		{"error_description", c.Description},
+		{"scope", c.Scope},
+	} {
+		if p.value != "" {
+			params = append(params, p.name+`="`+bearerParam(p.value)+`"`)
+		}
+	}
+
+	if len(params) == 0 {
+		return "Bearer"
+	}
+
+	return "Bearer " + strings.Join(params, ", ")
+}
+
+// Write writes the challenge as the status and WWW-Authenticate header of a
+// response.
+func (c BearerChallenge) Write(w http.ResponseWriter) {
+	if header := c.Header(); header != "" {
+		w.Header().Set("WWW-Authenticate", header)
+	}
+
+	w.WriteHeader(c.Status)
+}
+
+// bearerParam removes the characters RFC 6750 does not allow in the values of
+// challenge parameters
+func bearerParam(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e || r == '"' || r == '\\' {
+			return -1
+		}
+
+		return r
+	}, value)
+}
@@ -0,0 +1,60 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBearerChallenge(t *testing.T) {
+	cases := []struct {
+		Err      error
+		Status   int
+		Expected string
+		Reason   string
+	}{
+		{ErrMissingToken, http.StatusUnauthorized, `Bearer realm="api"`, "the request has no token"},
+		{ErrTokenExpired, http.StatusUnauthorized, `Bearer realm="api", error="invalid_token", error_description="token is expired"`, "the token expired"},
+		{fmt.Errorf("%w: sub", ErrMissingClaim), http.StatusUnauthorized, `Bearer realm="api", error="invalid_token", error_description="missing required claim: sub"`, "the token lacks a claim"},
+		{&ScopeError{Scopes: []string{"read", "write"}}, http.StatusForbidden, `Bearer realm="api", error="insufficient_scope", error_description="insufficient scope: read write", scope="read write"`, "the token lacks scopes"},
+		{errors.New("fetching key set: 503"), http.StatusInternalServerError, "", "the key set cannot be fetched"},
+	}
+
+	for _, c := range cases {
+		challenge := NewBearerChallenge(c.Err, "api")
+
+		if challenge.Status != c.Status || challenge.Header() != c.Expected {
+			t.Errorf("Expected %d %s when %s; got %d %s", c.Status, c.Expected, c.Reason, challenge.Status, challenge.Header())
+		}
+	}
+
+	if !errors.Is(&ScopeError{}, ErrInsufficientScope) {
+		t.Errorf("Expected a ScopeError to match %s", ErrInsufficientScope)
+	}
+
+	if header := (BearerChallenge{Code: BearerInvalidToken, Description: "bad \"quoted\"\\ token\n"}).Header(); header != `Bearer error="invalid_token", error_description="bad quoted token"` {
+		t.Errorf("Expected characters RFC 6750 disallows to be removed; got %s", header)
+	}
+
+	w := httptest.NewRecorder()
+	NewBearerChallenge(ErrBadSignature, "api").Write(w)
+
+	if w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") != `Bearer realm="api", error="invalid_token", error_description="`+ErrBadSignature.Error()+`"` {
+		t.Errorf("Expected the challenge to be written to the response; got %d %s", w.Code, w.Header().Get("WWW-Authenticate"))
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+// A VerifyCache remembers tokens a Decoder has verified so that an identical
+// token presented again before it expires, e.g. a retry from the same client,
+// skips signature verification. Claims are still decoded and checked. Tokens
+// without an exp claim are never cached.
+//
+// A VerifyCache holds at most a fixed number of tokens, evicting the oldest
+// first, and is safe for concurrent use. It must not be shared by Decoders
+// that trust different keys.
+type VerifyCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[verifyCacheKey]verifyCacheEntry
+	order   []verifyCacheKey
+	next    int
+}
+
+// verifyCacheKey identifies a token by the key that signed it and its signature
+type verifyCacheKey struct {
+	kid       string
+	signature string
+}
+
+// verifyCacheEntry is the digest of the signed content of a verified token
+type verifyCacheEntry struct {
+	digest  [sha256.Size]byte
+	expires time.Time
+}
+
+// NewVerifyCache constructs a VerifyCache holding up to size tokens.
+func NewVerifyCache(size int) *VerifyCache {
+	return &VerifyCache{size: size, entries: map[verifyCacheKey]verifyCacheEntry{}}
+}
+
+// Len returns the number of tokens in the cache, including expired tokens
+// that have not been evicted yet.
+func (c *VerifyCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.entries)
+}
+
+// verified reports whether a token with the same kid, signature and signed
+// content was verified before and has not expired.
+func (c *VerifyCache) verified(jwt *jwt) bool {
+	key, digest := verifyCacheKeyOf(jwt), verifyCacheDigest(jwt)
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+
+	return ok && entry.digest == digest && timeFunc().Before(entry.expires)
+}
+
+// add remembers a verified token until its exp claim
+func (c *VerifyCache) add(jwt *jwt) {
+	exp := jwt.claimsPayload.ExpirationTime
+	if c.size <= 0 || exp == nil {
+		return
+	}
+
+	key := verifyCacheKeyOf(jwt)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[key]; !ok {
+		if len(c.order) < c.size {
+			c.order = append(c.order, key)
+		} else {
+			delete(c.entries, c.order[c.next])
+			c.order[c.next] = key
+			c.next = (c.next + 1) % c.size
+		}
+	}
+
+	c.entries[key] = verifyCacheEntry{digest: verifyCacheDigest(jwt), expires: exp.Time}
+}
+
+func verifyCacheKeyOf(jwt *jwt) verifyCacheKey {
+	return verifyCacheKey{kid: jwt.Header.KeyID, signature: string(jwt.Signature)}
+}
+
+// verifyCacheDigest hashes the header and payload the signature covers
+func verifyCacheDigest(jwt *jwt) [sha256.Size]byte {
+	h := sha256.New()
+	h.Write(jwt.headerRaw)
+	h.Write(separator)
+	h.Write(jwt.payloadRaw)
+
+	var digest [sha256.Size]byte
+	h.Sum(digest[:0])
+
+	return digest
+}
@@ -0,0 +1,99 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// countingValidator counts the signatures it verifies
+type countingValidator struct {
+	Validator
+	validated int
+}
+
+func (v *countingValidator) validate(jwt *jwt) (bool, error) {
+	v.validated++
+	return v.Validator.validate(jwt)
+}
+
+func TestVerifyCache(t *testing.T) {
+	now := time.Unix(1516239022, 0)
+	timeFunc = func() time.Time { return now }
+	defer func() { timeFunc = time.Now }()
+
+	hs := NewHSValidator(HS256)
+	hs.Key = []byte("bogokey")
+	v := &countingValidator{Validator: hs}
+
+	dec := NewDecoder(nil, v)
+	dec.Cache = NewVerifyCache(2)
+	dec.Leeway = time.Hour
+
+	token := signTestToken(t, hs, Header{Type: "JWT", KeyID: "k1"}, &Payload{Subject: "1234567890", ExpirationTime: NewNumericDate(now.Add(time.Minute))})
+
+	for i, expected := range []bool{false, true} {
+		result, err := dec.verify(token, &Payload{})
+		if err != nil {
+			t.Fatalf("Didn't expect verifying a token to return an error: %s", err)
+		}
+
+		if result.Cached != expected {
+			t.Errorf("Expected attempt %d to be cached %t; got %t", i, expected, result.Cached)
+		}
+	}
+
+	if v.validated != 1 {
+		t.Errorf("Expected a retried token to be verified once; got %d", v.validated)
+	}
+
+	fields := strings.Split(token, ".")
+	forged := fields[0] + "." + encodeSegment([]byte(`{"sub":"admin","exp":1516239082}`)) + "." + fields[2]
+	if err := dec.Verify(forged, &Payload{}); err != ErrBadSignature {
+		t.Errorf("Expected a forged payload with a cached signature to return %s; got %v", ErrBadSignature, err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if result, err := dec.verify(token, &Payload{}); err != nil || result.Cached {
+		t.Errorf("Expected a token past its exp to be verified again; got %v", err)
+	}
+
+	unbounded := signTestToken(t, hs, Header{Type: "JWT"}, &Payload{Subject: "1234567890"})
+	dec.Cache = NewVerifyCache(2)
+	dec.Verify(unbounded, &Payload{})
+
+	if n := dec.Cache.Len(); n != 0 {
+		t.Errorf("Expected a token without an exp not to be cached; got %d tokens", n)
+	}
+
+	var tokens []string
+	for _, sub := range []string{"a", "b", "c"} {
+		tokens = append(tokens, signTestToken(t, hs, Header{Type: "JWT"}, &Payload{Subject: sub, ExpirationTime: NewNumericDate(now.Add(time.Minute))}))
+		dec.Verify(tokens[len(tokens)-1], &Payload{})
+	}
+
+	if n := dec.Cache.Len(); n != 2 {
+		t.Errorf("Expected the cache to hold 2 tokens; got %d", n)
+	}
+
+	if result, _ := dec.verify(tokens[0], &Payload{}); result.Cached {
+		t.Errorf("Expected the oldest token to be evicted")
+	}
+
+	if result, _ := dec.verify(tokens[2], &Payload{}); !result.Cached {
+		t.Errorf("Expected the newest token to be cached")
+	}
+}
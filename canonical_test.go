@@ -0,0 +1,79 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCanonicalizeJCS(t *testing.T) {
+	cases := []struct {
+		Input    string
+		Expected string
+		Reason   string
+	}{
+		{
+			`{"numbers":[333333333.33333329,1E30,4.50,2e-3,0.000000000000000000000000001],"string":"\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/","literals":[null,true,false]}`,
+			`{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`,
+			"the RFC 8785 example should be canonicalized",
+		},
+		{
+			`{"\u20ac":1,"\r":2,"\ufb33":3,"1":4,"\ud83d\ude00":5,"\u0080":6,"\u00f6":7}`,
+			"{\"\\r\":2,\"1\":4,\"\u0080\":6,\"ö\":7,\"€\":1,\"😀\":5,\"\ufb33\":3}",
+			"members should be sorted by UTF-16 code units",
+		},
+		{`[-0, 1.0, 100000000000000000000000, "<&>\u2028"]`, "[0,1,1e+23,\"<&>\u2028\"]", "numbers and strings should not be escaped beyond RFC 8785"},
+	}
+
+	for _, c := range cases {
+		b, err := CanonicalizeJCS([]byte(c.Input))
+
+		if err != nil || string(b) != c.Expected {
+			t.Errorf("Expected %s; %s: got %s and %v", c.Expected, c.Reason, b, err)
+		}
+	}
+
+	if _, err := CanonicalizeJCS([]byte(`[1e400]`)); err != ErrNotCanonicalizable {
+		t.Errorf("Expected a number out of range to return %s; got %v", ErrNotCanonicalizable, err)
+	}
+}
+
+func TestEncoderCanonicalize(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	enc := NewEncoder(nil, v)
+	enc.Canonicalize = CanonicalizeJCS
+
+	first, err := enc.Sign(map[string]interface{}{"b": 2.50, "a": "<x>"})
+	if err != nil {
+		t.Fatalf("Didn't expect signing a canonical payload to return an error: %s", err)
+	}
+
+	second, _ := enc.Sign(&struct {
+		B float64 `json:"b"`
+		A string  `json:"a"`
+	}{2.5, "<x>"})
+
+	if first != second {
+		t.Errorf("Expected equal payloads to produce identical tokens\n%+v\n%+v", first, second)
+	}
+
+	payload := map[string]interface{}{}
+	if err := NewDecoder(bytes.NewBufferString(string(first)), v).Decode(&payload); err != nil || payload["a"] != "<x>" {
+		t.Errorf("Expected a canonical token to decode; got %v and %v", payload, err)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// A ClaimsBuilder composes the claims of a token one at a time, e.g.
+//
+//	claims := jwt.NewClaims().Issuer("x").ExpiresIn(15 * time.Minute).Set("role", "admin")
+//	token, err := enc.Sign(claims)
+//
+// The first claim that cannot be marshaled is returned as an error when the
+// claims are marshaled.
+type ClaimsBuilder struct {
+	claims RawClaims
+	err    error
+}
+
+// NewClaims constructs an empty ClaimsBuilder.
+func NewClaims() *ClaimsBuilder {
+	return &ClaimsBuilder{claims: RawClaims{}}
+}
+
+// Set sets a claim with a given name to the JSON of a given value.
+func (b *ClaimsBuilder) Set(name string, value interface{}) *ClaimsBuilder {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		if b.err == nil {
+			b.err = err
+		}
+
+		return b
+	}
+
+	b.claims[name] = raw
+
+	return b
+}
+
+// Issuer sets the iss claim.
+func (b *ClaimsBuilder) Issuer(issuer string) *ClaimsBuilder {
+	return b.Set("iss", issuer)
+}
+
+// Subject sets the sub claim.
+func (b *ClaimsBuilder) Subject(subject string) *ClaimsBuilder {
+	return b.Set("sub", subject)
+}
+
+// Audience sets the aud claim, as a string when there is a single recipient.
+func (b *ClaimsBuilder) Audience(recipients ...string) *ClaimsBuilder {
+	return b.Set("aud", Audience(recipients))
+}
+
+// ID sets the jti claim.
+func (b *ClaimsBuilder) ID(id string) *ClaimsBuilder {
+	return b.Set("jti", id)
+}
+
+// ExpiresAt sets the exp claim.
+func (b *ClaimsBuilder) ExpiresAt(t time.Time) *ClaimsBuilder {
+	return b.Set("exp", NewNumericDate(t))
+}
+
+// ExpiresIn sets the exp claim to a given duration from now.
+func (b *ClaimsBuilder) ExpiresIn(d time.Duration) *ClaimsBuilder {
+	return b.ExpiresAt(timeFunc().Add(d))
+}
+
+// NotBefore sets the nbf claim.
+func (b *ClaimsBuilder) NotBefore(t time.Time) *ClaimsBuilder {
+	return b.Set("nbf", NewNumericDate(t))
+}
+
+// IssuedAt sets the iat claim.
+func (b *ClaimsBuilder) IssuedAt(t time.Time) *ClaimsBuilder {
+	return b.Set("iat", NewNumericDate(t))
+}
+
+// Claims returns the claims set so far and the first error setting them.
+func (b *ClaimsBuilder) Claims() (RawClaims, error) {
+	return b.claims, b.err
+}
+
+// MarshalJSON encodes the claims as a JSON object so that the builder can be
+// signed by an Encoder as is.
+func (b *ClaimsBuilder) MarshalJSON() ([]byte, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	return json.Marshal(b.claims)
+}
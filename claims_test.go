@@ -0,0 +1,70 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClaimsBuilder(t *testing.T) {
+	defer func() { timeFunc = time.Now }()
+
+	now := time.Unix(1700000000, 0)
+	timeFunc = func() time.Time { return now }
+
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	claims := NewClaims().
+		Issuer("https://issuer.example").
+		Subject("1234567890").
+		Audience("api").
+		ID("abc").
+		IssuedAt(now).
+		ExpiresIn(15*time.Minute).
+		Set("role", "admin")
+
+	token, err := NewEncoder(nil, v).Sign(claims)
+	if err != nil {
+		t.Fatalf("Didn't expect signing built claims to return an error: %s", err)
+	}
+
+	var decoded struct {
+		Payload
+		Role string `json:"role"`
+	}
+
+	if err := NewDecoder(nil, v).Verify(string(token), &decoded); err != nil {
+		t.Fatalf("Didn't expect verifying built claims to return an error: %s", err)
+	}
+
+	if decoded.Issuer != "https://issuer.example" || decoded.Subject != "1234567890" || !decoded.Audience.Contains("api") || decoded.JWTId != "abc" || decoded.Role != "admin" {
+		t.Errorf("Expected the built claims; got %#v", decoded)
+	}
+
+	if !decoded.ExpirationTime.Equal(now.Add(15*time.Minute)) || !decoded.IssuedAt.Equal(now) {
+		t.Errorf("Expected exp to be 15 minutes after iat %s; got %s", now, decoded.ExpirationTime)
+	}
+
+	invalid := NewClaims().Subject("1234567890").Set("callback", func() {})
+
+	if _, err := invalid.Claims(); err == nil {
+		t.Errorf("Expected a claim that cannot be marshaled to return an error")
+	}
+
+	if _, err := NewEncoder(nil, v).Sign(invalid); err == nil {
+		t.Errorf("Expected signing claims that cannot be marshaled to return an error")
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+)
+
+// ErrCertificateMismatch is returned when the client certificate presented on
+// a connection is not the one a token was bound to
+var ErrCertificateMismatch = errors.New("client certificate does not match the token confirmation")
+
+// A Confirmation is the cnf claim defined by RFC 7800. It binds a token to a
+// key held by the presenter so that a stolen token is useless without it.
+type Confirmation struct {
+	// X509ThumbprintS256 is the base64url encoded SHA-256 digest of the DER
+	// encoded certificate the token is bound to, as used by RFC 8705.
+	X509ThumbprintS256 string `json:"x5t#S256,omitempty"`
+	// JWKThumbprint is the RFC 7638 thumbprint of the public key the token is
+	// bound to, as used by DPoP.
+	JWKThumbprint string `json:"jkt,omitempty"`
+}
+
+// CertificateThumbprintS256 returns the x5t#S256 thumbprint of a certificate.
+func CertificateThumbprintS256(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return encodeSegment(sum[:])
+}
+
+// NewCertificateConfirmation constructs a Confirmation binding a token to the
+// given certificate.
+func NewCertificateConfirmation(cert *x509.Certificate) *Confirmation {
+	return &Confirmation{X509ThumbprintS256: CertificateThumbprintS256(cert)}
+}
+
+// NewKeyConfirmation constructs a Confirmation binding a token to the given
+// public key.
+func NewKeyConfirmation(jwk *JSONWebKey) *Confirmation {
+	return &Confirmation{JWKThumbprint: jwk.Thumbprint()}
+}
+
+// VerifyCertificateBinding asserts that the client certificate presented on a
+// TLS connection is the one the confirmation was issued for.
+func VerifyCertificateBinding(cnf *Confirmation, state *tls.ConnectionState) error {
+	if cnf == nil || cnf.X509ThumbprintS256 == "" {
+		return ErrCertificateMismatch
+	}
+
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return ErrCertificateMismatch
+	}
+
+	thumbprint := CertificateThumbprintS256(state.PeerCertificates[0])
+
+	if subtle.ConstantTimeCompare([]byte(thumbprint), []byte(cnf.X509ThumbprintS256)) != 1 {
+		return ErrCertificateMismatch
+	}
+
+	return nil
+}
+
+// confirmationOf returns the cnf claim of a verified payload, if it has one
+func confirmationOf(claimsRaw []byte) *Confirmation {
+	var claims struct {
+		Confirmation *Confirmation `json:"cnf"`
+	}
+
+	json.NewDecoder(bytes.NewReader(claimsRaw)).Decode(&claims)
+
+	return claims.Confirmation
+}
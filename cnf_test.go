@@ -0,0 +1,131 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// testCertificate returns a self signed certificate for the test key pair
+func testCertificate(t *testing.T, name string) *x509.Certificate {
+	v := testRSValidator(t)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, v.PublicKey, v.PrivateKey)
+	if err != nil {
+		t.Fatalf("Didn't expect creating a test certificate to return an error: %s", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Didn't expect parsing a test certificate to return an error: %s", err)
+	}
+
+	return cert
+}
+
+func TestVerifyCertificateBinding(t *testing.T) {
+	client := testCertificate(t, "client")
+	other := testCertificate(t, "other")
+	cnf := NewCertificateConfirmation(client)
+
+	cases := []struct {
+		Confirmation  *Confirmation
+		State         *tls.ConnectionState
+		ExpectedError error
+		Reason        string
+	}{
+		{cnf, &tls.ConnectionState{PeerCertificates: []*x509.Certificate{client}}, nil, "the bound certificate should be accepted"},
+		{cnf, &tls.ConnectionState{PeerCertificates: []*x509.Certificate{other}}, ErrCertificateMismatch, "another certificate should be rejected"},
+		{cnf, &tls.ConnectionState{}, ErrCertificateMismatch, "a connection without a client certificate should be rejected"},
+		{cnf, nil, ErrCertificateMismatch, "a request without TLS should be rejected"},
+		{nil, &tls.ConnectionState{PeerCertificates: []*x509.Certificate{client}}, ErrCertificateMismatch, "a token without a confirmation should be rejected"},
+	}
+
+	for _, c := range cases {
+		if err := VerifyCertificateBinding(c.Confirmation, c.State); err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		}
+	}
+}
+
+func TestCertificateThumbprintS256(t *testing.T) {
+	cert := testCertificate(t, "client")
+
+	if thumbprint := CertificateThumbprintS256(cert); len(thumbprint) != 43 {
+		t.Errorf("Expected an unpadded 43 character thumbprint; got %s", thumbprint)
+	}
+}
+
+func TestRequireCertificateBinding(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	client := testCertificate(t, "client")
+	other := testCertificate(t, "other")
+
+	bound := signTestToken(t, v, Header{Type: "JWT"}, &AccessTokenClaims{Subject: "1234567890", Confirmation: NewCertificateConfirmation(client)})
+	unbound := signTestToken(t, v, Header{Type: "JWT"}, &AccessTokenClaims{Subject: "1234567890"})
+	armored, _ := ArmorHex.Wrap(bound)
+
+	cases := []struct {
+		ExpectedError error
+		Reason        string
+		Token         string
+		State         *tls.ConnectionState
+	}{
+		{nil, "the token is bound to the client certificate", bound, &tls.ConnectionState{PeerCertificates: []*x509.Certificate{client}}},
+		{nil, "an armored token is bound to the client certificate", armored, &tls.ConnectionState{PeerCertificates: []*x509.Certificate{client}}},
+		{ErrCertificateMismatch, "the token is bound to another certificate", bound, &tls.ConnectionState{PeerCertificates: []*x509.Certificate{other}}},
+		{ErrCertificateMismatch, "the request is not over TLS", bound, nil},
+		{ErrCertificateMismatch, "the token has no cnf claim", unbound, &tls.ConnectionState{PeerCertificates: []*x509.Certificate{client}}},
+	}
+
+	dec := NewDecoder(nil, v)
+	dec.RequireCertificateBinding = true
+	dec.AcceptArmor = true
+
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", "Bearer "+c.Token)
+		r.TLS = c.State
+
+		if _, err := dec.VerifyRequest(r, AuthorizationHeader, &AccessTokenClaims{}); err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+bound)
+	r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{client}}
+
+	if _, err := dec.VerifyRequest(r, AuthorizationHeader, &Payload{}); err != nil {
+		t.Errorf("Didn't expect claims without a cnf field to lose the binding of a token: %s", err)
+	}
+}
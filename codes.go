@@ -0,0 +1,187 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import "errors"
+
+// An ErrorCode identifies an error of this package independently of its
+// message, so applications can render their own messages for end users.
+type ErrorCode string
+
+const (
+	// CodeUnknown is the code of errors that did not originate in this package
+	CodeUnknown ErrorCode = "unknown"
+	// CodeMalformedToken is the code of ErrMalformedToken
+	CodeMalformedToken ErrorCode = "malformed_token"
+	// CodeBadSignature is the code of ErrBadSignature
+	CodeBadSignature ErrorCode = "bad_signature"
+	// CodeAlgorithmNotImplemented is the code of ErrAlgorithmNotImplemented
+	CodeAlgorithmNotImplemented ErrorCode = "algorithm_not_implemented"
+	// CodeInvalidIssuer is the code of ErrInvalidIssuer
+	CodeInvalidIssuer ErrorCode = "invalid_issuer"
+	// CodeInvalidAudience is the code of ErrInvalidAudience
+	CodeInvalidAudience ErrorCode = "invalid_audience"
+	// CodeInvalidTenant is the code of ErrInvalidTenant
+	CodeInvalidTenant ErrorCode = "invalid_tenant"
+	// CodeInvalidTokenUse is the code of ErrInvalidTokenUse
+	CodeInvalidTokenUse ErrorCode = "invalid_token_use"
+	// CodeTokenExpired is the code of ErrTokenExpired
+	CodeTokenExpired ErrorCode = "token_expired"
+	// CodeTokenNotYetValid is the code of ErrTokenNotYetValid
+	CodeTokenNotYetValid ErrorCode = "token_not_yet_valid"
+	// CodeTokenUsedBeforeIssued is the code of ErrTokenUsedBeforeIssued
+	CodeTokenUsedBeforeIssued ErrorCode = "token_used_before_issued"
+	// CodeTokenTooOld is the code of ErrTokenTooOld
+	CodeTokenTooOld ErrorCode = "token_too_old"
+	// CodeClaimTooLarge is the code of ErrClaimTooLarge
+	CodeClaimTooLarge ErrorCode = "claim_too_large"
+	// CodeMissingClaim is the code of ErrMissingClaim
+	CodeMissingClaim ErrorCode = "missing_claim"
+	// CodeUnknownKey is the code of ErrUnknownKey
+	CodeUnknownKey ErrorCode = "unknown_key"
+	// CodeInvalidNonce is the code of ErrInvalidNonce
+	CodeInvalidNonce ErrorCode = "invalid_nonce"
+	// CodeUnknownVersion is the code of ErrUnknownVersion
+	CodeUnknownVersion ErrorCode = "unknown_version"
+	// CodeNoValidator is the code of ErrNoValidator
+	CodeNoValidator ErrorCode = "no_validator"
+	// CodeInvalidType is the code of ErrInvalidType
+	CodeInvalidType ErrorCode = "invalid_type"
+	// CodeCertificateMismatch is the code of ErrCertificateMismatch
+	CodeCertificateMismatch ErrorCode = "certificate_mismatch"
+	// CodeKeyMismatch is the code of ErrKeyMismatch
+	CodeKeyMismatch ErrorCode = "key_mismatch"
+	// CodeInvalidProof is the code of ErrInvalidProof
+	CodeInvalidProof ErrorCode = "invalid_proof"
+	// CodeInvalidSPIFFEID is the code of ErrInvalidSPIFFEID
+	CodeInvalidSPIFFEID ErrorCode = "invalid_spiffe_id"
+	// CodeNotCanonicalizable is the code of ErrNotCanonicalizable
+	CodeNotCanonicalizable ErrorCode = "not_canonicalizable"
+	// CodeVerifyOnly is the code of ErrVerifyOnly
+	CodeVerifyOnly ErrorCode = "verify_only"
+	// CodePayloadTooLarge is the code of ErrPayloadTooLarge
+	CodePayloadTooLarge ErrorCode = "payload_too_large"
+	// CodeInvalidAttestation is the code of ErrInvalidAttestation
+	CodeInvalidAttestation ErrorCode = "invalid_attestation"
+	// CodeBrokenLineage is the code of ErrBrokenLineage
+	CodeBrokenLineage ErrorCode = "broken_lineage"
+	// CodeTokenReplayed is the code of ErrTokenReplayed
+	CodeTokenReplayed ErrorCode = "token_replayed"
+	// CodeInvalidSectorIdentifier is the code of ErrInvalidSectorIdentifier
+	CodeInvalidSectorIdentifier ErrorCode = "invalid_sector_identifier"
+	// CodeUntrustedCertificate is the code of ErrUntrustedCertificate
+	CodeUntrustedCertificate ErrorCode = "untrusted_certificate"
+	// CodeWeakEntropy is the code of ErrWeakEntropy
+	CodeWeakEntropy ErrorCode = "weak_entropy"
+	// CodeTokenNotFound is the code of ErrTokenNotFound
+	CodeTokenNotFound ErrorCode = "token_not_found"
+	// CodeInvalidVault is the code of ErrInvalidVault
+	CodeInvalidVault ErrorCode = "invalid_vault"
+	// CodeUntrustedKeySet is the code of ErrUntrustedKeySet
+	CodeUntrustedKeySet ErrorCode = "untrusted_key_set"
+	// CodeDecryptionFailed is the code of ErrDecryptionFailed
+	CodeDecryptionFailed ErrorCode = "decryption_failed"
+	// CodeSigningMismatch is the code of ErrSigningMismatch
+	CodeSigningMismatch ErrorCode = "signing_mismatch"
+	// CodeInvalidPrivateKey is the code of ErrInvalidPrivateKey
+	CodeInvalidPrivateKey ErrorCode = "invalid_private_key"
+	// CodeIncorrectPassphrase is the code of ErrIncorrectPassphrase
+	CodeIncorrectPassphrase ErrorCode = "incorrect_passphrase"
+	// CodeMissingToken is the code of ErrMissingToken
+	CodeMissingToken ErrorCode = "missing_token"
+	// CodeInsufficientScope is the code of ErrInsufficientScope
+	CodeInsufficientScope ErrorCode = "insufficient_scope"
+	// CodeInvalidRequestObject is the code of ErrInvalidRequestObject
+	CodeInvalidRequestObject ErrorCode = "invalid_request_object"
+)
+
+// errorCodes pairs the errors of this package with their codes
+var errorCodes = []struct {
+	err  error
+	code ErrorCode
+}{
+	{ErrMalformedToken, CodeMalformedToken},
+	{ErrBadSignature, CodeBadSignature},
+	{ErrAlgorithmNotImplemented, CodeAlgorithmNotImplemented},
+	{ErrInvalidIssuer, CodeInvalidIssuer},
+	{ErrInvalidAudience, CodeInvalidAudience},
+	{ErrInvalidTenant, CodeInvalidTenant},
+	{ErrInvalidTokenUse, CodeInvalidTokenUse},
+	{ErrTokenExpired, CodeTokenExpired},
+	{ErrTokenNotYetValid, CodeTokenNotYetValid},
+	{ErrTokenUsedBeforeIssued, CodeTokenUsedBeforeIssued},
+	{ErrTokenTooOld, CodeTokenTooOld},
+	{ErrMissingClaim, CodeMissingClaim},
+	{ErrClaimTooLarge, CodeClaimTooLarge},
+	{ErrUnknownKey, CodeUnknownKey},
+	{ErrInvalidNonce, CodeInvalidNonce},
+	{ErrUnknownVersion, CodeUnknownVersion},
+	{ErrNoValidator, CodeNoValidator},
+	{ErrInvalidType, CodeInvalidType},
+	{ErrCertificateMismatch, CodeCertificateMismatch},
+	{ErrKeyMismatch, CodeKeyMismatch},
+	{ErrInvalidProof, CodeInvalidProof},
+	{ErrInvalidSPIFFEID, CodeInvalidSPIFFEID},
+	{ErrNotCanonicalizable, CodeNotCanonicalizable},
+	{ErrVerifyOnly, CodeVerifyOnly},
+	{ErrPayloadTooLarge, CodePayloadTooLarge},
+	{ErrInvalidAttestation, CodeInvalidAttestation},
+	{ErrBrokenLineage, CodeBrokenLineage},
+	{ErrTokenReplayed, CodeTokenReplayed},
+	{ErrInvalidSectorIdentifier, CodeInvalidSectorIdentifier},
+	{ErrUntrustedCertificate, CodeUntrustedCertificate},
+	{ErrWeakEntropy, CodeWeakEntropy},
+	{ErrTokenNotFound, CodeTokenNotFound},
+	{ErrInvalidVault, CodeInvalidVault},
+	{ErrUntrustedKeySet, CodeUntrustedKeySet},
+	{ErrDecryptionFailed, CodeDecryptionFailed},
+	{ErrSigningMismatch, CodeSigningMismatch},
+	{ErrInvalidPrivateKey, CodeInvalidPrivateKey},
+	{ErrIncorrectPassphrase, CodeIncorrectPassphrase},
+	{ErrMissingToken, CodeMissingToken},
+	{ErrInsufficientScope, CodeInsufficientScope},
+	{ErrInvalidRequestObject, CodeInvalidRequestObject},
+}
+
+// A MessageFunc renders the message shown to end users for an error code, e.g.
+// "your session has expired" for CodeTokenExpired. It returns an empty string
+// for codes it has no message for.
+type MessageFunc func(code ErrorCode) string
+
+// CodeOf returns the code of an error of this package, including errors that
+// wrap one, or CodeUnknown.
+func CodeOf(err error) ErrorCode {
+	for _, c := range errorCodes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+
+	return CodeUnknown
+}
+
+// Message renders the message for an error with a given MessageFunc. The
+// message of the error itself is returned when render has none.
+func Message(err error, render MessageFunc) string {
+	if err == nil {
+		return ""
+	}
+
+	if message := render(CodeOf(err)); message != "" {
+		return message
+	}
+
+	return err.Error()
+}
@@ -0,0 +1,63 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCodeOf(t *testing.T) {
+	for _, c := range errorCodes {
+		if code := CodeOf(c.err); code != c.code {
+			t.Errorf("Expected %s to have code %s; got %s", c.err, c.code, code)
+		}
+	}
+
+	if code := CodeOf(fmt.Errorf("%w: sub", ErrMissingClaim)); code != CodeMissingClaim {
+		t.Errorf("Expected a wrapped error to have the code of the error it wraps; got %s", code)
+	}
+
+	if code := CodeOf(errors.New("other")); code != CodeUnknown {
+		t.Errorf("Expected a foreign error to have code %s; got %s", CodeUnknown, code)
+	}
+}
+
+func TestMessage(t *testing.T) {
+	render := func(code ErrorCode) string {
+		switch code {
+		case CodeTokenExpired, CodeTokenTooOld:
+			return "Ihre Sitzung ist abgelaufen"
+		}
+
+		return ""
+	}
+
+	cases := []struct {
+		Err      error
+		Expected string
+	}{
+		{ErrTokenExpired, "Ihre Sitzung ist abgelaufen"},
+		{ErrBadSignature, ErrBadSignature.Error()},
+		{nil, ""},
+	}
+
+	for _, c := range cases {
+		if message := Message(c.Err, render); message != c.Expected {
+			t.Errorf("Expected the message of %v to be %q; got %q", c.Err, c.Expected, message)
+		}
+	}
+}
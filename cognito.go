@@ -0,0 +1,102 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+const (
+	// CognitoIDToken is the token_use of an Amazon Cognito identity token
+	CognitoIDToken = "id"
+	// CognitoAccessToken is the token_use of an Amazon Cognito access token
+	CognitoAccessToken = "access"
+)
+
+// CognitoClaims are the claims found in identity and access tokens issued by an
+// Amazon Cognito user pool.
+type CognitoClaims struct {
+	Payload
+	TokenUse      string   `json:"token_use,omitempty"`
+	ClientID      string   `json:"client_id,omitempty"`
+	Username      string   `json:"username,omitempty"`
+	UserPoolUser  string   `json:"cognito:username,omitempty"`
+	Groups        []string `json:"cognito:groups,omitempty"`
+	Scope         string   `json:"scope,omitempty"`
+	Email         string   `json:"email,omitempty"`
+	EmailVerified bool     `json:"email_verified,omitempty"`
+	AuthTime      int64    `json:"auth_time,omitempty"`
+	EventID       string   `json:"event_id,omitempty"`
+	OriginJTI     string   `json:"origin_jti,omitempty"`
+}
+
+// A CognitoPreset checks the claims of a token issued by an Amazon Cognito user
+// pool.
+type CognitoPreset struct {
+	// Region is the AWS region hosting the user pool, e.g. us-east-1
+	Region string
+	// UserPoolID is the id of the user pool, e.g. us-east-1_AbCdEf123
+	UserPoolID string
+	// ClientID is the app client id the token must have been issued to. An
+	// empty ClientID skips the check.
+	ClientID string
+	// TokenUse is the kind of token accepted, either CognitoIDToken or
+	// CognitoAccessToken.
+	TokenUse string
+}
+
+// NewCognitoPreset constructs a CognitoPreset accepting tokens of the given use
+// issued by a user pool to an app client.
+func NewCognitoPreset(region, userPoolID, clientID, tokenUse string) CognitoPreset {
+	return CognitoPreset{Region: region, UserPoolID: userPoolID, ClientID: clientID, TokenUse: tokenUse}
+}
+
+// Issuer returns the iss claim of tokens issued by the user pool.
+func (p CognitoPreset) Issuer() string {
+	return "https://cognito-idp." + p.Region + ".amazonaws.com/" + p.UserPoolID
+}
+
+// KeysURL returns the location of the JSON Web Key Set of the user pool.
+func (p CognitoPreset) KeysURL() string {
+	return p.Issuer() + "/.well-known/jwks.json"
+}
+
+// Validate asserts the token was issued by the user pool for the configured use
+// and app client. Identity tokens carry the app client in aud while access
+// tokens carry it in client_id.
+func (p CognitoPreset) Validate(claims *CognitoClaims) error {
+	if claims.Issuer != p.Issuer() {
+		return ErrInvalidIssuer
+	}
+
+	if claims.TokenUse != p.TokenUse {
+		return ErrInvalidTokenUse
+	}
+
+	if p.ClientID == "" {
+		return nil
+	}
+
+	switch claims.TokenUse {
+	case CognitoIDToken:
+		if !claims.Audience.Contains(p.ClientID) {
+			return ErrInvalidAudience
+		}
+	case CognitoAccessToken:
+		if claims.ClientID != p.ClientID {
+			return ErrInvalidAudience
+		}
+	default:
+		return ErrInvalidTokenUse
+	}
+
+	return nil
+}
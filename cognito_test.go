@@ -0,0 +1,69 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestCognitoPresetValidate(t *testing.T) {
+	idPreset := NewCognitoPreset("us-east-1", "us-east-1_AbCdEf123", "client", CognitoIDToken)
+	accessPreset := NewCognitoPreset("us-east-1", "us-east-1_AbCdEf123", "client", CognitoAccessToken)
+	issuer := "https://cognito-idp.us-east-1.amazonaws.com/us-east-1_AbCdEf123"
+
+	cases := []struct {
+		Preset        CognitoPreset
+		Claims        CognitoClaims
+		ExpectedError error
+		Reason        string
+	}{
+		{idPreset, CognitoClaims{Payload: Payload{Issuer: issuer, Audience: Audience{"client"}}, TokenUse: CognitoIDToken}, nil, "an identity token should be accepted"},
+		{accessPreset, CognitoClaims{Payload: Payload{Issuer: issuer}, TokenUse: CognitoAccessToken, ClientID: "client"}, nil, "an access token should be accepted"},
+		{idPreset, CognitoClaims{Payload: Payload{Issuer: issuer}, TokenUse: CognitoAccessToken, ClientID: "client"}, ErrInvalidTokenUse, "an access token should not be accepted as an identity token"},
+		{idPreset, CognitoClaims{Payload: Payload{Issuer: "https://cognito-idp.eu-west-1.amazonaws.com/us-east-1_AbCdEf123", Audience: Audience{"client"}}, TokenUse: CognitoIDToken}, ErrInvalidIssuer, "a token from another region should be rejected"},
+		{idPreset, CognitoClaims{Payload: Payload{Issuer: issuer, Audience: Audience{"other"}}, TokenUse: CognitoIDToken}, ErrInvalidAudience, "an identity token for another client should be rejected"},
+		{accessPreset, CognitoClaims{Payload: Payload{Issuer: issuer}, TokenUse: CognitoAccessToken, ClientID: "other"}, ErrInvalidAudience, "an access token for another client should be rejected"},
+	}
+
+	for _, c := range cases {
+		if err := c.Preset.Validate(&c.Claims); err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		}
+	}
+}
+
+func TestCognitoClaimsMapping(t *testing.T) {
+	raw := `{"sub":"abc","cognito:groups":["admin"],"cognito:username":"ben","token_use":"id","auth_time":1500000000}`
+	claims := &CognitoClaims{}
+
+	if err := json.NewDecoder(bytes.NewBufferString(raw)).Decode(claims); err != nil {
+		t.Fatalf("Didn't expect decoding cognito claims to return an error: %s", err)
+	}
+
+	if claims.Subject != "abc" || claims.UserPoolUser != "ben" || len(claims.Groups) != 1 || claims.AuthTime != 1500000000 {
+		t.Errorf("Cognito claims were not mapped: %+v", claims)
+	}
+}
+
+func TestCognitoKeysURL(t *testing.T) {
+	p := NewCognitoPreset("us-east-1", "us-east-1_AbCdEf123", "", CognitoIDToken)
+	expected := "https://cognito-idp.us-east-1.amazonaws.com/us-east-1_AbCdEf123/.well-known/jwks.json"
+
+	if url := p.KeysURL(); url != expected {
+		t.Errorf("Expected %s; got %s", expected, url)
+	}
+}
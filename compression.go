@@ -0,0 +1,99 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"io"
+)
+
+const (
+	// CompressionDeflate compresses payloads with DEFLATE as described by RFC 1951
+	CompressionDeflate = "DEF"
+	// CompressionGzip compresses payloads with gzip as described by RFC 1952
+	CompressionGzip = "GZIP"
+	// DefaultMaxInflatedSize is the largest a compressed payload may inflate to
+	// when a Decoder does not set MaxInflatedSize
+	DefaultMaxInflatedSize = 256 << 10
+)
+
+// ErrPayloadTooLarge is returned when a compressed payload inflates beyond
+// the limit of a Decoder
+var ErrPayloadTooLarge = errors.New("payload is too large")
+
+// compress compresses a payload with a given algorithm
+func compress(payload []byte, algorithm string) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+
+	var w io.WriteCloser
+	var err error
+
+	switch algorithm {
+	case CompressionDeflate:
+		w, err = flate.NewWriter(buf, flate.BestCompression)
+	case CompressionGzip:
+		w, err = gzip.NewWriterLevel(buf, gzip.BestCompression)
+	default:
+		return nil, ErrAlgorithmNotImplemented
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(payload); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// inflate decompresses a payload that must not inflate beyond limit bytes
+func inflate(payload []byte, algorithm string, limit int) ([]byte, error) {
+	var r io.ReadCloser
+	var err error
+
+	switch algorithm {
+	case CompressionDeflate:
+		r = flate.NewReader(bytes.NewReader(payload))
+	case CompressionGzip:
+		if r, err = gzip.NewReader(bytes.NewReader(payload)); err != nil {
+			return nil, ErrMalformedToken
+		}
+	default:
+		return nil, ErrAlgorithmNotImplemented
+	}
+	defer r.Close()
+
+	// One byte beyond the limit is read to tell a payload of exactly limit
+	// bytes from a larger one
+	value, err := io.ReadAll(io.LimitReader(r, int64(limit)+1))
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	if len(value) > limit {
+		return nil, ErrPayloadTooLarge
+	}
+
+	return value, nil
+}
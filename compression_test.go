@@ -0,0 +1,83 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCompressedPayload(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	type claims struct {
+		Payload
+		Groups []string `json:"groups"`
+	}
+
+	large := &claims{Groups: strings.Split(strings.Repeat("engineering,", 200), ",")}
+	small := &claims{Groups: []string{"engineering"}}
+
+	for _, algorithm := range []string{CompressionDeflate, CompressionGzip} {
+		enc := NewEncoder(nil, v)
+		enc.Compression = algorithm
+		enc.CompressionThreshold = 512
+
+		plain, _ := NewEncoder(nil, v).Sign(large)
+		token, err := enc.Sign(large)
+		if err != nil {
+			t.Fatalf("Didn't expect signing a compressed payload to return an error: %s", err)
+		}
+
+		if len(token) >= len(plain) {
+			t.Errorf("Expected %s to shrink the token; got %d bytes from %d", algorithm, len(token), len(plain))
+		}
+
+		decoded := &claims{}
+		result, err := NewDecoder(bytes.NewBufferString(string(token)), v).DecodeResult(decoded)
+		if err != nil {
+			t.Fatalf("Didn't expect decoding a compressed payload to return an error: %s", err)
+		}
+
+		if result.Header.Compression != algorithm || len(decoded.Groups) != len(large.Groups) {
+			t.Errorf("Expected the %s payload to inflate transparently; got %#v", algorithm, result.Header)
+		}
+
+		dec := NewDecoder(bytes.NewBufferString(string(token)), v)
+		dec.MaxInflatedSize = 1024
+
+		if err := dec.Decode(&claims{}); err != ErrPayloadTooLarge {
+			t.Errorf("Expected a payload inflating beyond the limit to return %s; got %v", ErrPayloadTooLarge, err)
+		}
+
+		if _, err := parseJWT(string(token), &claims{}); err != ErrMalformedToken {
+			t.Errorf("Expected compressed payloads to be rejected outside of a Decoder; got %v", err)
+		}
+
+		token, _ = enc.Sign(small)
+		if result, err := NewDecoder(bytes.NewBufferString(string(token)), v).DecodeResult(&claims{}); err != nil || result.Header.Compression != "" {
+			t.Errorf("Expected a payload under the threshold not to be compressed; got %#v and %v", result, err)
+		}
+	}
+
+	enc := NewEncoder(nil, v)
+	enc.Compression = "LZ4"
+
+	if _, err := enc.Sign(large); err != ErrAlgorithmNotImplemented {
+		t.Errorf("Expected an unknown compression to return %s; got %v", ErrAlgorithmNotImplemented, err)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"context"
+	"reflect"
+)
+
+// contextKey is the key the verification of a request is stored under in its
+// context
+type contextKey struct{}
+
+// contextEntry is a token verified in the scope of a request by a Decoder
+type contextEntry struct {
+	dec    *Decoder
+	token  string
+	result *DecodeResult
+	// claimsRaw is the payload of the token, decoded again for callers
+	// asking for claims of another type
+	claimsRaw []byte
+}
+
+// FromContext returns the result of the token verified by VerifyContext in the
+// scope of a request, so handlers further down a chain can read its claims
+// without verifying it again.
+func FromContext(ctx context.Context) (*DecodeResult, bool) {
+	entry, ok := ctx.Value(contextKey{}).(*contextEntry)
+	if !ok {
+		return nil, false
+	}
+
+	return entry.result, true
+}
+
+// VerifyContext verifies a token once in the scope of a request. The first
+// call verifies it as Verify does and returns a context carrying the result;
+// later calls of the same Decoder with that context and token reuse the result
+// instead, copying its claims into v when v is of the same type or decoding
+// them into v otherwise. Other Decoders verify the token again against their
+// own checks. The context is returned as is when the token is rejected.
+func (dec *Decoder) VerifyContext(ctx context.Context, token string, v interface{}) (context.Context, *DecodeResult, error) {
+	if entry, ok := ctx.Value(contextKey{}).(*contextEntry); ok && entry.dec == dec && entry.token == token {
+		if copyClaims(entry.result.Claims, v) {
+			return ctx, entry.result, nil
+		}
+
+		if err := dec.decodeClaims(entry.claimsRaw, v); err != nil {
+			return ctx, nil, err
+		}
+
+		result := *entry.result
+		result.Claims = v
+
+		return ctx, &result, nil
+	}
+
+	result, err := dec.verify(token, v)
+	if err != nil {
+		return ctx, nil, err
+	}
+
+	return context.WithValue(ctx, contextKey{}, &contextEntry{dec: dec, token: token, result: result, claimsRaw: result.claimsRaw}), result, nil
+}
+
+// copyClaims copies decoded claims into v and reports whether it could, which
+// requires both to be pointers to the same type
+func copyClaims(claims, v interface{}) bool {
+	src, dst := reflect.ValueOf(claims), reflect.ValueOf(v)
+
+	if src.Kind() != reflect.Ptr || dst.Kind() != reflect.Ptr || src.Type() != dst.Type() || src.IsNil() || dst.IsNil() {
+		return false
+	}
+
+	dst.Elem().Set(src.Elem())
+
+	return true
+}
+
+// claimsKey is the key claims of type T are stored under in a context
+type claimsKey[T any] struct{}
+
+// NewContextWithClaims returns a context carrying verified claims of type T,
+// e.g. for middleware to pass them on to handlers and downstream libraries.
+func NewContextWithClaims[T any](ctx context.Context, claims T) context.Context {
+	return context.WithValue(ctx, claimsKey[T]{}, claims)
+}
+
+// ClaimsFromContext returns the claims of type T carried by a context, either
+// as stored by NewContextWithClaims or as decoded by VerifyContext, e.g.
+//
+//	claims, ok := jwt.ClaimsFromContext[*MyClaims](ctx)
+func ClaimsFromContext[T any](ctx context.Context) (T, bool) {
+	if claims, ok := ctx.Value(claimsKey[T]{}).(T); ok {
+		return claims, true
+	}
+
+	if result, ok := FromContext(ctx); ok {
+		if claims, ok := result.Claims.(T); ok {
+			return claims, true
+		}
+	}
+
+	var zero T
+
+	return zero, false
+}
@@ -0,0 +1,151 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestVerifyContext(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	token := signTestToken(t, v, Header{Type: "JWT"}, &Payload{Subject: "1234567890"})
+	other := signTestToken(t, v, Header{Type: "JWT"}, &Payload{Subject: "0987654321"})
+
+	dec := NewDecoder(nil, v)
+
+	if _, ok := FromContext(context.Background()); ok {
+		t.Errorf("Expected no result in a context without a verified token")
+	}
+
+	ctx, result, err := dec.VerifyContext(context.Background(), token, &Payload{})
+	if err != nil {
+		t.Fatalf("Didn't expect verifying a token to return an error: %s", err)
+	}
+
+	if stored, ok := FromContext(ctx); !ok || stored != result {
+		t.Errorf("Expected the result to be stored in the context; got %v", stored)
+	}
+
+	cases := []struct {
+		Reason   string
+		Token    string
+		Claims   interface{}
+		Verified uint64
+	}{
+		{"the token was verified in the context", token, &Payload{}, 1},
+		{"another token is verified", other, &Payload{}, 2},
+		{"the claims are of another type", token, &map[string]interface{}{}, 2},
+	}
+
+	for _, c := range cases {
+		if _, _, err := dec.VerifyContext(ctx, c.Token, c.Claims); err != nil {
+			t.Errorf("Didn't expect verifying to return an error when %s: %s", c.Reason, err)
+		}
+
+		if verified := dec.Stats().Verified; verified != c.Verified {
+			t.Errorf("Expected %d verifications when %s; got %d", c.Verified, c.Reason, verified)
+		}
+	}
+
+	payload := &Payload{}
+	if _, _, err := dec.VerifyContext(ctx, token, payload); err != nil || payload.Subject != "1234567890" {
+		t.Errorf("Expected the claims to be copied from the context; got %#v and %v", payload, err)
+	}
+
+	if rejected, _, err := dec.VerifyContext(ctx, "not.a.token", &Payload{}); err == nil || rejected != ctx {
+		t.Errorf("Expected the context to be returned as is when the token is rejected; got %v", err)
+	}
+}
+
+func TestVerifyContextDecoders(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	token := signTestToken(t, v, Header{Type: "JWT"}, &Payload{Subject: "1234567890", Audience: Audience{"svc-a"}})
+
+	ctx, _, err := NewDecoder(nil, v).VerifyContext(context.Background(), token, &Payload{})
+	if err != nil {
+		t.Fatalf("Didn't expect verifying a token to return an error: %s", err)
+	}
+
+	strict := NewDecoder(nil, v)
+	strict.Audience = "svc-b"
+
+	if _, _, err := strict.VerifyContext(ctx, token, &Payload{}); err != ErrInvalidAudience {
+		t.Errorf("Expected %s when another decoder verified the token in the context; got %v", ErrInvalidAudience, err)
+	}
+}
+
+func TestVerifyContextReplay(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	token := signTestToken(t, v, Header{Type: "JWT"}, &Payload{JWTId: "one-time", ExpirationTime: NewNumericDate(time.Now().Add(time.Minute))})
+
+	dec := NewDecoder(nil, v)
+	dec.ReplayStore = NewMemoryReplayStore()
+
+	ctx, _, err := dec.VerifyContext(context.Background(), token, &Payload{})
+	if err != nil {
+		t.Fatalf("Didn't expect verifying a token to return an error: %s", err)
+	}
+
+	claims := map[string]interface{}{}
+	if _, result, err := dec.VerifyContext(ctx, token, &claims); err != nil || claims["jti"] != "one-time" || result.Claims != &claims {
+		t.Errorf("Expected claims of another type to be decoded from the context without replaying the token; got %v and %v", claims, err)
+	}
+
+	if entry := ctx.Value(contextKey{}).(*contextEntry); entry.result.Claims == &claims {
+		t.Errorf("Didn't expect the result stored in the context to be replaced")
+	}
+
+	if err := dec.Verify(token, &Payload{}); err != ErrTokenReplayed {
+		t.Errorf("Expected a token replayed outside the context to return %s; got %v", ErrTokenReplayed, err)
+	}
+}
+
+func TestClaimsContext(t *testing.T) {
+	type accountClaims struct {
+		Payload
+		Account string `json:"account"`
+	}
+
+	ctx := NewContextWithClaims(context.Background(), &accountClaims{Account: "acme"})
+
+	if claims, ok := ClaimsFromContext[*accountClaims](ctx); !ok || claims.Account != "acme" {
+		t.Errorf("Expected the stored claims; got %#v", claims)
+	}
+
+	if _, ok := ClaimsFromContext[*Payload](ctx); ok {
+		t.Errorf("Didn't expect claims of another type")
+	}
+
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	token := signTestToken(t, v, Header{Type: "JWT"}, &Payload{Subject: "1234567890"})
+
+	ctx, _, err := NewDecoder(nil, v).VerifyContext(context.Background(), token, &Payload{})
+	if err != nil {
+		t.Fatalf("Didn't expect verifying a token to return an error: %s", err)
+	}
+
+	if claims, ok := ClaimsFromContext[*Payload](ctx); !ok || claims.Subject != "1234567890" {
+		t.Errorf("Expected the claims decoded by VerifyContext; got %#v", claims)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// A NumericDate is a time encoded as the number of seconds since the epoch as
+// required of the exp, nbf and iat claims by RFC 7519.
+type NumericDate struct {
+	time.Time
+}
+
+// An rfc3339Payload accepts exp, nbf and iat claims encoded as RFC 3339
+// strings, as emitted by older versions of this package, by rewriting them as
+// NumericDates before decoding into v.
+type rfc3339Payload struct {
+	v interface{}
+}
+
+// NewNumericDate returns a NumericDate for a given time.
+func NewNumericDate(t time.Time) *NumericDate {
+	return &NumericDate{t}
+}
+
+// MarshalJSON encodes the date as seconds since the epoch.
+func (d NumericDate) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.FormatInt(d.Unix(), 10)), nil
+}
+
+// UnmarshalJSON accepts seconds since the epoch, including fractional seconds.
+func (d *NumericDate) UnmarshalJSON(b []byte) error {
+	var seconds float64
+	if err := json.Unmarshal(b, &seconds); err != nil {
+		return err
+	}
+
+	whole, fraction := math.Modf(seconds)
+	d.Time = time.Unix(int64(whole), int64(fraction*1e9))
+
+	return nil
+}
+
+// ClaimTimeLayouts are the layouts, in order of preference, that ClaimTimes
+// encoded as strings are parsed with. Times without a zone are taken to be in
+// UTC. It should be set once before any token is decoded.
+var ClaimTimeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05"}
+
+// A ClaimTime is a time claim of an issuer that emits times as strings rather
+// than NumericDates, e.g. "2023-01-02 15:04:05". It also accepts NumericDates.
+type ClaimTime struct {
+	time.Time
+	// Layout is the layout the time is encoded with, or empty for a
+	// NumericDate. Decoding sets it to the layout the claim was parsed with.
+	Layout string
+}
+
+// MarshalJSON encodes the time with its Layout, or as a NumericDate.
+func (t ClaimTime) MarshalJSON() ([]byte, error) {
+	if t.Layout == "" {
+		return NumericDate{t.Time}.MarshalJSON()
+	}
+
+	return json.Marshal(t.Format(t.Layout))
+}
+
+// UnmarshalJSON accepts a string in one of the ClaimTimeLayouts or a
+// NumericDate.
+func (t *ClaimTime) UnmarshalJSON(b []byte) error {
+	var value string
+	if err := json.Unmarshal(b, &value); err != nil {
+		var date NumericDate
+		if err := date.UnmarshalJSON(b); err != nil {
+			return err
+		}
+
+		*t = ClaimTime{Time: date.Time}
+
+		return nil
+	}
+
+	for _, layout := range ClaimTimeLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			*t = ClaimTime{Time: parsed, Layout: layout}
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%q matches none of the claim time layouts", value)
+}
+
+func (p *rfc3339Payload) UnmarshalJSON(b []byte) error {
+	var claims map[string]json.RawMessage
+	if err := json.Unmarshal(b, &claims); err != nil {
+		return json.Unmarshal(b, p.v)
+	}
+
+	for _, name := range []string{"exp", "nbf", "iat"} {
+		var value string
+		if raw, ok := claims[name]; !ok || json.Unmarshal(raw, &value) != nil {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return err
+		}
+
+		claims[name], _ = NumericDate{t}.MarshalJSON()
+	}
+
+	b, err := json.Marshal(claims)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, p.v)
+}
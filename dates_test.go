@@ -0,0 +1,116 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestNumericDateJSON(t *testing.T) {
+	expiry := time.Unix(1516239022, 0)
+
+	b, err := json.Marshal(&Payload{ExpirationTime: NewNumericDate(expiry)})
+	if err != nil || string(b) != `{"exp":1516239022}` {
+		t.Errorf("Expected exp to be encoded as a NumericDate; got %s and %v", b, err)
+	}
+
+	payload := &Payload{}
+	if err := json.Unmarshal([]byte(`{"iat":1516239022.5}`), payload); err != nil {
+		t.Fatalf("Didn't expect a fractional NumericDate to return an error: %s", err)
+	}
+
+	if !payload.IssuedAt.Equal(expiry.Add(500 * time.Millisecond)) {
+		t.Errorf("Expected iat to keep fractional seconds; got %s", payload.IssuedAt)
+	}
+
+	if err := json.Unmarshal([]byte(`{"iat":"2018-01-18T01:30:22Z"}`), payload); err == nil {
+		t.Errorf("Expected an RFC 3339 string to be rejected as a NumericDate")
+	}
+}
+
+func TestDecodeRFC3339Dates(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	legacy := map[string]interface{}{"sub": "1234567890", "exp": "2018-01-18T01:30:22Z", "nbf": 1516239000}
+	token := signTestToken(t, v, Header{Type: "JWT"}, legacy)
+
+	if err := NewDecoder(bytes.NewBufferString(token), v).Decode(&Payload{}); err != ErrMalformedToken {
+		t.Errorf("Expected an RFC 3339 exp to be rejected by default; got %v", err)
+	}
+
+	timeFunc = func() time.Time { return time.Unix(1516239010, 0) }
+	defer func() { timeFunc = time.Now }()
+
+	dec := NewDecoder(bytes.NewBufferString(token), v)
+	dec.AcceptRFC3339Dates = true
+
+	payload := &Payload{}
+	if err := dec.Decode(payload); err != nil {
+		t.Fatalf("Didn't expect an RFC 3339 exp to return an error when accepted: %s", err)
+	}
+
+	if payload.ExpirationTime.Unix() != 1516239022 || payload.NotBefore.Unix() != 1516239000 {
+		t.Errorf("Expected exp and nbf to decode to the same instants; got %s and %s", payload.ExpirationTime, payload.NotBefore)
+	}
+}
+
+func TestClaimTimeJSON(t *testing.T) {
+	type claims struct {
+		Updated ClaimTime `json:"updated_at"`
+	}
+
+	cases := []struct {
+		JSON     string
+		Expected time.Time
+		Layout   string
+		Reason   string
+	}{
+		{`{"updated_at":"2023-01-02 15:04:05"}`, time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC), "2006-01-02 15:04:05", "a time without a zone is in UTC"},
+		{`{"updated_at":"2023-01-02T15:04:05+01:00"}`, time.Date(2023, 1, 2, 14, 4, 5, 0, time.UTC), time.RFC3339, "an RFC 3339 time keeps its zone"},
+		{`{"updated_at":1672671845}`, time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC), "", "a NumericDate is accepted"},
+	}
+
+	for _, c := range cases {
+		var decoded claims
+		if err := json.Unmarshal([]byte(c.JSON), &decoded); err != nil {
+			t.Errorf("Didn't expect decoding %s to return an error: %s", c.JSON, err)
+			continue
+		}
+
+		if !decoded.Updated.Equal(c.Expected) || decoded.Updated.Layout != c.Layout {
+			t.Errorf("Expected %s with layout %q when %s; got %s with %q", c.Expected, c.Layout, c.Reason, decoded.Updated.Time, decoded.Updated.Layout)
+		}
+
+		if b, err := json.Marshal(decoded); err != nil || string(b) != c.JSON {
+			t.Errorf("Expected %s to encode as it was decoded; got %s and %v", c.JSON, b, err)
+		}
+	}
+
+	var decoded claims
+	if err := json.Unmarshal([]byte(`{"updated_at":"02/01/2023"}`), &decoded); err == nil {
+		t.Errorf("Expected a time matching no layout to return an error")
+	}
+
+	defer func(layouts []string) { ClaimTimeLayouts = layouts }(ClaimTimeLayouts)
+	ClaimTimeLayouts = []string{"02/01/2006"}
+
+	if err := json.Unmarshal([]byte(`{"updated_at":"02/01/2023"}`), &decoded); err != nil || decoded.Updated.Month() != time.January {
+		t.Errorf("Expected a configured layout to be used; got %s and %v", decoded.Updated.Time, err)
+	}
+}
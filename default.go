@@ -0,0 +1,53 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+var (
+	// DefaultEncoder is the Encoder used by Sign. It has no validator until one
+	// is configured, typically once during program start up:
+	//
+	//	jwt.DefaultEncoder = jwt.NewEncoder(nil, v)
+	//
+	// It must not be replaced while tokens are being signed.
+	DefaultEncoder = &Encoder{}
+
+	// DefaultDecoder is the Decoder used by Verify. Like DefaultEncoder it must
+	// be configured before use and not replaced while tokens are being verified.
+	DefaultDecoder = &Decoder{}
+)
+
+// Sign composes a new signed jwt from a given payload using DefaultEncoder. It
+// is safe to call from multiple goroutines.
+func Sign(v interface{}) (SignedToken, error) {
+	return DefaultEncoder.Sign(v)
+}
+
+// Verify verifies a given token and populates a given interface with its
+// claims using DefaultDecoder. It is safe to call from multiple goroutines.
+func Verify(token string, v interface{}) error {
+	return DefaultDecoder.Verify(token, v)
+}
+
+// SignWith composes a new signed jwt from a given payload with a given
+// validator, without configuring an Encoder.
+func SignWith(v interface{}, validator Validator) (SignedToken, error) {
+	return NewEncoder(nil, validator).Sign(v)
+}
+
+// VerifyWith verifies a given token with a given validator and populates a
+// given interface with its claims, without configuring a Decoder.
+func VerifyWith(token string, validator Validator, v interface{}) error {
+	return NewDecoder(nil, validator).Verify(token, v)
+}
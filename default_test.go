@@ -0,0 +1,102 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestDefaultSignVerify(t *testing.T) {
+	encoder, decoder := DefaultEncoder, DefaultDecoder
+	defer func() { DefaultEncoder, DefaultDecoder = encoder, decoder }()
+
+	if _, err := Sign(&Payload{}); err != ErrNoValidator {
+		t.Errorf("Expected signing without a configured validator to return %s; got %s", ErrNoValidator, err)
+	}
+
+	if err := Verify("eyJhbGciOiJub25lIn0K.e30k.", &Payload{}); err != ErrNoValidator {
+		t.Errorf("Expected verifying without a configured validator to return %s; got %s", ErrNoValidator, err)
+	}
+
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	DefaultEncoder = NewEncoder(nil, v)
+	DefaultDecoder = NewDecoder(nil, v)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			token, err := Sign(&Payload{Subject: "1234567890"})
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			payload := &Payload{}
+			if err := Verify(string(token), payload); err != nil {
+				errs <- err
+				return
+			}
+
+			if payload.Subject != "1234567890" {
+				t.Errorf("Expected the verified subject to be 1234567890; got %s", payload.Subject)
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("Didn't expect concurrent signing and verifying to return an error: %s", err)
+	}
+
+	if err := Verify("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.e30k.YQo=", &Payload{}); err != ErrBadSignature {
+		t.Errorf("Expected a bad signature to return %s; got %s", ErrBadSignature, err)
+	}
+}
+
+func TestSignVerifyWith(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	token, err := SignWith(&Payload{Subject: "1234567890"}, v)
+	if err != nil {
+		t.Fatalf("Didn't expect signing with a validator to return an error: %s", err)
+	}
+
+	payload := &Payload{}
+	if err := VerifyWith(string(token), v, payload); err != nil || payload.Subject != "1234567890" {
+		t.Errorf("Expected the verified subject to be 1234567890; got %#v and %v", payload, err)
+	}
+
+	other := NewHSValidator(HS256)
+	other.Key = []byte("otherkey")
+
+	if err := VerifyWith(string(token), other, &Payload{}); err != ErrBadSignature {
+		t.Errorf("Expected a token signed with another key to return %s; got %v", ErrBadSignature, err)
+	}
+
+	if _, err := SignWith(&Payload{}, nil); err != ErrNoValidator {
+		t.Errorf("Expected signing without a validator to return %s; got %v", ErrNoValidator, err)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// AddDefault registers a value for a claim that is decoded into the payload of
+// each token without the claim, or with a null claim, once the token has
+// passed every check, e.g. a default scope or tenant. Defaults are not seen by
+// the Policy or validations of the Decoder. Defaults must be added before the
+// Decoder is used.
+func (dec *Decoder) AddDefault(name string, value interface{}) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	if dec.defaults == nil {
+		dec.defaults = RawClaims{}
+	}
+
+	dec.defaults[name] = raw
+
+	return nil
+}
+
+// applyDefaults decodes the defaults of the claims absent from a payload into
+// v and returns the sorted names of the claims it defaulted
+func (dec *Decoder) applyDefaults(v interface{}, payload []byte) ([]string, error) {
+	var claims RawClaims
+	if err := json.NewDecoder(bytes.NewReader(payload)).Decode(&claims); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	absent := RawClaims{}
+	var names []string
+
+	for name, value := range dec.defaults {
+		if raw, ok := claims[name]; ok && string(raw) != "null" {
+			continue
+		}
+
+		absent[name] = value
+		names = append(names, name)
+	}
+
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(absent)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, v); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
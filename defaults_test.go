@@ -0,0 +1,86 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDecodeDefaults(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	type claims struct {
+		Subject string   `json:"sub"`
+		Scope   string   `json:"scope"`
+		Tenant  string   `json:"tid" claim:"tenant"`
+		Roles   []string `json:"roles"`
+	}
+
+	cases := []struct {
+		Claims    map[string]interface{}
+		Expected  claims
+		Defaulted []string
+		Reason    string
+	}{
+		{map[string]interface{}{"sub": "a"}, claims{"a", "read", "common", nil}, []string{"scope", "tid"}, "absent claims should be defaulted"},
+		{map[string]interface{}{"sub": "a", "scope": "write", "tid": "contoso"}, claims{"a", "write", "contoso", nil}, nil, "present claims should be kept"},
+		{map[string]interface{}{"sub": "a", "scope": nil}, claims{"a", "read", "common", nil}, []string{"scope", "tid"}, "null claims should be defaulted"},
+	}
+
+	for _, c := range cases {
+		dec := NewDecoder(nil, v)
+		dec.AddDefault("scope", "read")
+		dec.AddDefault("tid", "common")
+
+		var decoded claims
+		result, err := dec.verify(signTestToken(t, v, Header{Type: "JWT"}, c.Claims), &decoded)
+		if err != nil {
+			t.Errorf("Didn't expect decoding to return an error when %s: %s", c.Reason, err)
+			continue
+		}
+
+		if decoded.Subject != c.Expected.Subject || decoded.Scope != c.Expected.Scope || decoded.Tenant != c.Expected.Tenant {
+			t.Errorf("Expected %v when %s; got %v", c.Expected, c.Reason, decoded)
+		}
+
+		if len(result.Defaulted) != len(c.Defaulted) || (len(c.Defaulted) > 0 && result.Defaulted[0] != c.Defaulted[0]) {
+			t.Errorf("Expected %v to be defaulted when %s; got %v", c.Defaulted, c.Reason, result.Defaulted)
+		}
+	}
+
+	dec := NewDecoder(nil, v)
+	dec.TagName = "claim"
+	dec.AddDefault("tenant", "common")
+	dec.AddValidation(func(claims RawClaims) error {
+		var tenant string
+		return claims.Decode("tenant", &tenant)
+	})
+
+	if err := dec.Verify(signTestToken(t, v, Header{Type: "JWT"}, map[string]interface{}{"sub": "a"}), &claims{}); !errors.Is(err, ErrMissingClaim) {
+		t.Errorf("Expected validations not to see defaults; got %v", err)
+	}
+
+	dec.validations = nil
+
+	var decoded claims
+	if err := dec.Verify(signTestToken(t, v, Header{Type: "JWT"}, map[string]interface{}{"sub": "a"}), &decoded); err != nil || decoded.Tenant != "common" {
+		t.Errorf("Expected defaults to follow the TagName of the Decoder; got %v and %v", decoded, err)
+	}
+
+	if err := dec.AddDefault("roles", func() {}); err == nil {
+		t.Errorf("Expected a default that cannot be encoded to return an error")
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// A KeySourceConfig describes a source of keys without revealing them.
+type KeySourceConfig struct {
+	// Kind is the kind of source, e.g. "rsa" or "remote_key_set"
+	Kind string `json:"kind"`
+	// Algorithm is the algorithm the source signs or verifies with, if it is
+	// limited to one
+	Algorithm Algorithm `json:"alg,omitempty"`
+	// Fingerprint is the JWK thumbprint of the public key of the source.
+	// HMAC secrets are never fingerprinted.
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// URLs are the locations keys are fetched from
+	URLs []string `json:"urls,omitempty"`
+	// Sources describe the sources a composite source delegates to
+	Sources []KeySourceConfig `json:"sources,omitempty"`
+}
+
+// An EncoderConfig describes the effective configuration of an Encoder.
+type EncoderConfig struct {
+	KeySource            *KeySourceConfig `json:"key_source,omitempty"`
+	Compression          string           `json:"compression,omitempty"`
+	CompressionThreshold int              `json:"compression_threshold,omitempty"`
+	Armor                Armor            `json:"armor,omitempty"`
+	Canonicalized        bool             `json:"canonicalized,omitempty"`
+	Redacted             bool             `json:"redacted,omitempty"`
+	// AttestationKeyID is the KeyID of the attestation embedded in tokens
+	AttestationKeyID string `json:"attestation_kid,omitempty"`
+	// Certificate is the SHA-256 thumbprint of the certificate of the signing
+	// key embedded in or referenced by tokens
+	Certificate string `json:"certificate,omitempty"`
+}
+
+// A DecoderConfig describes the effective configuration of a Decoder, with the
+// Policy of the Decoder completed by the checks configured on the Decoder
+// itself.
+type DecoderConfig struct {
+	KeySources         []KeySourceConfig `json:"key_sources"`
+	Types              []string          `json:"types,omitempty"`
+	Issuers            []string          `json:"issuers,omitempty"`
+	Audience           string            `json:"audience,omitempty"`
+	RequiredClaims     []string          `json:"required_claims,omitempty"`
+	MaxClaimSizes      map[string]int    `json:"max_claim_sizes,omitempty"`
+	Leeway             time.Duration     `json:"leeway"`
+	MaxAge             time.Duration     `json:"max_age,omitempty"`
+	StaleGrace         time.Duration     `json:"stale_grace,omitempty"`
+	DryRun             bool              `json:"dry_run,omitempty"`
+	RequireAttestation []string          `json:"require_attestation,omitempty"`
+	Nonce              bool              `json:"nonce,omitempty"`
+	ReplayProtection   bool              `json:"replay_protection,omitempty"`
+	Cache              bool              `json:"cache,omitempty"`
+	Validations        int               `json:"validations,omitempty"`
+	// Defaults are the sorted names of the claims defaulted by AddDefault
+	Defaults           []string `json:"defaults,omitempty"`
+	MaxInflatedSize    int      `json:"max_inflated_size"`
+	AcceptArmor        bool     `json:"accept_armor,omitempty"`
+	AcceptRFC3339Dates bool     `json:"accept_rfc3339_dates,omitempty"`
+	CertificateBinding bool     `json:"certificate_binding,omitempty"`
+}
+
+// DescribeConfig describes the effective configuration of the Encoder so that
+// operators can verify the token policy of a deployment at runtime. Secrets
+// are never included.
+func (enc *Encoder) DescribeConfig() EncoderConfig {
+	config := EncoderConfig{
+		Compression:          enc.Compression,
+		CompressionThreshold: enc.CompressionThreshold,
+		Armor:                enc.Armor,
+		Canonicalized:        enc.Canonicalize != nil,
+		Redacted:             enc.Redact != nil,
+	}
+
+	if enc.validator != nil {
+		source := describeKeySource(enc.validator)
+		config.KeySource = &source
+	}
+
+	if enc.Attestation != nil {
+		config.AttestationKeyID = enc.Attestation.KeyID
+	}
+
+	if len(enc.CertificateChain) > 0 {
+		config.Certificate = CertificateThumbprintS256(enc.CertificateChain[0])
+	} else if enc.ThumbprintCertificate != nil {
+		config.Certificate = CertificateThumbprintS256(enc.ThumbprintCertificate)
+	}
+
+	return config
+}
+
+// DescribeConfig describes the effective configuration of the Decoder so that
+// operators can verify the token policy of a deployment at runtime. Secrets
+// are never included.
+func (dec *Decoder) DescribeConfig() DecoderConfig {
+	policy := dec.policy()
+
+	config := DecoderConfig{
+		KeySources:         []KeySourceConfig{},
+		Types:              dec.Types,
+		Issuers:            policy.Issuers,
+		Audience:           policy.Audience,
+		RequiredClaims:     policy.RequiredClaims,
+		MaxClaimSizes:      policy.MaxClaimSizes,
+		Leeway:             policy.Leeway,
+		MaxAge:             policy.MaxAge,
+		StaleGrace:         policy.StaleGrace,
+		DryRun:             policy.DryRun,
+		RequireAttestation: dec.RequireAttestation,
+		Nonce:              dec.NonceFunc != nil,
+		ReplayProtection:   dec.ReplayStore != nil,
+		Cache:              dec.Cache != nil,
+		Validations:        len(dec.validations),
+		MaxInflatedSize:    dec.maxInflatedSize(),
+		AcceptArmor:        dec.AcceptArmor,
+		AcceptRFC3339Dates: dec.AcceptRFC3339Dates,
+		CertificateBinding: dec.RequireCertificateBinding,
+	}
+
+	if policy.Issuer != "" {
+		config.Issuers = append([]string{policy.Issuer}, config.Issuers...)
+	}
+
+	if dec.KeyProvider != nil {
+		config.KeySources = append(config.KeySources, describeKeySource(dec.KeyProvider))
+	} else if dec.validator != nil {
+		config.KeySources = append(config.KeySources, describeKeySource(dec.validator))
+	}
+
+	for _, v := range dec.Verifiers {
+		config.KeySources = append(config.KeySources, describeKeySource(v))
+	}
+
+	for name := range dec.defaults {
+		config.Defaults = append(config.Defaults, name)
+	}
+
+	sort.Strings(config.Defaults)
+
+	return config
+}
+
+// describeKeySource describes a Validator or KeyProvider
+func describeKeySource(source interface{}) KeySourceConfig {
+	switch s := source.(type) {
+	case hsValidator:
+		return KeySourceConfig{Kind: "hmac", Algorithm: s.algorithm}
+	case hs256Validator:
+		return KeySourceConfig{Kind: "hmac", Algorithm: HS256}
+	case RSValidator:
+		return KeySourceConfig{Kind: "rsa", Algorithm: s.algorithm, Fingerprint: rsaFingerprint(s.PublicKey, s.PrivateKey)}
+	case *RSValidator:
+		return describeKeySource(*s)
+	case ESValidator:
+		return KeySourceConfig{Kind: "ecdsa", Algorithm: s.algorithm, Fingerprint: ecdsaFingerprint(s.PublicKey, s.PrivateKey)}
+	case *ESValidator:
+		return describeKeySource(*s)
+	case ExternalValidator:
+		return KeySourceConfig{Kind: "external", Algorithm: s.algorithm}
+	case *RemoteKeySet:
+		return KeySourceConfig{Kind: "remote_key_set", URLs: []string{s.URL}}
+	case *JKUKeyProvider:
+		return KeySourceConfig{Kind: "jku", URLs: s.AllowedURLs}
+	case *KeyStore:
+		return KeySourceConfig{Kind: "key_store"}
+	case *CertificateStore:
+		return KeySourceConfig{Kind: "certificate_store"}
+	case *AlgorithmMigration:
+		return KeySourceConfig{Kind: "algorithm_migration", Sources: []KeySourceConfig{describeKeySource(s.old), describeKeySource(s.next)}}
+	default:
+		return KeySourceConfig{Kind: fmt.Sprintf("%T", source)}
+	}
+}
+
+// rsaFingerprint returns the JWK thumbprint of an RSA public key, or of the
+// public half of a private key
+func rsaFingerprint(pub *rsa.PublicKey, priv *rsa.PrivateKey) string {
+	if pub == nil && priv != nil {
+		pub = &priv.PublicKey
+	}
+
+	if pub == nil {
+		return ""
+	}
+
+	return keyFingerprint(pub)
+}
+
+// ecdsaFingerprint returns the JWK thumbprint of an ECDSA public key, or of
+// the public half of a private key
+func ecdsaFingerprint(pub *ecdsa.PublicKey, priv *ecdsa.PrivateKey) string {
+	if pub == nil && priv != nil {
+		pub = &priv.PublicKey
+	}
+
+	if pub == nil {
+		return ""
+	}
+
+	return keyFingerprint(pub)
+}
+
+// keyFingerprint returns the JWK thumbprint of a public key
+func keyFingerprint(key crypto.PublicKey) string {
+	jwk, err := NewJSONWebKey(key)
+	if err != nil {
+		return ""
+	}
+
+	return jwk.Thumbprint()
+}
@@ -0,0 +1,91 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDescribeDecoderConfig(t *testing.T) {
+	hs := NewHSValidator(HS256)
+	hs.Key = []byte("bogokey")
+
+	rs := testRSValidator(t)
+	jwk, _ := NewJSONWebKey(rs.PublicKey)
+
+	dec := NewDecoder(nil, hs)
+	dec.Policy = &Policy{Issuer: "https://issuer.example", RequiredClaims: []string{"sub"}, Leeway: time.Minute}
+	dec.Audience = "api"
+	dec.Types = []string{"at+jwt"}
+	dec.Verifiers = []Validator{rs, NewRemoteKeySet("https://issuer.example/jwks")}
+	dec.AddDefault("scope", "read")
+	dec.AddValidation(func(claims RawClaims) error { return nil })
+
+	config := dec.DescribeConfig()
+
+	if len(config.KeySources) != 3 || config.KeySources[0].Kind != "hmac" || config.KeySources[0].Fingerprint != "" {
+		t.Errorf("Expected an HMAC key source without a fingerprint; got %#v", config.KeySources)
+	}
+
+	if config.KeySources[1].Fingerprint != jwk.Thumbprint() {
+		t.Errorf("Expected the RSA key to be fingerprinted with %s; got %#v", jwk.Thumbprint(), config.KeySources[1])
+	}
+
+	if urls := config.KeySources[2].URLs; len(urls) != 1 || urls[0] != "https://issuer.example/jwks" {
+		t.Errorf("Expected the URL of the remote key set; got %v", urls)
+	}
+
+	if config.Audience != "api" || len(config.Issuers) != 1 || config.Leeway != time.Minute || config.Validations != 1 {
+		t.Errorf("Expected the effective policy of the Decoder; got %#v", config)
+	}
+
+	if len(config.Defaults) != 1 || config.MaxInflatedSize != DefaultMaxInflatedSize {
+		t.Errorf("Expected the defaults and inflation limit of the Decoder; got %#v", config)
+	}
+
+	b, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("Didn't expect marshaling the configuration to return an error: %s", err)
+	}
+
+	if strings.Contains(string(b), encodeSegment(hs.Key)) || strings.Contains(string(b), "bogokey") {
+		t.Errorf("Expected the configuration not to reveal the HMAC secret; got %s", b)
+	}
+}
+
+func TestDescribeEncoderConfig(t *testing.T) {
+	leaf, _ := testCertificateChain(t)
+
+	enc := NewEncoder(nil, testRSValidator(t))
+	enc.Compression = CompressionDeflate
+	enc.ThumbprintCertificate = leaf
+	enc.Redact = DropClaims("email")
+
+	config := enc.DescribeConfig()
+
+	if config.KeySource == nil || config.KeySource.Kind != "rsa" || config.KeySource.Algorithm != RS256 || config.KeySource.Fingerprint == "" {
+		t.Errorf("Expected the RSA key source of the Encoder; got %#v", config.KeySource)
+	}
+
+	if config.Compression != CompressionDeflate || !config.Redacted || config.Certificate != CertificateThumbprintS256(leaf) {
+		t.Errorf("Expected the configuration of the Encoder; got %#v", config)
+	}
+
+	if config := NewEncoder(nil, nil).DescribeConfig(); config.KeySource != nil {
+		t.Errorf("Expected no key source without a validator; got %#v", config.KeySource)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import "strings"
+
+// SignDetached signs a payload supplied out of band, e.g. the body of an HTTP
+// request, and returns the token without its payload segment as described in
+// RFC 7515 Appendix F. The payload is signed as is and need not be JSON. A
+// WebhookSigner also protects the time of signing.
+func (enc *Encoder) SignDetached(h Header, payload []byte) (SignedToken, error) {
+	if enc.validator == nil {
+		return "", ErrNoValidator
+	}
+
+	token, err := signDetached(enc.validator, h, payload)
+
+	return SignedToken(token), err
+}
+
+// VerifyDetached verifies the signature of a token produced by SignDetached
+// over a payload supplied out of band and returns the header of the token.
+// Only the signature is verified as the payload need not be a claims set.
+func (dec *Decoder) VerifyDetached(token string, payload []byte) (*Header, error) {
+	validator := dec.verifier()
+	if validator == nil {
+		return nil, ErrNoValidator
+	}
+
+	header, err := verifyDetached(validator, token, payload)
+	if err != nil {
+		dec.stats.record(nil, err)
+		return nil, err
+	}
+
+	dec.stats.record(&DecodeResult{Header: *header, Algorithm: header.Algorithm, KeyID: header.KeyID}, nil)
+
+	return header, nil
+}
+
+// signDetached signs a payload with a given header and returns the token
+// without its payload segment
+func signDetached(v Validator, h Header, payload []byte) (string, error) {
+	jwt := &jwt{
+		Header:  &h,
+		Payload: rawPayload(payload),
+	}
+
+	if err := v.sign(jwt); err != nil {
+		return "", err
+	}
+
+	jwt.payloadRaw = nil
+
+	return jwt.token(), nil
+}
+
+// verifyDetached attaches a payload to a detached token, verifies its
+// signature and returns its header
+func verifyDetached(v Validator, input string, payload []byte) (*Header, error) {
+	fields := strings.Split(input, ".")
+	if len(fields) != 3 || fields[1] != "" {
+		return nil, ErrMalformedToken
+	}
+
+	jwt := &jwt{Header: &Header{}}
+	if err := jwt.parseHeader(fields[0]); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	jwt.payloadRaw = []byte(encodeSegment(payload))
+	jwt.Signature = []byte(fields[2])
+
+	if err := verifySignature(v, jwt, false); err != nil {
+		return nil, err
+	}
+
+	return jwt.Header, nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetachedPayloads(t *testing.T) {
+	v := testRSValidator(t)
+	body := []byte(`{"amount":"10.00","currency":"GBP","ref":"a"}`)
+
+	token, err := NewEncoder(nil, v).SignDetached(Header{KeyID: "payments"}, body)
+	if err != nil {
+		t.Fatalf("Didn't expect signing a detached payload to return an error: %s", err)
+	}
+
+	if fields := strings.Split(string(token), "."); len(fields) != 3 || fields[1] != "" {
+		t.Fatalf("Expected a token with an empty payload segment; got %s", token)
+	}
+
+	attached := signTestToken(t, v, Header{Type: "JWT"}, &Payload{})
+
+	cases := []struct {
+		ExpectedError error
+		Reason        string
+		Token         string
+		Payload       []byte
+	}{
+		{nil, "the payload is unchanged", string(token), body},
+		{ErrBadSignature, "the payload was changed", string(token), []byte(`{"amount":"99.00","currency":"GBP"}`)},
+		{ErrBadSignature, "no payload is supplied", string(token), nil},
+		{ErrMalformedToken, "the payload is attached", attached, body},
+	}
+
+	dec := NewDecoder(nil, v)
+
+	for _, c := range cases {
+		header, err := dec.VerifyDetached(c.Token, c.Payload)
+
+		if err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		} else if err == nil && header.KeyID != "payments" {
+			t.Errorf("Expected the header of the token when %s; got %#v", c.Reason, header)
+		}
+	}
+
+	if stats := dec.Stats(); stats.Verified != 1 {
+		t.Errorf("Expected detached verifications to be recorded in the stats; got %#v", stats)
+	}
+
+	if _, err := NewDecoder(nil, nil).VerifyDetached(string(token), body); err != ErrNoValidator {
+		t.Errorf("Expected %v without a validator; got %v", ErrNoValidator, err)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import "strings"
+
+const (
+	// EntraIssuerTemplate is the issuer advertised by the multi-tenant Microsoft
+	// Entra ID v2.0 metadata. The {tenantid} placeholder stands for the tid claim.
+	EntraIssuerTemplate = "https://login.microsoftonline.com/{tenantid}/v2.0"
+	// EntraV1IssuerTemplate is the issuer used by Microsoft Entra ID v1.0 tokens
+	EntraV1IssuerTemplate = "https://sts.windows.net/{tenantid}/"
+
+	entraTenantPlaceholder = "{tenantid}"
+)
+
+// EntraClaims are the claims found in tokens issued by Microsoft Entra ID
+// (formerly Azure AD).
+type EntraClaims struct {
+	Payload
+	TenantID          string   `json:"tid,omitempty"`
+	ObjectID          string   `json:"oid,omitempty"`
+	Version           string   `json:"ver,omitempty"`
+	AuthorizedParty   string   `json:"azp,omitempty"`
+	PreferredUsername string   `json:"preferred_username,omitempty"`
+	Name              string   `json:"name,omitempty"`
+	Roles             []string `json:"roles,omitempty"`
+	Scope             string   `json:"scp,omitempty"`
+}
+
+// An EntraPreset checks the claims of an Entra ID token. Entra issues tokens
+// with a different issuer for every tenant, so the issuer is derived from the
+// tid claim of the token rather than compared against a fixed value.
+type EntraPreset struct {
+	// ClientID is the application id expected in the aud claim. An empty
+	// ClientID skips the audience check.
+	ClientID string
+	// TenantIDs restricts the tenants whose tokens are accepted. An empty list
+	// accepts tokens from any tenant.
+	TenantIDs []string
+	// IssuerTemplate overrides the issuer template. When empty the template is
+	// chosen from the ver claim of the token.
+	IssuerTemplate string
+}
+
+// NewEntraPreset constructs an EntraPreset for the given application accepting
+// tokens from the given tenants, or from any tenant if none are given.
+func NewEntraPreset(clientID string, tenantIDs ...string) EntraPreset {
+	return EntraPreset{ClientID: clientID, TenantIDs: tenantIDs}
+}
+
+// Validate asserts the token was issued by the tenant named in its tid claim,
+// that the tenant is accepted and that the token is meant for this application.
+func (p EntraPreset) Validate(claims *EntraClaims) error {
+	if claims.TenantID == "" {
+		return ErrInvalidTenant
+	}
+
+	if len(p.TenantIDs) > 0 && !containsString(p.TenantIDs, claims.TenantID) {
+		return ErrInvalidTenant
+	}
+
+	if claims.Issuer != EntraIssuer(p.issuerTemplate(claims), claims.TenantID) {
+		return ErrInvalidIssuer
+	}
+
+	if p.ClientID != "" && !claims.Audience.Contains(p.ClientID) {
+		return ErrInvalidAudience
+	}
+
+	return nil
+}
+
+func (p EntraPreset) issuerTemplate(claims *EntraClaims) string {
+	if p.IssuerTemplate != "" {
+		return p.IssuerTemplate
+	}
+
+	if claims.Version == "1.0" {
+		return EntraV1IssuerTemplate
+	}
+
+	return EntraIssuerTemplate
+}
+
+// EntraIssuer expands an issuer template for the given tenant.
+func EntraIssuer(template, tenantID string) string {
+	return strings.Replace(template, entraTenantPlaceholder, tenantID, -1)
+}
+
+// EntraKeysURL returns the location of the JSON Web Key Set used to sign tokens
+// for a tenant. The tenant may be a tenant id or one of the aliases "common",
+// "organizations" or "consumers".
+func EntraKeysURL(tenant string) string {
+	return "https://login.microsoftonline.com/" + tenant + "/discovery/v2.0/keys"
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,80 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import "testing"
+
+func TestEntraPresetValidate(t *testing.T) {
+	tenant := "9188040d-6c67-4c5b-b112-36a304b66dad"
+	other := "72f988bf-86f1-41af-91ab-2d7cd011db47"
+
+	cases := []struct {
+		Preset        EntraPreset
+		Claims        EntraClaims
+		ExpectedError error
+		Reason        string
+	}{
+		{
+			NewEntraPreset("client"),
+			EntraClaims{Payload: Payload{Issuer: EntraIssuer(EntraIssuerTemplate, tenant), Audience: Audience{"client"}}, TenantID: tenant},
+			nil,
+			"a v2.0 token from any tenant should be accepted",
+		},
+		{
+			NewEntraPreset("client"),
+			EntraClaims{Payload: Payload{Issuer: "https://sts.windows.net/" + tenant + "/", Audience: Audience{"client"}}, TenantID: tenant, Version: "1.0"},
+			nil,
+			"a v1.0 token should be checked against the v1.0 issuer",
+		},
+		{
+			NewEntraPreset("client", other),
+			EntraClaims{Payload: Payload{Issuer: EntraIssuer(EntraIssuerTemplate, tenant), Audience: Audience{"client"}}, TenantID: tenant},
+			ErrInvalidTenant,
+			"a tenant not in the allowed list should be rejected",
+		},
+		{
+			NewEntraPreset("client"),
+			EntraClaims{Payload: Payload{Issuer: EntraIssuer(EntraIssuerTemplate, tenant), Audience: Audience{"client"}}},
+			ErrInvalidTenant,
+			"a token without a tid claim should be rejected",
+		},
+		{
+			NewEntraPreset("client"),
+			EntraClaims{Payload: Payload{Issuer: EntraIssuer(EntraIssuerTemplate, other), Audience: Audience{"client"}}, TenantID: tenant},
+			ErrInvalidIssuer,
+			"an issuer for a different tenant than tid should be rejected",
+		},
+		{
+			NewEntraPreset("client"),
+			EntraClaims{Payload: Payload{Issuer: EntraIssuer(EntraIssuerTemplate, tenant), Audience: Audience{"someone else"}}, TenantID: tenant},
+			ErrInvalidAudience,
+			"a token for another application should be rejected",
+		},
+	}
+
+	for _, c := range cases {
+		if err := c.Preset.Validate(&c.Claims); err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		}
+	}
+}
+
+func TestEntraKeysURL(t *testing.T) {
+	expected := "https://login.microsoftonline.com/common/discovery/v2.0/keys"
+
+	if url := EntraKeysURL("common"); url != expected {
+		t.Errorf("Expected %s; got %s", expected, url)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// ErrWeakEntropy is returned when a randomness source fails its health check
+var ErrWeakEntropy = errors.New("randomness source failed its health check")
+
+const (
+	// entropySampleSize is the number of bytes a health check reads
+	entropySampleSize = 1024
+	// maxRepetition is the longest run of a byte a healthy sample may have
+	maxRepetition = 8
+	// maxOccurrences is how often a byte value may occur in a healthy sample,
+	// about eight times what is expected
+	maxOccurrences = 32
+)
+
+// CheckEntropy reads a sample from a randomness source and rejects it with
+// ErrWeakEntropy when it is obviously broken, e.g. it fails to read, repeats
+// itself or is heavily biased. It is loosely based on the repetition count and
+// adaptive proportion tests of NIST SP 800-90B and cannot prove a source is
+// good, only catch sources that are badly broken.
+func CheckEntropy(r io.Reader) error {
+	first := make([]byte, entropySampleSize)
+	second := make([]byte, entropySampleSize)
+
+	if _, err := io.ReadFull(r, first); err != nil {
+		return ErrWeakEntropy
+	}
+
+	if _, err := io.ReadFull(r, second); err != nil {
+		return ErrWeakEntropy
+	}
+
+	if bytes.Equal(first, second) {
+		return ErrWeakEntropy
+	}
+
+	var occurrences [256]int
+	run := 0
+
+	for i, b := range first {
+		if i > 0 && b == first[i-1] {
+			run++
+		} else {
+			run = 1
+		}
+
+		occurrences[b]++
+
+		if run > maxRepetition || occurrences[b] > maxOccurrences {
+			return ErrWeakEntropy
+		}
+	}
+
+	return nil
+}
+
+// A CheckedRandom is a randomness source that is health checked with
+// CheckEntropy before it is first used and, optionally, periodically after.
+// Reads fail with ErrWeakEntropy once a check fails so that ES and RS
+// validators stop signing rather than produce weak signatures. It is safe for
+// concurrent use if the underlying source is.
+type CheckedRandom struct {
+	r        io.Reader
+	interval time.Duration
+
+	mu      sync.Mutex
+	checked time.Time
+	err     error
+}
+
+// NewCheckedRandom checks a randomness source and returns it as a
+// CheckedRandom that is checked again every interval, or never again if the
+// interval is zero. The error of the first check is returned so broken
+// environments fail at startup.
+func NewCheckedRandom(r io.Reader, interval time.Duration) (*CheckedRandom, error) {
+	c := &CheckedRandom{r: r, interval: interval}
+
+	return c, c.check()
+}
+
+// Read implements io.Reader.
+func (c *CheckedRandom) Read(p []byte) (int, error) {
+	if err := c.check(); err != nil {
+		return 0, err
+	}
+
+	return c.r.Read(p)
+}
+
+// check runs the health check if it is due and returns the error of the last
+// check. A source that failed is never checked again.
+func (c *CheckedRandom) check() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := timeFunc()
+
+	if c.err == nil && (c.checked.IsZero() || (c.interval > 0 && now.Sub(c.checked) >= c.interval)) {
+		c.err = CheckEntropy(c.r)
+		c.checked = now
+	}
+
+	return c.err
+}
+
+// checkRandom runs the health check of a CheckedRandom before it is used to
+// sign, as some signatures do not read from their randomness source
+func checkRandom(r io.Reader) error {
+	if c, ok := r.(*CheckedRandom); ok {
+		return c.check()
+	}
+
+	return nil
+}
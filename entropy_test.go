@@ -0,0 +1,116 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"io"
+	"testing"
+	"time"
+)
+
+// switchableReader reads from a healthy source until it is broken
+type switchableReader struct {
+	broken bool
+}
+
+func (r *switchableReader) Read(p []byte) (int, error) {
+	if r.broken {
+		return len(p), nil
+	}
+
+	return rand.Read(p)
+}
+
+// cycleReader repeats the same bytes forever
+type cycleReader struct {
+	i int
+}
+
+func (r *cycleReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(r.i)
+		r.i = (r.i + 1) % 256
+	}
+
+	return len(p), nil
+}
+
+func TestCheckEntropy(t *testing.T) {
+	biased := make([]byte, 4096)
+	rand.Read(biased)
+	for i := 0; i < len(biased); i += 16 {
+		biased[i] = 'x'
+	}
+
+	cases := []struct {
+		Expected error
+		Reason   string
+		Source   io.Reader
+	}{
+		{nil, "the source is crypto/rand", rand.Reader},
+		{ErrWeakEntropy, "the source only returns zeros", &switchableReader{broken: true}},
+		{ErrWeakEntropy, "the source fails to read", bytes.NewReader(nil)},
+		{ErrWeakEntropy, "the source repeats itself", &cycleReader{}},
+		{ErrWeakEntropy, "the source is biased", bytes.NewReader(biased)},
+	}
+
+	for _, c := range cases {
+		if err := CheckEntropy(c.Source); err != c.Expected {
+			t.Errorf("Expected %v when %s; got %v", c.Expected, c.Reason, err)
+		}
+	}
+}
+
+func TestCheckedRandom(t *testing.T) {
+	now := time.Unix(1516239022, 0)
+	timeFunc = func() time.Time { return now }
+	defer func() { timeFunc = time.Now }()
+
+	if _, err := NewCheckedRandom(&switchableReader{broken: true}, 0); err != ErrWeakEntropy {
+		t.Errorf("Expected a broken source to fail at startup with %s; got %v", ErrWeakEntropy, err)
+	}
+
+	source := &switchableReader{}
+	checked, err := NewCheckedRandom(source, time.Hour)
+	if err != nil {
+		t.Fatalf("Didn't expect a healthy source to fail its health check: %s", err)
+	}
+
+	v, _ := NewESValidator(ES256)
+	v.PrivateKey, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	v.PublicKey = &v.PrivateKey.PublicKey
+	v.SetRandom(checked)
+
+	if _, err := NewEncoder(nil, v).Sign(&Payload{}); err != nil {
+		t.Errorf("Didn't expect signing with a healthy source to return an error: %s", err)
+	}
+
+	source.broken = true
+	now = now.Add(time.Hour)
+
+	if _, err := NewEncoder(nil, v).Sign(&Payload{}); err != ErrWeakEntropy {
+		t.Errorf("Expected signing once the source breaks to return %s; got %v", ErrWeakEntropy, err)
+	}
+
+	rs := testRSValidator(t)
+	rs.SetRandom(checked)
+
+	if _, err := NewEncoder(nil, rs).Sign(&Payload{}); err != ErrWeakEntropy {
+		t.Errorf("Expected an RS validator with a broken source to return %s; got %v", ErrWeakEntropy, err)
+	}
+}
@@ -17,8 +17,8 @@ package jwt
 import (
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
-	"encoding/base64"
 	"errors"
 	"io"
 	"math/big"
@@ -54,11 +54,21 @@ func NewESValidator(algorithm Algorithm) (v ESValidator, err error) {
 	}
 }
 
+// SetRandom sets the randomness source signatures are made with, e.g. a
+// CheckedRandom.
+func (v *ESValidator) SetRandom(r io.Reader) {
+	v.rand = r
+}
+
 func (v ESValidator) sign(jwt *jwt) (err error) {
 	if v.PrivateKey == nil {
 		return errors.New("Cannot sign with a nil private key")
 	}
 
+	if err := checkRandom(v.rand); err != nil {
+		return err
+	}
+
 	jwt.Header.Algorithm = v.algorithm
 	jwt.rawEncode()
 
@@ -69,10 +79,17 @@ func (v ESValidator) sign(jwt *jwt) (err error) {
 
 	r, s, err := ecdsa.Sign(v.rand, v.PrivateKey, hash)
 
-	signature := r.Bytes()
-	signature = append(signature, s.Bytes()...)
-	jwt.Signature = make([]byte, base64.URLEncoding.EncodedLen(len(signature)))
-	base64.URLEncoding.Encode(jwt.Signature, signature)
+	if err != nil {
+		return err
+	}
+
+	// r and s are padded to the size of the curve so the signature can be split
+	// in half when validating
+	size := curveSize(v.PrivateKey.Curve)
+	signature := make([]byte, 2*size)
+	r.FillBytes(signature[:size])
+	s.FillBytes(signature[size:])
+	jwt.Signature = []byte(encodeSegment(signature))
 
 	return err
 }
@@ -85,15 +102,25 @@ func (v ESValidator) validate(jwt *jwt) (bool, error) {
 		return false, ErrMalformedToken
 	}
 
-	signature := make([]byte, base64.URLEncoding.DecodedLen(len(jwt.Signature)))
-	count, err := base64.URLEncoding.Decode(signature, jwt.Signature)
+	if v.PublicKey == nil {
+		return false, ErrBadSignature
+	}
+
+	signature, err := parseField(string(jwt.Signature))
 
 	if err != nil {
 		return false, ErrMalformedToken
 	}
 
-	r.SetBytes(signature[0 : count/2])
-	s.SetBytes(signature[count/2 : count])
+	// Signatures of encoders that trim leading zeros from r or s are rejected
+	// rather than guessed at
+	size := curveSize(v.PublicKey.Curve)
+	if len(signature) != 2*size {
+		return false, nil
+	}
+
+	r.SetBytes(signature[:size])
+	s.SetBytes(signature[size:])
 
 	hsh := v.hashType.New()
 	hsh.Write([]byte(string(jwt.headerRaw) + "." + string(jwt.payloadRaw)))
@@ -101,3 +128,9 @@ func (v ESValidator) validate(jwt *jwt) (bool, error) {
 
 	return ecdsa.Verify(v.PublicKey, hash, r, s), nil
 }
+
+// curveSize returns the length in bytes of r and s in signatures made on a
+// given curve, which is 66 rather than 65.25 for P-521
+func curveSize(curve elliptic.Curve) int {
+	return (curve.Params().BitSize + 7) / 8
+}
@@ -17,8 +17,11 @@ package jwt
 import (
 	"bytes"
 	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/x509"
 	"encoding/pem"
+	"strings"
 	"testing"
 )
 
@@ -58,8 +61,8 @@ func TestESSign(t *testing.T) {
 
 	b64signature := "axfR8uEsQkf4vOblY6RA8ncDfYEt6zOg9KE5RdiYwpY1q7OQwkG30-DAgdLFcbXyCnpXQNucJwr1oF-m0ri0ZA=="
 	jwt := &jwt{
-		Header: &header{
-			ContentType: "JWT",
+		Header: &Header{
+			Type: "JWT",
 		},
 		Payload: &Payload{
 			Subject: "1234567890",
@@ -127,9 +130,9 @@ func TestESValidate(t *testing.T) {
 	b64Signature := "axfR8uEsQkf4vOblY6RA8ncDfYEt6zOg9KE5RdiYwpY1q7OQwkG30-DAgdLFcbXyCnpXQNucJwr1oF-m0ri0ZA=="
 
 	jwt := &jwt{
-		Header: &header{
-			Algorithm:   ES256,
-			ContentType: "JWT",
+		Header: &Header{
+			Algorithm: ES256,
+			Type:      "JWT",
 		},
 		headerRaw: []byte(b64Header),
 		Payload: &Payload{
@@ -176,3 +179,43 @@ func TestESValidate(t *testing.T) {
 	}
 
 }
+
+func TestESFixedLengthSignatures(t *testing.T) {
+	cases := []struct {
+		Algorithm Algorithm
+		Curve     elliptic.Curve
+		Size      int
+	}{
+		{ES256, elliptic.P256(), 64},
+		{ES384, elliptic.P384(), 96},
+		{ES512, elliptic.P521(), 132},
+	}
+
+	for _, c := range cases {
+		v, _ := NewESValidator(c.Algorithm)
+		v.PrivateKey, _ = ecdsa.GenerateKey(c.Curve, rand.Reader)
+		v.PublicKey = &v.PrivateKey.PublicKey
+
+		// Enough signatures that some have r or s with leading zero bytes
+		for i := 0; i < 64; i++ {
+			token := signTestToken(t, v, Header{Type: "JWT"}, &Payload{})
+			fields := strings.Split(token, ".")
+
+			signature, _ := parseField(fields[2])
+			if len(signature) != c.Size {
+				t.Fatalf("Expected %s signatures of %d bytes; got %d", c.Algorithm, c.Size, len(signature))
+			}
+
+			if err := NewDecoder(nil, v).Verify(token, &Payload{}); err != nil {
+				t.Fatalf("Didn't expect verifying an %s signature to return an error: %s", c.Algorithm, err)
+			}
+
+			// A signature with a byte of r trimmed, as made by encoders that
+			// concatenate r.Bytes() and s.Bytes()
+			fields[2] = encodeSegment(signature[1:])
+			if err := NewDecoder(nil, v).Verify(strings.Join(fields, "."), &Payload{}); err != ErrBadSignature {
+				t.Fatalf("Expected a short %s signature to return %s; got %v", c.Algorithm, ErrBadSignature, err)
+			}
+		}
+	}
+}
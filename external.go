@@ -0,0 +1,69 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+// An ExternalSigner signs and verifies with a scheme implemented outside this
+// package, e.g. a post-quantum scheme such as ML-DSA ahead of its
+// standardization for JOSE, or a key held by an HSM.
+type ExternalSigner interface {
+	// Sign returns the signature of a signing input. Signers that only
+	// verify return ErrVerifyOnly.
+	Sign(input []byte) ([]byte, error)
+	// Verify returns nil when a signature of a signing input is valid.
+	Verify(input, signature []byte) error
+}
+
+// An ExternalValidator is a Validator signing and verifying tokens of an
+// algorithm this package does not implement with an ExternalSigner. Tokens of
+// other algorithms are rejected so that a token cannot choose the scheme it is
+// verified with.
+type ExternalValidator struct {
+	algorithm Algorithm
+	signer    ExternalSigner
+}
+
+// NewExternalValidator constructs an ExternalValidator for a given algorithm,
+// e.g. "ML-DSA-65", and the signer implementing it.
+func NewExternalValidator(algorithm Algorithm, s ExternalSigner) ExternalValidator {
+	return ExternalValidator{algorithm: algorithm, signer: s}
+}
+
+func (v ExternalValidator) validate(jwt *jwt) (bool, error) {
+	if jwt.Header.Algorithm != v.algorithm {
+		return false, ErrAlgorithmNotImplemented
+	}
+
+	signature, err := parseField(string(jwt.Signature))
+	if err != nil {
+		return false, ErrMalformedToken
+	}
+
+	input := string(jwt.headerRaw) + "." + string(jwt.payloadRaw)
+
+	return v.signer.Verify([]byte(input), signature) == nil, nil
+}
+
+func (v ExternalValidator) sign(jwt *jwt) error {
+	jwt.Header.Algorithm = v.algorithm
+	jwt.rawEncode()
+
+	signature, err := v.signer.Sign([]byte(string(jwt.headerRaw) + "." + string(jwt.payloadRaw)))
+	if err != nil {
+		return err
+	}
+
+	jwt.Signature = []byte(encodeSegment(signature))
+
+	return nil
+}
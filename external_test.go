@@ -0,0 +1,68 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+// testSigner stands in for an external scheme with ed25519
+type testSigner struct {
+	key ed25519.PrivateKey
+}
+
+func (s testSigner) Sign(input []byte) ([]byte, error) {
+	return ed25519.Sign(s.key, input), nil
+}
+
+func (s testSigner) Verify(input, signature []byte) error {
+	if !ed25519.Verify(s.key.Public().(ed25519.PublicKey), input, signature) {
+		return errors.New("invalid signature")
+	}
+
+	return nil
+}
+
+func TestExternalValidator(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	v := NewExternalValidator("ML-DSA-65", testSigner{key})
+
+	token, err := NewEncoder(nil, v).Sign(&Payload{Subject: "1234567890"})
+	if err != nil {
+		t.Fatalf("Didn't expect signing with an external signer to return an error: %s", err)
+	}
+
+	cases := []struct {
+		ExpectedError error
+		Reason        string
+		Validator     Validator
+		Token         string
+	}{
+		{nil, "the external signature is valid", v, string(token)},
+		{ErrBadSignature, "the external signature is from another key", NewExternalValidator("ML-DSA-65", testSigner{otherKey}), string(token)},
+		{ErrAlgorithmNotImplemented, "the token is of another algorithm", NewExternalValidator("ML-DSA-87", testSigner{key}), string(token)},
+		{ErrAlgorithmNotImplemented, "the token is signed classically", v, signTestToken(t, testRSValidator(t), Header{Type: "JWT"}, &Payload{})},
+	}
+
+	for _, c := range cases {
+		if err := NewDecoder(nil, c.Validator).Verify(c.Token, &Payload{}); err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		}
+	}
+}
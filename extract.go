@@ -0,0 +1,122 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"net/http"
+	"strings"
+)
+
+// An Extractor finds the token of a request. ErrMissingToken is returned when
+// the request has none.
+type Extractor interface {
+	Extract(r *http.Request) (string, error)
+}
+
+// ExtractorFunc adapts a function to an Extractor.
+type ExtractorFunc func(r *http.Request) (string, error)
+
+// Extract implements Extractor.
+func (f ExtractorFunc) Extract(r *http.Request) (string, error) {
+	return f(r)
+}
+
+// AuthorizationHeader extracts the token of the Bearer scheme of the
+// Authorization header as described by RFC 6750.
+var AuthorizationHeader Extractor = ExtractorFunc(func(r *http.Request) (string, error) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", ErrMissingToken
+	}
+
+	return nonEmptyToken(strings.TrimSpace(token))
+})
+
+// CookieExtractor extracts the token of a cookie with a given name.
+func CookieExtractor(name string) Extractor {
+	return ExtractorFunc(func(r *http.Request) (string, error) {
+		cookie, err := r.Cookie(name)
+		if err != nil {
+			return "", ErrMissingToken
+		}
+
+		return nonEmptyToken(cookie.Value)
+	})
+}
+
+// QueryExtractor extracts the token of a query parameter with a given name,
+// e.g. "access_token". Tokens in URLs end up in logs and browser histories so
+// it should only be used where no other transport is possible.
+func QueryExtractor(name string) Extractor {
+	return ExtractorFunc(func(r *http.Request) (string, error) {
+		return nonEmptyToken(r.URL.Query().Get(name))
+	})
+}
+
+// FormExtractor extracts the token of a field of a url encoded form body with
+// a given name, e.g. "access_token".
+func FormExtractor(name string) Extractor {
+	return ExtractorFunc(func(r *http.Request) (string, error) {
+		return nonEmptyToken(r.PostFormValue(name))
+	})
+}
+
+// FirstOf extracts the token of the first of a number of extractors that
+// finds one, e.g. the Authorization header and then a session cookie. Errors
+// other than ErrMissingToken are returned as is.
+func FirstOf(extractors ...Extractor) Extractor {
+	return ExtractorFunc(func(r *http.Request) (string, error) {
+		for _, e := range extractors {
+			token, err := e.Extract(r)
+			if err != ErrMissingToken {
+				return token, err
+			}
+		}
+
+		return "", ErrMissingToken
+	})
+}
+
+// VerifyRequest extracts the token of a request with a given Extractor and
+// verifies it as VerifyContext does, and against the client certificate of the
+// request if RequireCertificateBinding is set. The request is returned with a
+// context carrying the result for handlers further down a chain.
+func (dec *Decoder) VerifyRequest(r *http.Request, e Extractor, v interface{}) (*http.Request, error) {
+	token, err := e.Extract(r)
+	if err != nil {
+		return r, err
+	}
+
+	ctx, result, err := dec.VerifyContext(r.Context(), token, v)
+	if err != nil {
+		return r, err
+	}
+
+	if dec.RequireCertificateBinding {
+		if err := VerifyCertificateBinding(result.Confirmation, r.TLS); err != nil {
+			return r, err
+		}
+	}
+
+	return r.WithContext(ctx), nil
+}
+
+// nonEmptyToken returns ErrMissingToken for an empty token
+func nonEmptyToken(token string) (string, error) {
+	if token == "" {
+		return "", ErrMissingToken
+	}
+
+	return token, nil
+}
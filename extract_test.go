@@ -0,0 +1,96 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestExtractors(t *testing.T) {
+	request := func(header, cookie, query, form string) *http.Request {
+		var r *http.Request
+		if form != "" {
+			r = httptest.NewRequest(http.MethodPost, "/?"+query, strings.NewReader(url.Values{"access_token": {form}}.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		} else {
+			r = httptest.NewRequest(http.MethodGet, "/?"+query, nil)
+		}
+
+		if header != "" {
+			r.Header.Set("Authorization", header)
+		}
+
+		if cookie != "" {
+			r.AddCookie(&http.Cookie{Name: "session", Value: cookie})
+		}
+
+		return r
+	}
+
+	all := FirstOf(AuthorizationHeader, CookieExtractor("session"), QueryExtractor("access_token"), FormExtractor("access_token"))
+
+	cases := []struct {
+		ExpectedError error
+		Expected      string
+		Reason        string
+		Extractor     Extractor
+		Request       *http.Request
+	}{
+		{nil, "a.b.c", "the header has a bearer token", AuthorizationHeader, request("Bearer a.b.c", "", "", "")},
+		{nil, "a.b.c", "the scheme is lower case", AuthorizationHeader, request("bearer a.b.c", "", "", "")},
+		{ErrMissingToken, "", "the header has another scheme", AuthorizationHeader, request("Basic dXNlcjpwYXNz", "", "", "")},
+		{ErrMissingToken, "", "the bearer token is empty", AuthorizationHeader, request("Bearer ", "", "", "")},
+		{nil, "c.o.o", "the cookie has a token", CookieExtractor("session"), request("", "c.o.o", "", "")},
+		{nil, "q.u.e", "the query has a token", QueryExtractor("access_token"), request("", "", "access_token=q.u.e", "")},
+		{nil, "f.o.r", "the form has a token", FormExtractor("access_token"), request("", "", "", "f.o.r")},
+		{nil, "a.b.c", "the header is the first match", all, request("Bearer a.b.c", "c.o.o", "access_token=q.u.e", "")},
+		{nil, "c.o.o", "the cookie is the first match", all, request("Basic dXNlcjpwYXNz", "c.o.o", "access_token=q.u.e", "")},
+		{ErrMissingToken, "", "nothing matches", all, request("", "", "", "")},
+	}
+
+	for _, c := range cases {
+		token, err := c.Extractor.Extract(c.Request)
+
+		if err != c.ExpectedError || token != c.Expected {
+			t.Errorf("Expected %q and %v when %s; got %q and %v", c.Expected, c.ExpectedError, c.Reason, token, err)
+		}
+	}
+}
+
+func TestVerifyRequest(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+signTestToken(t, v, Header{Type: "JWT"}, &Payload{Subject: "1234567890"}))
+
+	dec := NewDecoder(nil, v)
+
+	verified, err := dec.VerifyRequest(r, AuthorizationHeader, &Payload{})
+	if err != nil {
+		t.Fatalf("Didn't expect verifying a request to return an error: %s", err)
+	}
+
+	if claims, ok := ClaimsFromContext[*Payload](verified.Context()); !ok || claims.Subject != "1234567890" {
+		t.Errorf("Expected the claims in the context of the request; got %#v", claims)
+	}
+
+	if _, err := dec.VerifyRequest(httptest.NewRequest(http.MethodGet, "/", nil), AuthorizationHeader, &Payload{}); err != ErrMissingToken {
+		t.Errorf("Expected %s for a request without a token; got %v", ErrMissingToken, err)
+	}
+}
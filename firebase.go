@@ -0,0 +1,115 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import "encoding/json"
+
+const (
+	// FirebaseKeysURL is the location of the keys Firebase Authentication signs
+	// ID tokens with, the securetoken certificates of Google as a JSON Web Key
+	// Set
+	FirebaseKeysURL = "https://www.googleapis.com/service_accounts/v1/jwk/securetoken@system.gserviceaccount.com"
+
+	firebaseIssuerPrefix = "https://securetoken.google.com/"
+)
+
+// FirebaseClaims are the claims found in ID tokens issued by Firebase
+// Authentication.
+type FirebaseClaims struct {
+	Payload
+	AuthTime      int64    `json:"auth_time,omitempty"`
+	UserID        string   `json:"user_id,omitempty"`
+	Email         string   `json:"email,omitempty"`
+	EmailVerified bool     `json:"email_verified,omitempty"`
+	PhoneNumber   string   `json:"phone_number,omitempty"`
+	Name          string   `json:"name,omitempty"`
+	Picture       string   `json:"picture,omitempty"`
+	Firebase      Firebase `json:"firebase"`
+}
+
+// Firebase is the firebase claim of an ID token describing how the user
+// signed in.
+type Firebase struct {
+	// Identities are the ids of the user by provider, e.g. "google.com"
+	Identities     map[string][]string `json:"identities,omitempty"`
+	SignInProvider string              `json:"sign_in_provider,omitempty"`
+	// Tenant is the Identity Platform tenant of the user, if any
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// A FirebasePreset checks the claims of an ID token issued by Firebase
+// Authentication for a project.
+type FirebasePreset struct {
+	// ProjectID is the id of the Firebase project, e.g. my-project-1234
+	ProjectID string
+}
+
+// NewFirebasePreset constructs a FirebasePreset accepting ID tokens of a
+// project.
+func NewFirebasePreset(projectID string) FirebasePreset {
+	return FirebasePreset{ProjectID: projectID}
+}
+
+// Issuer returns the iss claim of ID tokens of the project.
+func (p FirebasePreset) Issuer() string {
+	return firebaseIssuerPrefix + p.ProjectID
+}
+
+// KeysURL returns the location of the keys ID tokens are signed with.
+func (p FirebasePreset) KeysURL() string {
+	return FirebaseKeysURL
+}
+
+// Validate asserts the token was issued by Firebase for the project to a user
+// that has signed in.
+func (p FirebasePreset) Validate(claims *FirebaseClaims) error {
+	if claims.Issuer != p.Issuer() {
+		return ErrInvalidIssuer
+	}
+
+	if !claims.Audience.Contains(p.ProjectID) {
+		return ErrInvalidAudience
+	}
+
+	if claims.Subject == "" {
+		return ErrMissingClaim
+	}
+
+	if claims.AuthTime > timeFunc().Unix() {
+		return ErrTokenNotYetValid
+	}
+
+	return nil
+}
+
+// Decoder returns a Decoder verifying ID tokens of the project with the keys
+// at KeysURL and validating their claims with the preset.
+func (p FirebasePreset) Decoder() *Decoder {
+	dec := NewDecoder(nil, NewRemoteKeySet(p.KeysURL()))
+	dec.AddValidation(func(raw RawClaims) error {
+		b, err := json.Marshal(raw)
+		if err != nil {
+			return err
+		}
+
+		var claims FirebaseClaims
+		if err := json.Unmarshal(b, &claims); err != nil {
+			return ErrMalformedToken
+		}
+
+		return p.Validate(&claims)
+	})
+
+	return dec
+}
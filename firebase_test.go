@@ -0,0 +1,92 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestFirebasePresetValidate(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	timeFunc = func() time.Time { return now }
+	defer func() { timeFunc = time.Now }()
+
+	preset := NewFirebasePreset("my-project")
+	issuer := "https://securetoken.google.com/my-project"
+
+	cases := []struct {
+		Claims        FirebaseClaims
+		ExpectedError error
+		Reason        string
+	}{
+		{FirebaseClaims{Payload: Payload{Issuer: issuer, Audience: Audience{"my-project"}, Subject: "uid"}, AuthTime: now.Unix()}, nil, "an ID token of the project should be accepted"},
+		{FirebaseClaims{Payload: Payload{Issuer: "https://securetoken.google.com/other", Audience: Audience{"my-project"}, Subject: "uid"}}, ErrInvalidIssuer, "a token issued for another project should be rejected"},
+		{FirebaseClaims{Payload: Payload{Issuer: issuer, Audience: Audience{"other"}, Subject: "uid"}}, ErrInvalidAudience, "a token meant for another project should be rejected"},
+		{FirebaseClaims{Payload: Payload{Issuer: issuer, Audience: Audience{"my-project"}}}, ErrMissingClaim, "a token without a subject should be rejected"},
+		{FirebaseClaims{Payload: Payload{Issuer: issuer, Audience: Audience{"my-project"}, Subject: "uid"}, AuthTime: now.Unix() + 60}, ErrTokenNotYetValid, "a token authenticated in the future should be rejected"},
+	}
+
+	for _, c := range cases {
+		if err := preset.Validate(&c.Claims); err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		}
+	}
+}
+
+func TestFirebaseClaimsMapping(t *testing.T) {
+	raw := `{"sub":"uid","user_id":"uid","email":"ben@example.com","firebase":{"identities":{"google.com":["123"]},"sign_in_provider":"google.com","tenant":"tenant-1"}}`
+	claims := &FirebaseClaims{}
+
+	if err := json.NewDecoder(bytes.NewBufferString(raw)).Decode(claims); err != nil {
+		t.Fatalf("Didn't expect decoding firebase claims to return an error: %s", err)
+	}
+
+	if claims.UserID != "uid" || claims.Firebase.SignInProvider != "google.com" || len(claims.Firebase.Identities["google.com"]) != 1 || claims.Firebase.Tenant != "tenant-1" {
+		t.Errorf("Firebase claims were not mapped: %+v", claims)
+	}
+}
+
+func TestFirebasePresetDecoder(t *testing.T) {
+	preset := NewFirebasePreset("my-project")
+	signer := testRSValidator(t)
+
+	dec := preset.Decoder()
+	if keys, ok := dec.validator.(*RemoteKeySet); !ok || keys.URL != FirebaseKeysURL {
+		t.Errorf("Expected the decoder to verify with the keys at %s; got %#v", FirebaseKeysURL, dec.validator)
+	}
+
+	cases := []struct {
+		Claims        FirebaseClaims
+		ExpectedError error
+		Reason        string
+	}{
+		{FirebaseClaims{Payload: Payload{Issuer: preset.Issuer(), Audience: Audience{"my-project"}, Subject: "uid"}}, nil, "the token is an ID token of the project"},
+		{FirebaseClaims{Payload: Payload{Issuer: preset.Issuer(), Audience: Audience{"other"}, Subject: "uid"}}, ErrInvalidAudience, "the token is meant for another project"},
+	}
+
+	for _, c := range cases {
+		token := signTestToken(t, signer, Header{Type: "JWT"}, &c.Claims)
+
+		dec := preset.Decoder()
+		dec.validator = signer
+
+		var claims FirebaseClaims
+		if err := dec.Verify(token, &claims); err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		}
+	}
+}
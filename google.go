@@ -0,0 +1,114 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// GoogleTokenURL is the token endpoint service accounts mint access tokens at
+// when their key file names none
+const GoogleTokenURL = "https://oauth2.googleapis.com/token"
+
+// A GoogleServiceAccount is the JSON key file of a Google Cloud service
+// account. Its tokens are issued by the email of the account and signed with
+// RS256 under the id of the key.
+type GoogleServiceAccount struct {
+	Type         string `json:"type"`
+	ProjectID    string `json:"project_id"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	ClientEmail  string `json:"client_email"`
+	ClientID     string `json:"client_id"`
+	TokenURI     string `json:"token_uri"`
+
+	validator RSValidator
+}
+
+// ParseGoogleServiceAccount parses the JSON key file of a service account and
+// its private key.
+func ParseGoogleServiceAccount(data []byte) (*GoogleServiceAccount, error) {
+	var account GoogleServiceAccount
+	if err := json.Unmarshal(data, &account); err != nil {
+		return nil, err
+	}
+
+	if account.Type != "service_account" {
+		return nil, fmt.Errorf("key file of type %q is not a service account", account.Type)
+	}
+
+	if account.ClientEmail == "" {
+		return nil, fmt.Errorf("key file of service account has no client_email")
+	}
+
+	signer, err := ParsePrivateKeyPEM([]byte(account.PrivateKey), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok := signer.(*rsa.PrivateKey)
+	if !ok {
+		return nil, ErrInvalidPrivateKey
+	}
+
+	if account.validator, err = NewRSValidator(RS256); err != nil {
+		return nil, err
+	}
+
+	account.validator.PrivateKey = key
+	account.validator.PublicKey = &key.PublicKey
+
+	if account.TokenURI == "" {
+		account.TokenURI = GoogleTokenURL
+	}
+
+	return &account, nil
+}
+
+// Validator returns the RS256 validator of the key of the service account.
+func (a *GoogleServiceAccount) Validator() RSValidator {
+	return a.validator
+}
+
+// Encoder returns an Encoder signing with the key of the service account and
+// naming it in the kid header.
+func (a *GoogleServiceAccount) Encoder() *Encoder {
+	enc := NewEncoder(nil, a.validator)
+	enc.HeaderParams = map[string]interface{}{"kid": a.PrivateKeyID}
+
+	return enc
+}
+
+// Assertion signs a token issued by and about the service account for a given
+// audience and valid for ttl. It authorizes calls to Google APIs without an
+// access token when the audience is the API, e.g.
+// https://pubsub.googleapis.com/, and to an Identity-Aware Proxy when it is the
+// URL of the protected resource.
+func (a *GoogleServiceAccount) Assertion(audience string, ttl time.Duration) (SignedToken, error) {
+	return a.Encoder().Issue(Payload{Issuer: a.ClientEmail, Subject: a.ClientEmail, Audience: Audience{audience}}, ttl)
+}
+
+// TokenSource returns a BearerTokenSource minting access tokens of the service
+// account with the given scopes, e.g.
+// https://www.googleapis.com/auth/cloud-platform.
+func (a *GoogleServiceAccount) TokenSource(scopes ...string) *BearerTokenSource {
+	s := NewBearerTokenSource(a.Encoder(), a.TokenURI, a.ClientEmail)
+	s.Scopes = scopes
+	s.ScopeInAssertion = true
+
+	return s
+}
@@ -0,0 +1,120 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testGoogleServiceAccount(t *testing.T, v RSValidator, tokenURI string) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(v.PrivateKey)
+	if err != nil {
+		t.Fatalf("Didn't expect marshalling a private key to return an error: %s", err)
+	}
+
+	b, _ := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     "project",
+		"private_key_id": "key-1",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"client_email":   "robot@project.iam.gserviceaccount.com",
+		"token_uri":      tokenURI,
+	})
+
+	return b
+}
+
+func TestParseGoogleServiceAccount(t *testing.T) {
+	v := testRSValidator(t)
+
+	account, err := ParseGoogleServiceAccount(testGoogleServiceAccount(t, v, ""))
+	if err != nil {
+		t.Fatalf("Didn't expect parsing a key file to return an error: %s", err)
+	}
+
+	if account.TokenURI != GoogleTokenURL {
+		t.Errorf("Expected the default token endpoint %s; got %s", GoogleTokenURL, account.TokenURI)
+	}
+
+	token, err := account.Assertion("https://pubsub.googleapis.com/", time.Hour)
+	if err != nil {
+		t.Fatalf("Didn't expect signing an assertion to return an error: %s", err)
+	}
+
+	header, err := PeekHeader(string(token))
+	if err != nil || header.Algorithm != RS256 || header.KeyID != "key-1" {
+		t.Errorf("Expected an RS256 header naming the key; got %#v and %v", header, err)
+	}
+
+	var payload Payload
+	if err := NewDecoder(strings.NewReader(string(token)), v).Decode(&payload); err != nil {
+		t.Fatalf("Didn't expect decoding an assertion to return an error: %s", err)
+	}
+
+	if payload.Issuer != account.ClientEmail || payload.Subject != account.ClientEmail || !payload.Audience.Contains("https://pubsub.googleapis.com/") {
+		t.Errorf("Expected the claims of the service account; got %#v", payload)
+	}
+
+	cases := []struct {
+		Reason  string
+		KeyFile string
+	}{
+		{"the key file is not JSON", "{"},
+		{"the key file is not of a service account", `{"type": "authorized_user"}`},
+		{"the key file has no private key", `{"type": "service_account", "client_email": "robot@project.iam.gserviceaccount.com"}`},
+	}
+
+	for _, c := range cases {
+		if _, err := ParseGoogleServiceAccount([]byte(c.KeyFile)); err == nil {
+			t.Errorf("Expected an error when %s", c.Reason)
+		}
+	}
+}
+
+func TestGoogleServiceAccountTokenSource(t *testing.T) {
+	v := testRSValidator(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var claims struct {
+			Payload
+			Scope string `json:"scope"`
+		}
+
+		dec := NewDecoder(strings.NewReader(r.PostFormValue("assertion")), v)
+		if dec.Decode(&claims) != nil || claims.Scope != "https://www.googleapis.com/auth/cloud-platform" || r.PostFormValue("scope") != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "ya29.token", "expires_in": 3600})
+	}))
+	defer server.Close()
+
+	account, err := ParseGoogleServiceAccount(testGoogleServiceAccount(t, v, server.URL))
+	if err != nil {
+		t.Fatalf("Didn't expect parsing a key file to return an error: %s", err)
+	}
+
+	token, err := account.TokenSource("https://www.googleapis.com/auth/cloud-platform").Token()
+	if err != nil || token != "ya29.token" {
+		t.Errorf("Expected an access token minted with the scopes in the assertion; got %q and %v", token, err)
+	}
+}
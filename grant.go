@@ -0,0 +1,171 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JWTBearerGrantType is the grant type of RFC 7523 exchanging a signed
+// assertion for an access token
+const JWTBearerGrantType = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+
+// A BearerTokenSource is a TokenSource of access tokens obtained with the JWT
+// bearer grant of RFC 7523. Each exchange signs a fresh assertion with its
+// Encoder, and the access token is reused until it is about to expire. Access
+// tokens are returned as issued and need not be JWTs. It is safe for
+// concurrent use.
+//
+// A BearerTokenSource implements the TokenSource of this package rather than
+// oauth2.TokenSource so that the package keeps to the standard library.
+type BearerTokenSource struct {
+	// TokenURL is the token endpoint of the authorization server
+	TokenURL string
+	// Issuer and Subject are the iss and sub claims of the assertion. Subject
+	// defaults to Issuer, the client acting on its own behalf.
+	Issuer  string
+	Subject string
+	// Audience is the aud claim of the assertion and defaults to TokenURL
+	Audience string
+	Scopes   []string
+	// ScopeInAssertion sends the scopes in the scope claim of the assertion
+	// instead of the scope parameter of the request, as Google requires
+	ScopeInAssertion bool
+	// Lifetime is how long an assertion is valid and defaults to 5 minutes
+	Lifetime time.Duration
+	// RefreshBefore is how long before its expiry an access token is
+	// exchanged again
+	RefreshBefore time.Duration
+	// Client is used for the exchange and defaults to a client with a
+	// timeout of DefaultFetchTimeout
+	Client *http.Client
+
+	enc    *Encoder
+	mu     sync.Mutex
+	token  SignedToken
+	expiry time.Time
+}
+
+// NewBearerTokenSource constructs a BearerTokenSource signing assertions issued
+// by a given client with an Encoder and exchanging them at a token endpoint.
+func NewBearerTokenSource(enc *Encoder, tokenURL, issuer string) *BearerTokenSource {
+	return &BearerTokenSource{TokenURL: tokenURL, Issuer: issuer, enc: enc}
+}
+
+// Token implements TokenSource. An access token issued without an expiry is
+// exchanged again on every call.
+func (s *BearerTokenSource) Token() (SignedToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && timeFunc().Add(s.RefreshBefore).Before(s.expiry) {
+		return s.token, nil
+	}
+
+	token, expiry, err := s.exchange()
+	if err != nil {
+		return "", err
+	}
+
+	s.token, s.expiry = token, expiry
+
+	return token, nil
+}
+
+// exchange signs an assertion and redeems it for an access token
+func (s *BearerTokenSource) exchange() (SignedToken, time.Time, error) {
+	var payload struct {
+		Payload
+		Scope string `json:"scope,omitempty"`
+	}
+
+	payload.Issuer, payload.Subject, payload.Audience = s.Issuer, s.Subject, Audience{s.Audience}
+	if payload.Subject == "" {
+		payload.Subject = s.Issuer
+	}
+
+	if s.Audience == "" {
+		payload.Audience = Audience{s.TokenURL}
+	}
+
+	lifetime := s.Lifetime
+	if lifetime == 0 {
+		lifetime = 5 * time.Minute
+	}
+
+	if s.ScopeInAssertion {
+		payload.Scope = strings.Join(s.Scopes, " ")
+	}
+
+	assertion, err := s.enc.Issue(payload, lifetime)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	form := url.Values{
+		"grant_type": {JWTBearerGrantType},
+		"assertion":  {string(assertion)},
+	}
+
+	if len(s.Scopes) > 0 && !s.ScopeInAssertion {
+		form.Set("scope", strings.Join(s.Scopes, " "))
+	}
+
+	client := s.Client
+	if client == nil {
+		client = defaultClient
+	}
+
+	resp, err := client.PostForm(s.TokenURL, form)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+
+	// The body of an error may not be JSON and is only decoded on a best
+	// effort basis
+	decodeErr := json.NewDecoder(io.LimitReader(resp.Body, maxKeySetSize)).Decode(&body)
+
+	switch {
+	case resp.StatusCode != http.StatusOK && body.Error != "":
+		return "", time.Time{}, fmt.Errorf("exchanging assertion at %s: %s %s", s.TokenURL, body.Error, body.ErrorDescription)
+	case resp.StatusCode != http.StatusOK:
+		return "", time.Time{}, fmt.Errorf("exchanging assertion at %s: %s", s.TokenURL, resp.Status)
+	case decodeErr != nil:
+		return "", time.Time{}, decodeErr
+	case body.AccessToken == "":
+		return "", time.Time{}, fmt.Errorf("exchanging assertion at %s: no access_token", s.TokenURL)
+	}
+
+	var expiry time.Time
+	if body.ExpiresIn > 0 {
+		expiry = timeFunc().Add(time.Duration(body.ExpiresIn) * time.Second)
+	}
+
+	return SignedToken(body.AccessToken), expiry, nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBearerTokenSource(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	timeFunc = func() time.Time { return now }
+	defer func() { timeFunc = time.Now }()
+
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	var exchanges int
+	var tokenURL string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var assertion Payload
+		dec := NewDecoder(strings.NewReader(r.PostFormValue("assertion")), v)
+		dec.Audience = tokenURL
+
+		if r.PostFormValue("grant_type") != JWTBearerGrantType || dec.Decode(&assertion) != nil || assertion.Subject != "client" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		exchanges++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": fmt.Sprintf("token-%d-%s", exchanges, r.PostFormValue("scope")),
+			"expires_in":   3600,
+		})
+	}))
+	defer server.Close()
+
+	tokenURL = server.URL
+
+	source := NewBearerTokenSource(NewEncoder(nil, v), tokenURL, "client")
+	source.Scopes = []string{"read", "write"}
+	source.RefreshBefore = time.Minute
+
+	cases := []struct {
+		Expected string
+		Reason   string
+		Elapsed  time.Duration
+	}{
+		{"token-1-read write", "no token was exchanged yet", 0},
+		{"token-1-read write", "the token is fresh", 58 * time.Minute},
+		{"token-2-read write", "the token expires within RefreshBefore", time.Minute},
+	}
+
+	for _, c := range cases {
+		now = now.Add(c.Elapsed)
+
+		token, err := source.Token()
+		if err != nil {
+			t.Fatalf("Didn't expect obtaining a token to return an error: %s", err)
+		}
+
+		if string(token) != c.Expected {
+			t.Errorf("Expected %q when %s; got %q", c.Expected, c.Reason, token)
+		}
+	}
+
+	source = NewBearerTokenSource(NewEncoder(nil, v), tokenURL, "other")
+	if _, err := source.Token(); err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Errorf("Expected the error of the token endpoint when the grant is rejected; got %v", err)
+	}
+}
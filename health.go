@@ -0,0 +1,147 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"errors"
+	"time"
+)
+
+// A HealthChecker reports the health of a component tokens are verified with,
+// e.g. a key source or a ReplayStore backed by a remote database. Components
+// set on a Decoder that implement it are included in its Health.
+type HealthChecker interface {
+	Health() ComponentHealth
+}
+
+// ComponentHealth is the health of a component tokens are verified with.
+type ComponentHealth struct {
+	// Name identifies the component, e.g. the URL of a RemoteKeySet
+	Name string `json:"name"`
+	// Healthy reports whether the component can take part in verifying tokens
+	Healthy bool `json:"healthy"`
+	// Error is why the component is unhealthy, or the last error it recovered
+	// from
+	Error string `json:"error,omitempty"`
+	// Refreshed is when the keys of the component were last fetched, or nil
+	// if they never were
+	Refreshed *time.Time `json:"refreshed,omitempty"`
+	// Expires is when the first of the keys of the component expires, or nil
+	// if it has none
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+// Health is the health of the components a Decoder verifies tokens with,
+// suitable for a readiness endpoint.
+type Health struct {
+	// Ready reports whether every component is healthy
+	Ready      bool              `json:"ready"`
+	Components []ComponentHealth `json:"components"`
+}
+
+// Health reports the health of the validator, KeyProvider, Verifiers and
+// ReplayStore of the Decoder that implement HealthChecker. A Decoder without a
+// validator or KeyProvider is never ready.
+func (dec *Decoder) Health() Health {
+	health := Health{Ready: true}
+
+	if dec.verifier() == nil {
+		health.Ready = false
+		health.Components = append(health.Components, ComponentHealth{Name: "validator", Error: ErrNoValidator.Error()})
+	}
+
+	components := []interface{}{dec.validator, dec.KeyProvider, dec.ReplayStore}
+	for _, v := range dec.Verifiers {
+		components = append(components, v)
+	}
+
+	for _, component := range components {
+		if checker, ok := component.(HealthChecker); ok {
+			h := checker.Health()
+			health.Ready = health.Ready && h.Healthy
+			health.Components = append(health.Components, h)
+		}
+	}
+
+	return health
+}
+
+// Health implements HealthChecker. The key set is fetched if it was never
+// fetched, so that a readiness probe warms it up before tokens arrive. Keys
+// past their TTL are unhealthy even though they are still used while the key
+// set cannot be fetched.
+func (s *RemoteKeySet) Health() ComponentHealth {
+	now := timeFunc()
+
+	s.mu.Lock()
+	var f *keySetFetch
+	if s.keys == nil {
+		f = s.startFetch(now)
+	}
+	s.mu.Unlock()
+
+	if f != nil {
+		<-f.done
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	health := ComponentHealth{Name: s.URL}
+
+	if s.keys != nil {
+		refreshed, expires := s.fetched, s.fetched.Add(s.ttl())
+		health.Refreshed, health.Expires = &refreshed, &expires
+		health.Healthy = now.Before(expires)
+	}
+
+	if s.err != nil {
+		health.Error = s.err.Error()
+	}
+
+	return health
+}
+
+// errNoValidCertificates is the Error of the health of a CertificateStore
+// without a certificate in its validity period
+var errNoValidCertificates = errors.New("no certificate in its validity period")
+
+// Health implements HealthChecker. The store is healthy while one of its
+// certificates is in its validity period and expires with the first of them.
+func (s *CertificateStore) Health() ComponentHealth {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := timeFunc()
+	health := ComponentHealth{Name: "certificates"}
+
+	for _, cert := range s.certs {
+		if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+			continue
+		}
+
+		if health.Expires == nil || cert.NotAfter.Before(*health.Expires) {
+			expires := cert.NotAfter
+			health.Expires = &expires
+		}
+
+		health.Healthy = true
+	}
+
+	if !health.Healthy {
+		health.Error = errNoValidCertificates.Error()
+	}
+
+	return health
+}
@@ -0,0 +1,114 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// unreachableStore is a ReplayStore whose database cannot be reached
+type unreachableStore struct{}
+
+func (unreachableStore) Seen(jti string, exp time.Time) bool {
+	return false
+}
+
+func (unreachableStore) Health() ComponentHealth {
+	return ComponentHealth{Name: "replay", Error: "connection refused"}
+}
+
+func TestDecoderHealth(t *testing.T) {
+	defer func() { timeFunc = time.Now }()
+
+	now := time.Now()
+	timeFunc = func() time.Time { return now }
+
+	signer := testRSValidator(t)
+	jwk, _ := NewJSONWebKey(signer.PublicKey)
+	available := true
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !available {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		json.NewEncoder(w).Encode(NewJWKSet(jwk))
+	}))
+	defer server.Close()
+
+	keys := NewRemoteKeySet(server.URL)
+	keys.Client = server.Client()
+
+	if health := NewDecoder(nil, nil).Health(); health.Ready {
+		t.Errorf("Expected a Decoder without a validator not to be ready; got %#v", health)
+	}
+
+	health := NewDecoder(nil, keys).Health()
+	if !health.Ready || len(health.Components) != 1 || health.Components[0].Refreshed == nil || !health.Components[0].Refreshed.Equal(now) {
+		t.Errorf("Expected the health check to fetch the key set; got %#v", health)
+	}
+
+	available = false
+	now = now.Add(DefaultKeySetTTL + time.Minute)
+
+	if _, err := keys.Key(jwk.KeyID); err != nil {
+		t.Fatalf("Expected expired keys to be kept while the key set cannot be fetched; got %s", err)
+	}
+
+	waitForFetch(keys)
+
+	if health := keys.Health(); health.Healthy || health.Error == "" {
+		t.Errorf("Expected keys past their TTL that cannot be fetched to be unhealthy; got %#v", health)
+	}
+
+	dec := NewDecoder(nil, signer)
+	dec.KeyProvider = NewCertificateStore()
+	dec.ReplayStore = unreachableStore{}
+
+	if health := dec.Health(); health.Ready || len(health.Components) != 2 {
+		t.Errorf("Expected every unhealthy component to be reported; got %#v", health)
+	}
+
+	if b, _ := json.Marshal(dec.Health()); bytes.Contains(b, []byte("refreshed")) || bytes.Contains(b, []byte("expires")) {
+		t.Errorf("Expected components without keys to omit when they were refreshed and expire; got %s", b)
+	}
+}
+
+func TestCertificateStoreHealth(t *testing.T) {
+	defer func() { timeFunc = time.Now }()
+
+	leaf, ca := testCertificateChain(t)
+	store := NewCertificateStore(leaf, ca)
+
+	expires := leaf.NotAfter
+	if ca.NotAfter.Before(expires) {
+		expires = ca.NotAfter
+	}
+
+	if health := store.Health(); !health.Healthy || health.Expires == nil || !health.Expires.Equal(expires) {
+		t.Errorf("Expected the store to expire with its first certificate at %s; got %#v", expires, health)
+	}
+
+	timeFunc = func() time.Time { return expires.Add(time.Hour) }
+
+	if health := store.Health(); health.Healthy || health.Expires != nil {
+		t.Errorf("Expected a store of expired certificates to be unhealthy; got %#v", health)
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+)
+
+// A KeyDerivationFunc derives a content encryption key from a master secret
+// and a salt that is unique to a token.
+type KeyDerivationFunc func(master, salt []byte) ([]byte, error)
+
+// HKDF returns a KeyDerivationFunc deriving 32 byte keys with HKDF and SHA-256
+// as described by RFC 5869. The info binds keys to their context, e.g.
+// "sessions/v1", so that keys derived for one purpose are useless for another.
+func HKDF(info string) KeyDerivationFunc {
+	return func(master, salt []byte) ([]byte, error) {
+		return hkdf(master, salt, []byte(info), 32), nil
+	}
+}
+
+// hkdf extracts a pseudorandom key from a secret and expands it to size bytes
+func hkdf(secret, salt, info []byte, size int) []byte {
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(secret)
+
+	expand := hmac.New(sha256.New, extract.Sum(nil))
+	key := make([]byte, 0, size+expand.Size())
+
+	var t []byte
+	for i := byte(1); len(key) < size; i++ {
+		expand.Reset()
+		expand.Write(t)
+		expand.Write(info)
+		expand.Write([]byte{i})
+
+		t = expand.Sum(nil)
+		key = append(key, t...)
+	}
+
+	return key[:size]
+}
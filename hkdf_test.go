@@ -0,0 +1,81 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestHKDF(t *testing.T) {
+	// Test case 1 of RFC 5869
+	secret := bytes.Repeat([]byte{0x0b}, 22)
+	salt, _ := hex.DecodeString("000102030405060708090a0b0c")
+	info, _ := hex.DecodeString("f0f1f2f3f4f5f6f7f8f9")
+	expected := "3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865"
+
+	if key := hex.EncodeToString(hkdf(secret, salt, info, 42)); key != expected {
+		t.Errorf("Expected HKDF to derive %s; got %s", expected, key)
+	}
+}
+
+func TestDerivedKeyEncryptedTokens(t *testing.T) {
+	master := []byte("a master secret of any length")
+
+	enc := NewDirectEncrypter(nil, master)
+	enc.DeriveKey = HKDF("sessions/v1")
+
+	first, err := enc.Seal(&Payload{Subject: "1234567890"})
+	if err != nil {
+		t.Fatalf("Didn't expect encrypting a token with a derived key to return an error: %s", err)
+	}
+
+	second, _ := enc.Seal(&Payload{Subject: "1234567890"})
+
+	if strings.Split(first, ".")[0] == strings.Split(second, ".")[0] {
+		t.Errorf("Expected each token to have its own salt")
+	}
+
+	dec := NewDirectDecrypter(nil, master)
+	dec.DeriveKey = HKDF("sessions/v1")
+
+	payload := &Payload{}
+	if _, err := dec.Open(first, payload); err != nil || payload.Subject != "1234567890" {
+		t.Errorf("Expected the payload of the token; got %#v and %v", payload, err)
+	}
+
+	unsalted, _ := NewDirectEncrypter(nil, bytes.Repeat([]byte{1}, 32)).Seal(&Payload{})
+
+	other := NewDirectDecrypter(nil, master)
+	other.DeriveKey = HKDF("exports/v1")
+
+	cases := []struct {
+		ExpectedError error
+		Reason        string
+		Decrypter     *Decrypter
+		Token         string
+	}{
+		{ErrDecryptionFailed, "the key is derived for another context", other, first},
+		{ErrDecryptionFailed, "the master secret is used as a key", NewDirectDecrypter(nil, master), first},
+		{ErrDecryptionFailed, "the token has no salt", dec, unsalted},
+	}
+
+	for _, c := range cases {
+		if _, err := c.Decrypter.Open(c.Token, &Payload{}); err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		}
+	}
+}
@@ -18,9 +18,10 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"crypto/sha512"
+	"encoding"
 	"encoding/base64"
 	"hash"
-	"strings"
+	"sync"
 )
 
 type hsValidator struct {
@@ -29,6 +30,26 @@ type hsValidator struct {
 	Key       []byte
 }
 
+// separator joins the header and payload of a token when signing
+var separator = []byte(".")
+
+// An hs256Validator is an HS256 validator for hot paths. The hash states after
+// the padded key blocks are computed once and restored into pooled hashes so a
+// verification does not allocate.
+type hs256Validator struct {
+	key        []byte
+	innerState []byte
+	outerState []byte
+	pool       *sync.Pool
+}
+
+// hs256State is the scratch space of one HS256 verification
+type hs256State struct {
+	inner, outer hash.Hash
+	sum          [sha256.Size]byte
+	signature    [2 * sha256.Size]byte
+}
+
 func NewHSValidator(algorithm Algorithm) hsValidator {
 	var hashFunc func() hash.Hash
 	switch algorithm {
@@ -44,16 +65,11 @@ func NewHSValidator(algorithm Algorithm) hsValidator {
 }
 
 func (v hsValidator) validate(jwt *jwt) (bool, error) {
-	b64Signature := string(jwt.Signature)
-	if m := len(b64Signature) % 4; m != 0 {
-		b64Signature += strings.Repeat("=", 4-m)
-	}
-
 	if jwt.Header.Algorithm != v.algorithm {
 		return false, ErrAlgorithmNotImplemented
 	}
 
-	signature, err := base64.URLEncoding.DecodeString(b64Signature)
+	signature, err := parseField(string(jwt.Signature))
 
 	if err != nil {
 		return false, ErrMalformedToken
@@ -72,8 +88,79 @@ func (v hsValidator) sign(jwt *jwt) error {
 	jwt.rawEncode()
 
 	mac := hmac.New(v.hashFunc, v.Key)
-	mac.Write([]byte(strings.Trim(string(jwt.headerRaw), "=") + "." + strings.Trim(string(jwt.payloadRaw), "=")))
+	mac.Write([]byte(string(jwt.headerRaw) + "." + string(jwt.payloadRaw)))
 
-	jwt.Signature = []byte(base64.URLEncoding.EncodeToString(mac.Sum(nil)))
+	jwt.Signature = []byte(encodeSegment(mac.Sum(nil)))
 	return nil
 }
+
+// NewHS256Validator constructs an HS256 validator for a given key that is
+// optimized for verifying many tokens.
+func NewHS256Validator(key []byte) hs256Validator {
+	if len(key) > sha256.BlockSize {
+		sum := sha256.Sum256(key)
+		key = sum[:]
+	}
+
+	ipad := make([]byte, sha256.BlockSize)
+	opad := make([]byte, sha256.BlockSize)
+	copy(ipad, key)
+	copy(opad, key)
+
+	for i := range ipad {
+		ipad[i] ^= 0x36
+		opad[i] ^= 0x5c
+	}
+
+	inner, outer := sha256.New(), sha256.New()
+	inner.Write(ipad)
+	outer.Write(opad)
+
+	v := hs256Validator{key: append([]byte(nil), key...)}
+	v.innerState, _ = inner.(encoding.BinaryMarshaler).MarshalBinary()
+	v.outerState, _ = outer.(encoding.BinaryMarshaler).MarshalBinary()
+	v.pool = &sync.Pool{New: func() interface{} {
+		return &hs256State{inner: sha256.New(), outer: sha256.New()}
+	}}
+
+	return v
+}
+
+func (v hs256Validator) validate(jwt *jwt) (bool, error) {
+	if jwt.Header.Algorithm != HS256 {
+		return false, ErrAlgorithmNotImplemented
+	}
+
+	b64Signature := jwt.Signature
+	for len(b64Signature) > 0 && b64Signature[len(b64Signature)-1] == '=' {
+		b64Signature = b64Signature[:len(b64Signature)-1]
+	}
+
+	st := v.pool.Get().(*hs256State)
+	defer v.pool.Put(st)
+
+	if base64.RawURLEncoding.DecodedLen(len(b64Signature)) > len(st.signature) {
+		return false, nil
+	}
+
+	n, err := base64.RawURLEncoding.Decode(st.signature[:], b64Signature)
+	if err != nil {
+		return false, ErrMalformedToken
+	}
+
+	st.inner.(encoding.BinaryUnmarshaler).UnmarshalBinary(v.innerState)
+	st.inner.Write(jwt.headerRaw)
+	st.inner.Write(separator)
+	st.inner.Write(jwt.payloadRaw)
+	st.inner.Sum(st.sum[:0])
+
+	st.outer.(encoding.BinaryUnmarshaler).UnmarshalBinary(v.outerState)
+	st.outer.Write(st.sum[:])
+	st.outer.Sum(st.sum[:0])
+
+	return hmac.Equal(st.signature[:n], st.sum[:]), nil
+}
+
+func (v hs256Validator) sign(jwt *jwt) error {
+	return hsValidator{HS256, sha256.New, v.key}.sign(jwt)
+}
@@ -0,0 +1,42 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+// A HybridPolicy decides which signatures of a hybrid token must be valid.
+type HybridPolicy string
+
+const (
+	// HybridEither accepts a token with a valid classical or alternative
+	// signature, e.g. while verifiers of the alternative are rolled out
+	HybridEither HybridPolicy = "either"
+	// HybridBoth accepts a token only when both signatures are valid, so
+	// that it stays secure when either scheme is broken
+	HybridBoth HybridPolicy = "both"
+)
+
+// SignHybrid signs a payload with the validator of the Encoder, the classical
+// signature, and with an alternative validator, e.g. an ExternalValidator of a
+// post-quantum scheme. Both sign the same payload and the token is the general
+// JSON serialization of RFC 7515 with the classical signature first.
+func (enc *Encoder) SignHybrid(alt Validator, v interface{}) ([]byte, error) {
+	return enc.SignJSON(v, alt)
+}
+
+// VerifyHybrid verifies a token made by SignHybrid. The classical signature is
+// verified with the validator of the Decoder and the alternative signature
+// with alt, as required by a HybridPolicy. The payload is decoded into v once
+// it passes every other check of the Decoder.
+func (dec *Decoder) VerifyHybrid(b []byte, alt Validator, policy HybridPolicy, v interface{}) error {
+	return dec.verifyJSON(b, []Validator{dec.verifier(), alt}, policy != HybridEither, v)
+}
@@ -0,0 +1,76 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+)
+
+func TestHybridTokens(t *testing.T) {
+	classical := testRSValidator(t)
+
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+	alt := NewExternalValidator("ML-DSA-65", testSigner{key})
+	otherAlt := NewExternalValidator("ML-DSA-65", testSigner{otherKey})
+
+	token, err := NewEncoder(nil, classical).SignHybrid(alt, &Payload{Subject: "1234567890"})
+	if err != nil {
+		t.Fatalf("Didn't expect signing a hybrid token to return an error: %s", err)
+	}
+
+	parsed, err := ParseJSONToken(token)
+	if err != nil || len(parsed.Signatures) != 2 {
+		t.Fatalf("Expected a JSON token with two signatures; got %#v and %v", parsed, err)
+	}
+
+	stripped, _ := json.Marshal(&JSONToken{Payload: parsed.Payload, Signatures: parsed.Signatures[:1]})
+
+	hs := NewHSValidator(HS256)
+	hs.Key = []byte("bogokey")
+
+	cases := []struct {
+		ExpectedError error
+		Reason        string
+		Classical     Validator
+		Alternative   Validator
+		Policy        HybridPolicy
+		Token         []byte
+	}{
+		{nil, "both signatures are valid", classical, alt, HybridBoth, token},
+		{nil, "only the classical signature is valid", classical, otherAlt, HybridEither, token},
+		{nil, "only the alternative signature is valid", hs, alt, HybridEither, token},
+		{ErrBadSignature, "the alternative signature is invalid", classical, otherAlt, HybridBoth, token},
+		{ErrBadSignature, "the classical signature is invalid", hs, alt, HybridBoth, token},
+		{ErrBadSignature, "the alternative signature is stripped", classical, alt, HybridBoth, stripped},
+		{nil, "the alternative signature is stripped but not required", classical, alt, HybridEither, stripped},
+		{ErrBadSignature, "the classical signature also matches the alternative", classical, classical, HybridBoth, stripped},
+		{ErrBadSignature, "no signature is valid", hs, otherAlt, HybridEither, token},
+		{ErrMalformedToken, "the token is compact", classical, alt, HybridEither, []byte(signTestToken(t, classical, Header{Type: "JWT"}, &Payload{}))},
+	}
+
+	for _, c := range cases {
+		payload := &Payload{}
+		err := NewDecoder(nil, c.Classical).VerifyHybrid(c.Token, c.Alternative, c.Policy, payload)
+
+		if err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		} else if err == nil && payload.Subject != "1234567890" {
+			t.Errorf("Expected the payload when %s; got %#v", c.Reason, payload)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"time"
+)
+
+// Issue signs a payload valid for a given ttl from now. The iat and nbf claims
+// are set to now and the exp claim to now plus ttl, replacing any the payload
+// has, and a random jti is set unless the payload has one. The payload must
+// marshal to a JSON object.
+func (enc *Encoder) Issue(v interface{}, ttl time.Duration) (SignedToken, error) {
+	if enc.TagName != "" {
+		v = &taggedPayload{v: v, tag: enc.TagName}
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	var claims RawClaims
+	if err := json.Unmarshal(b, &claims); err != nil || claims == nil {
+		return "", ErrMalformedToken
+	}
+
+	now := timeFunc()
+
+	claims["iat"], _ = json.Marshal(NewNumericDate(now))
+	claims["nbf"] = claims["iat"]
+	claims["exp"], _ = json.Marshal(NewNumericDate(now.Add(ttl)))
+
+	if _, ok := claims["jti"]; !ok {
+		id, err := newTokenID()
+		if err != nil {
+			return "", err
+		}
+
+		claims["jti"], _ = json.Marshal(id)
+	}
+
+	if b, err = json.Marshal(claims); err != nil {
+		return "", err
+	}
+
+	return enc.Sign(json.RawMessage(b))
+}
+
+// newTokenID returns a random jti
+func newTokenID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	return encodeSegment(id), nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIssue(t *testing.T) {
+	defer func() { timeFunc = time.Now }()
+
+	now := time.Unix(1700000000, 0)
+	timeFunc = func() time.Time { return now }
+
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	enc := NewEncoder(nil, v)
+	dec := NewDecoder(nil, v)
+
+	cases := []struct {
+		Reason string
+		Claims interface{}
+		JWTId  string
+	}{
+		{"the payload has no time claims", &Payload{Subject: "1234567890"}, ""},
+		{"the payload has stale time claims", &Payload{Subject: "1234567890", ExpirationTime: NewNumericDate(now.Add(-time.Hour))}, ""},
+		{"the payload has a jti", &Payload{Subject: "1234567890", JWTId: "abc"}, "abc"},
+		{"the payload is a map", map[string]interface{}{"sub": "1234567890"}, ""},
+	}
+
+	for _, c := range cases {
+		token, err := enc.Issue(c.Claims, 15*time.Minute)
+		if err != nil {
+			t.Errorf("Didn't expect issuing a token to return an error when %s: %s", c.Reason, err)
+			continue
+		}
+
+		payload := &Payload{}
+		if err := dec.Verify(string(token), payload); err != nil {
+			t.Errorf("Didn't expect verifying an issued token to return an error when %s: %s", c.Reason, err)
+			continue
+		}
+
+		if !payload.IssuedAt.Equal(now) || !payload.NotBefore.Equal(now) || !payload.ExpirationTime.Equal(now.Add(15*time.Minute)) {
+			t.Errorf("Expected the token to be valid for 15 minutes from %s when %s; got %#v", now, c.Reason, payload)
+		}
+
+		if payload.JWTId == "" || (c.JWTId != "" && payload.JWTId != c.JWTId) || payload.Subject != "1234567890" {
+			t.Errorf("Expected the claims and a jti when %s; got %#v", c.Reason, payload)
+		}
+	}
+
+	first, _ := enc.Issue(&Payload{}, time.Minute)
+	second, _ := enc.Issue(&Payload{}, time.Minute)
+
+	if first == second {
+		t.Errorf("Expected every issued token to have its own jti")
+	}
+
+	if _, err := enc.Issue("not an object", time.Minute); err != ErrMalformedToken {
+		t.Errorf("Expected issuing a payload that is not an object to return %s; got %v", ErrMalformedToken, err)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// ErrInvalidRequestObject is returned when a request object breaks the rules
+// of RFC 9101
+var ErrInvalidRequestObject = errors.New("invalid request object")
+
+// RequestObjectType is the typ header of request objects
+const RequestObjectType = "oauth-authz-req+jwt"
+
+// A RequestObject is an authorization request passed as the claims of a
+// signed token as described by RFC 9101, so that its parameters cannot be
+// tampered with on their way through the browser.
+type RequestObject struct {
+	// Issuer is the client id of the client making the request
+	Issuer string `json:"iss,omitempty"`
+	// Audience is the issuer of the authorization server
+	Audience       Audience     `json:"aud,omitempty"`
+	ExpirationTime *NumericDate `json:"exp,omitempty"`
+	NotBefore      *NumericDate `json:"nbf,omitempty"`
+	IssuedAt       *NumericDate `json:"iat,omitempty"`
+	JWTId          string       `json:"jti,omitempty"`
+
+	ClientID            string `json:"client_id"`
+	ResponseType        string `json:"response_type,omitempty"`
+	RedirectURI         string `json:"redirect_uri,omitempty"`
+	Scope               string `json:"scope,omitempty"`
+	State               string `json:"state,omitempty"`
+	Nonce               string `json:"nonce,omitempty"`
+	CodeChallenge       string `json:"code_challenge,omitempty"`
+	CodeChallengeMethod string `json:"code_challenge_method,omitempty"`
+}
+
+// SignRequestObject signs a request object valid for ttl from now. Its iss
+// defaults to its client_id and its iat, nbf and exp claims are set to now and
+// now plus ttl. A random jti is set unless it has one.
+func (enc *Encoder) SignRequestObject(ro RequestObject, ttl time.Duration) (SignedToken, error) {
+	if ro.Issuer == "" {
+		ro.Issuer = ro.ClientID
+	}
+
+	now := timeFunc()
+	ro.IssuedAt, ro.NotBefore, ro.ExpirationTime = NewNumericDate(now), NewNumericDate(now), NewNumericDate(now.Add(ttl))
+
+	if ro.JWTId == "" {
+		id, err := newTokenID()
+		if err != nil {
+			return "", err
+		}
+
+		ro.JWTId = id
+	}
+
+	return enc.SignHeader(Header{Type: RequestObjectType}, ro)
+}
+
+// AuthorizationRequestURL returns the URL of an authorization endpoint passing
+// a signed request object by value. The client_id is repeated outside of the
+// request object as RFC 9101 requires.
+func AuthorizationRequestURL(endpoint, clientID string, request SignedToken) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	query := u.Query()
+	query.Set("client_id", clientID)
+	query.Set("request", string(request))
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// NewRequestObjectDecoder constructs a Decoder for the request objects sent to
+// an authorization server of a given issuer. Request objects must have the
+// oauth-authz-req+jwt typ header, name the issuer in their aud claim and have
+// a client_id claim equal to their iss claim, and may not nest request or
+// request_uri claims. The validator verifies the keys of clients, e.g. a
+// KeyProvider selecting them by client.
+func NewRequestObjectDecoder(v Validator, issuer string) *Decoder {
+	dec := NewDecoder(nil, v)
+	dec.Types = []string{RequestObjectType}
+	dec.Audience = issuer
+
+	dec.AddValidation(func(claims RawClaims) error {
+		for _, name := range []string{"request", "request_uri"} {
+			if _, ok := claims[name]; ok {
+				return fmt.Errorf("%w: nested %s", ErrInvalidRequestObject, name)
+			}
+		}
+
+		var clientID, issuer string
+		if err := claims.Decode("client_id", &clientID); err != nil || clientID == "" {
+			return fmt.Errorf("%w: %s", ErrMissingClaim, "client_id")
+		}
+
+		if err := claims.Decode("iss", &issuer); err == nil && issuer != clientID {
+			return fmt.Errorf("%w: iss is not the client_id", ErrInvalidRequestObject)
+		}
+
+		return nil
+	})
+
+	return dec
+}
+
+// VerifyRequestObject verifies a request object received by an authorization
+// server from the client with a given client_id parameter, which must match
+// the client_id claim of the request object.
+func (dec *Decoder) VerifyRequestObject(token, clientID string) (*RequestObject, error) {
+	var ro RequestObject
+	if err := dec.Verify(token, &ro); err != nil {
+		return nil, err
+	}
+
+	if ro.ClientID != clientID {
+		return nil, fmt.Errorf("%w: client_id does not match", ErrInvalidRequestObject)
+	}
+
+	return &ro, nil
+}
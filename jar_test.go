@@ -0,0 +1,83 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestRequestObject(t *testing.T) {
+	v := testRSValidator(t)
+	enc := NewEncoder(nil, v)
+
+	request, err := enc.SignRequestObject(RequestObject{
+		Audience:     Audience{"https://as.example.com"},
+		ClientID:     "client",
+		ResponseType: "code",
+		RedirectURI:  "https://client.example.com/callback",
+		State:        "af0ifjsldkj",
+	}, time.Minute)
+	if err != nil {
+		t.Fatalf("Didn't expect signing a request object to return an error: %s", err)
+	}
+
+	location, err := AuthorizationRequestURL("https://as.example.com/authorize", "client", request)
+	if err != nil {
+		t.Fatalf("Didn't expect building an authorization request to return an error: %s", err)
+	}
+
+	u, _ := url.Parse(location)
+	if u.Query().Get("client_id") != "client" || u.Query().Get("request") != string(request) {
+		t.Errorf("Expected the client_id and request parameters; got %s", location)
+	}
+
+	dec := NewRequestObjectDecoder(v, "https://as.example.com")
+
+	ro, err := dec.VerifyRequestObject(u.Query().Get("request"), "client")
+	if err != nil {
+		t.Fatalf("Didn't expect verifying a request object to return an error: %s", err)
+	}
+
+	if ro.Issuer != "client" || ro.State != "af0ifjsldkj" || ro.JWTId == "" || ro.ExpirationTime == nil {
+		t.Errorf("Expected the parameters of the request; got %#v", ro)
+	}
+
+	nested := struct {
+		RequestObject
+		RequestURI string `json:"request_uri"`
+	}{RequestObject{Issuer: "client", Audience: Audience{"https://as.example.com"}, ClientID: "client"}, "https://client.example.com/request"}
+
+	cases := []struct {
+		ExpectedError error
+		Reason        string
+		Token         string
+		ClientID      string
+	}{
+		{ErrInvalidRequestObject, "the client_id parameter does not match", string(request), "other"},
+		{ErrInvalidType, "the token is not a request object", signTestToken(t, v, Header{Type: "JWT"}, &RequestObject{Issuer: "client", Audience: Audience{"https://as.example.com"}, ClientID: "client"}), "client"},
+		{ErrInvalidAudience, "the request object is meant for another server", signTestToken(t, v, Header{Type: RequestObjectType}, &RequestObject{Issuer: "client", Audience: Audience{"https://other.example.com"}, ClientID: "client"}), "client"},
+		{ErrInvalidRequestObject, "the issuer is not the client", signTestToken(t, v, Header{Type: RequestObjectType}, &RequestObject{Issuer: "other", Audience: Audience{"https://as.example.com"}, ClientID: "client"}), "client"},
+		{ErrMissingClaim, "the request object has no client_id", signTestToken(t, v, Header{Type: RequestObjectType}, &RequestObject{Audience: Audience{"https://as.example.com"}}), ""},
+		{ErrInvalidRequestObject, "the request object nests a request_uri", signTestToken(t, v, Header{Type: RequestObjectType}, &nested), "client"},
+	}
+
+	for _, c := range cases {
+		if _, err := dec.VerifyRequestObject(c.Token, c.ClientID); !errors.Is(err, c.ExpectedError) {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		}
+	}
+}
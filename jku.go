@@ -0,0 +1,88 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// ErrUntrustedKeySet is returned when the jku header of a token names a key
+// set that is not allowed
+var ErrUntrustedKeySet = errors.New("untrusted key set url")
+
+// A JKUKeyProvider is a KeyProvider that verifies tokens with the key named by
+// their kid in the key set their jku header points to. Only key sets in an
+// allowlist are fetched so that tokens cannot make the verifier request
+// arbitrary URLs, nor name a key set of their own making. Each key set is
+// cached as a RemoteKeySet. It is safe for concurrent use.
+type JKUKeyProvider struct {
+	// AllowedURLs are the exact URLs of the key sets tokens may name
+	AllowedURLs []string
+	// Client fetches the key sets. A client with a timeout of
+	// DefaultFetchTimeout is used when nil.
+	Client *http.Client
+
+	mu   sync.Mutex
+	sets map[string]*RemoteKeySet
+}
+
+// NewJKUKeyProvider constructs a JKUKeyProvider allowing the key sets at the
+// given URLs.
+func NewJKUKeyProvider(urls ...string) *JKUKeyProvider {
+	return &JKUKeyProvider{AllowedURLs: urls}
+}
+
+// VerificationKey implements KeyProvider.
+func (p *JKUKeyProvider) VerificationKey(h Header) (interface{}, error) {
+	if h.JWKSetURL == "" {
+		return nil, ErrUnknownKey
+	}
+
+	set, err := p.keySet(h.JWKSetURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return set.Key(h.KeyID)
+}
+
+// keySet returns the cached key set at an allowed URL
+func (p *JKUKeyProvider) keySet(url string) (*RemoteKeySet, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if set, ok := p.sets[url]; ok {
+		return set, nil
+	}
+
+	for _, allowed := range p.AllowedURLs {
+		if url != allowed {
+			continue
+		}
+
+		if p.sets == nil {
+			p.sets = map[string]*RemoteKeySet{}
+		}
+
+		set := NewRemoteKeySet(url)
+		set.Client = p.Client
+		p.sets[url] = set
+
+		return set, nil
+	}
+
+	return nil, ErrUntrustedKeySet
+}
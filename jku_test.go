@@ -0,0 +1,62 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestJKUKeyProvider(t *testing.T) {
+	signer := testRSValidator(t)
+	jwk, _ := NewJSONWebKey(signer.PublicKey)
+	jwk.KeyID = "k1"
+
+	fetches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		json.NewEncoder(w).Encode(NewJWKSet(jwk))
+	}))
+	defer server.Close()
+
+	provider := NewJKUKeyProvider(server.URL + "/keys")
+	provider.Client = server.Client()
+
+	cases := []struct {
+		ExpectedError error
+		Reason        string
+		Header        Header
+	}{
+		{nil, "the key set is allowed", Header{Type: "JWT", KeyID: "k1", JWKSetURL: server.URL + "/keys"}},
+		{ErrUnknownKey, "the kid is not in the key set", Header{Type: "JWT", KeyID: "k2", JWKSetURL: server.URL + "/keys"}},
+		{ErrUntrustedKeySet, "the key set is not allowed", Header{Type: "JWT", KeyID: "k1", JWKSetURL: server.URL + "/other"}},
+		{ErrUntrustedKeySet, "the key set only shares a prefix with an allowed one", Header{Type: "JWT", KeyID: "k1", JWKSetURL: server.URL + "/keys/../other"}},
+		{ErrUnknownKey, "the token has no jku", Header{Type: "JWT", KeyID: "k1"}},
+	}
+
+	for _, c := range cases {
+		dec := NewDecoder(nil, nil)
+		dec.KeyProvider = provider
+
+		if err := dec.Verify(signTestToken(t, signer, c.Header, &Payload{}), &Payload{}); err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		}
+	}
+
+	if fetches != 1 {
+		t.Errorf("Expected only the allowed key set to be fetched once; got %d fetches", fetches)
+	}
+}
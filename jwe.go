@@ -0,0 +1,369 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+)
+
+const (
+	// RSAOAEP is the RSAES-OAEP key encryption algorithm of encrypted tokens
+	RSAOAEP = "RSA-OAEP"
+	// Direct is the key management algorithm of tokens encrypted with a
+	// content encryption key shared in advance
+	Direct = "dir"
+	// A128KW is the AES Key Wrap key management algorithm with a 128 bit key
+	// encryption key
+	A128KW = "A128KW"
+	// A192KW is the AES Key Wrap key management algorithm with a 192 bit key
+	// encryption key
+	A192KW = "A192KW"
+	// A256KW is the AES Key Wrap key management algorithm with a 256 bit key
+	// encryption key
+	A256KW = "A256KW"
+	// A256GCM is the AES-256 GCM content encryption algorithm of encrypted
+	// tokens
+	A256GCM = "A256GCM"
+)
+
+// ErrDecryptionFailed is returned when an encrypted token cannot be decrypted
+// with the given key or was tampered with
+var ErrDecryptionFailed = errors.New("token cannot be decrypted")
+
+// An Encrypter writes encrypted tokens in the five segment compact
+// serialization of RFC 7516, so that claims such as personal data can only be
+// read by their recipients. The payload is encrypted with A256GCM under a
+// content encryption key that is either encrypted to an RSA public key with
+// RSAOAEP, wrapped under a shared key encryption key with A128KW, A192KW or
+// A256KW or one derived from a password with PBES2, or shared in advance with
+// Direct.
+type Encrypter struct {
+	w   io.Writer
+	alg Algorithm
+	key *rsa.PublicKey
+	// secret is the shared content encryption key, key encryption key or
+	// password
+	secret []byte
+	// KeyID, if set, is the kid header naming the key tokens are encrypted to
+	KeyID string
+	// PBES2Count is the number of PBKDF2 iterations keys are derived from
+	// passwords with. DefaultPBES2Count is used when it is zero.
+	PBES2Count int
+	// DeriveKey, if set, derives the content encryption key of each Direct
+	// token from the shared secret and a new salt, e.g. with HKDF, so that
+	// the secret is not used as a key itself.
+	DeriveKey KeyDerivationFunc
+}
+
+// A Decrypter reads encrypted tokens written by an Encrypter.
+type Decrypter struct {
+	source TokenSourceReader
+	alg    Algorithm
+	key    *rsa.PrivateKey
+	secret []byte
+	// DeriveKey, if set, derives the content encryption key of each Direct
+	// token from the shared secret and the salt of the token. It must match
+	// the DeriveKey of the Encrypter.
+	DeriveKey KeyDerivationFunc
+}
+
+// NewEncrypter creates an Encrypter that writes tokens encrypted to a given
+// public key to w.
+func NewEncrypter(w io.Writer, key *rsa.PublicKey) *Encrypter {
+	return &Encrypter{w: w, alg: RSAOAEP, key: key}
+}
+
+// NewDirectEncrypter creates an Encrypter that writes tokens encrypted with a
+// given 32 byte content encryption key shared with their recipients to w.
+func NewDirectEncrypter(w io.Writer, cek []byte) *Encrypter {
+	return &Encrypter{w: w, alg: Direct, secret: cek}
+}
+
+// NewKeyWrapEncrypter creates an Encrypter that writes tokens whose content
+// encryption keys are wrapped under a given key encryption key shared with
+// their recipients to w. The size of the key selects A128KW, A192KW or A256KW.
+func NewKeyWrapEncrypter(w io.Writer, kek []byte) *Encrypter {
+	return &Encrypter{w: w, alg: keyWrapAlgorithm(kek), secret: kek}
+}
+
+// NewDecrypter creates a Decrypter of the white space separated tokens read
+// from r with a given private key.
+func NewDecrypter(r io.Reader, key *rsa.PrivateKey) *Decrypter {
+	return &Decrypter{source: StreamSource(r), alg: RSAOAEP, key: key}
+}
+
+// NewDirectDecrypter creates a Decrypter of the white space separated tokens
+// read from r with a given 32 byte content encryption key shared with their
+// issuer.
+func NewDirectDecrypter(r io.Reader, cek []byte) *Decrypter {
+	return &Decrypter{source: StreamSource(r), alg: Direct, secret: cek}
+}
+
+// NewKeyWrapDecrypter creates a Decrypter of the white space separated tokens
+// read from r whose content encryption keys are wrapped under a given key
+// encryption key shared with their issuer.
+func NewKeyWrapDecrypter(r io.Reader, kek []byte) *Decrypter {
+	return &Decrypter{source: StreamSource(r), alg: keyWrapAlgorithm(kek), secret: kek}
+}
+
+// Encrypt writes a token with the given payload encrypted to the underlying
+// writer.
+func (e *Encrypter) Encrypt(v interface{}) error {
+	token, err := e.Seal(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(e.w, token)
+
+	return err
+}
+
+// Seal returns a token with the given payload encrypted. A SignedToken is
+// encrypted as is with a cty header of NestedContentType, so that tokens can
+// be signed and then encrypted; any other payload is encoded as JSON.
+func (e *Encrypter) Seal(v interface{}) (string, error) {
+	header := Header{Algorithm: e.alg, Encryption: A256GCM, KeyID: e.KeyID}
+
+	var plaintext []byte
+	if token, ok := v.(SignedToken); ok {
+		header.ContentType = NestedContentType
+		plaintext = []byte(token)
+	} else {
+		var err error
+		if plaintext, err = json.Marshal(v); err != nil {
+			return "", err
+		}
+	}
+
+	cek, encryptedKey, err := e.contentKey(&header)
+	if err != nil {
+		return "", err
+	}
+
+	h, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+
+	iv := make([]byte, 12)
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return "", err
+	}
+
+	aead, err := contentCipher(cek)
+	if err != nil {
+		return "", err
+	}
+
+	protected := encodeSegment(h)
+	sealed := aead.Seal(nil, iv, plaintext, []byte(protected))
+	ciphertext, tag := sealed[:len(sealed)-aead.Overhead()], sealed[len(sealed)-aead.Overhead():]
+
+	return strings.Join([]string{
+		protected,
+		encodeSegment(encryptedKey),
+		encodeSegment(iv),
+		encodeSegment(ciphertext),
+		encodeSegment(tag),
+	}, "."), nil
+}
+
+// Decrypt consumes the next token from the underlying reader and decrypts its
+// payload into v. It returns io.EOF when no tokens are left.
+func (d *Decrypter) Decrypt(v interface{}) error {
+	token, err := d.source.ReadToken()
+	if err != nil {
+		return err
+	}
+
+	_, err = d.Open(token, v)
+
+	return err
+}
+
+// Open decrypts a token and decodes its payload into v, which may be a
+// *SignedToken for tokens with a cty header of NestedContentType. The
+// protected header of the token is returned.
+func (d *Decrypter) Open(token string, v interface{}) (*Header, error) {
+	fields := strings.Split(token, ".")
+	if len(fields) != 5 {
+		return nil, ErrMalformedToken
+	}
+
+	segments := make([][]byte, len(fields))
+	for i, field := range fields {
+		var err error
+		if segments[i], err = parseField(field); err != nil {
+			return nil, ErrMalformedToken
+		}
+	}
+
+	header := &Header{}
+	if err := json.Unmarshal(segments[0], header); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	if header.Encryption != A256GCM {
+		return nil, ErrAlgorithmNotImplemented
+	}
+
+	cek, err := d.contentKey(header, segments[1])
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := contentCipher(cek)
+	if err != nil || len(segments[2]) != aead.NonceSize() {
+		return nil, ErrDecryptionFailed
+	}
+
+	sealed := append(segments[3], segments[4]...)
+
+	plaintext, err := aead.Open(nil, segments[2], sealed, []byte(fields[0]))
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+
+	if token, ok := v.(*SignedToken); ok {
+		*token = SignedToken(plaintext)
+		return header, nil
+	}
+
+	if err := json.Unmarshal(plaintext, v); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	return header, nil
+}
+
+// contentKey returns the content encryption key of a new token and the
+// encrypted key sent with it, which is empty for Direct. The parameters of the
+// key management algorithm are added to the header.
+func (e *Encrypter) contentKey(header *Header) ([]byte, []byte, error) {
+	if e.alg == Direct {
+		cek := e.secret
+
+		if e.DeriveKey != nil {
+			salt := make([]byte, 16)
+			if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+				return nil, nil, err
+			}
+
+			var err error
+			if cek, err = e.DeriveKey(e.secret, salt); err != nil {
+				return nil, nil, err
+			}
+
+			header.KeySalt = encodeSegment(salt)
+		}
+
+		if len(cek) != 32 {
+			return nil, nil, ErrAlgorithmNotImplemented
+		}
+
+		return cek, nil, nil
+	}
+
+	cek := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, cek); err != nil {
+		return nil, nil, err
+	}
+
+	var encryptedKey []byte
+	var err error
+
+	switch e.alg {
+	case RSAOAEP:
+		encryptedKey, err = rsa.EncryptOAEP(sha1.New(), rand.Reader, e.key, cek, nil)
+	case A128KW, A192KW, A256KW:
+		encryptedKey, err = wrapKey(e.secret, cek)
+	case PBES2HS256A128KW, PBES2HS384A192KW, PBES2HS512A256KW:
+		encryptedKey, err = e.passwordWrap(header, cek)
+	default:
+		err = ErrAlgorithmNotImplemented
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cek, encryptedKey, nil
+}
+
+// contentKey returns the content encryption key of a token with a given
+// header and encrypted key. A token must use the algorithm the Decrypter was
+// created for.
+func (d *Decrypter) contentKey(header *Header, encryptedKey []byte) ([]byte, error) {
+	alg := header.Algorithm
+
+	if d.alg == pbes2 && isPBES2(alg) {
+		return d.passwordUnwrap(header, encryptedKey)
+	}
+
+	if alg != d.alg || alg == "" {
+		return nil, ErrAlgorithmNotImplemented
+	}
+
+	var cek []byte
+	var err error
+
+	switch alg {
+	case Direct:
+		if len(encryptedKey) != 0 || (header.KeySalt != "") != (d.DeriveKey != nil) {
+			return nil, ErrDecryptionFailed
+		}
+
+		cek = d.secret
+
+		if d.DeriveKey != nil {
+			salt, err := parseField(header.KeySalt)
+			if err != nil {
+				return nil, ErrDecryptionFailed
+			}
+
+			if cek, err = d.DeriveKey(d.secret, salt); err != nil {
+				return nil, err
+			}
+		}
+	case RSAOAEP:
+		cek, err = rsa.DecryptOAEP(sha1.New(), nil, d.key, encryptedKey, nil)
+	default:
+		cek, err = unwrapKey(d.secret, encryptedKey)
+	}
+
+	if err != nil || len(cek) != 32 {
+		return nil, ErrDecryptionFailed
+	}
+
+	return cek, nil
+}
+
+// contentCipher returns the A256GCM cipher of a content encryption key
+func contentCipher(cek []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
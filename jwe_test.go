@@ -0,0 +1,148 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestEncryptedTokens(t *testing.T) {
+	key := testRSValidator(t).PrivateKey
+
+	enc := NewEncrypter(nil, &key.PublicKey)
+	enc.KeyID = "k1"
+
+	token, err := enc.Seal(&Payload{Subject: "1234567890"})
+	if err != nil {
+		t.Fatalf("Didn't expect encrypting a token to return an error: %s", err)
+	}
+
+	if segments := strings.Split(token, "."); len(segments) != 5 {
+		t.Fatalf("Expected an encrypted token to have 5 segments; got %d", len(segments))
+	}
+
+	payload := &Payload{}
+	header, err := NewDecrypter(nil, key).Open(token, payload)
+	if err != nil || payload.Subject != "1234567890" || header.KeyID != "k1" || header.Encryption != A256GCM {
+		t.Errorf("Expected the payload and header of the token; got %#v, %#v and %v", payload, header, err)
+	}
+
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Recieved error when generating test key: %s", err)
+	}
+
+	tamper := func(i int, segment string) string {
+		segments := strings.Split(token, ".")
+		segments[i] = segment
+		return strings.Join(segments, ".")
+	}
+
+	cases := []struct {
+		ExpectedError error
+		Reason        string
+		Token         string
+		Key           *rsa.PrivateKey
+	}{
+		{ErrDecryptionFailed, "the token is encrypted to another key", token, other},
+		{ErrDecryptionFailed, "the ciphertext is modified", tamper(3, encodeSegment([]byte("forged"))), key},
+		{ErrDecryptionFailed, "the header is modified", tamper(0, encodeSegment([]byte(`{"alg":"RSA-OAEP","enc":"A256GCM"}`))), key},
+		{ErrAlgorithmNotImplemented, "the content encryption is not supported", tamper(0, encodeSegment([]byte(`{"alg":"RSA-OAEP","enc":"A128CBC-HS256"}`))), key},
+		{ErrMalformedToken, "the token is signed rather than encrypted", signTestToken(t, testRSValidator(t), Header{Type: "JWT"}, &Payload{}), key},
+	}
+
+	for _, c := range cases {
+		if _, err := NewDecrypter(nil, c.Key).Open(c.Token, &Payload{}); err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		}
+	}
+}
+
+func TestEncryptNestedToken(t *testing.T) {
+	key := testRSValidator(t).PrivateKey
+
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	signed, _ := NewEncoder(nil, v).Sign(&Payload{Subject: "1234567890"})
+
+	buf := bytes.NewBuffer(nil)
+	if err := NewEncrypter(buf, &key.PublicKey).Encrypt(signed); err != nil {
+		t.Fatalf("Didn't expect encrypting a signed token to return an error: %s", err)
+	}
+
+	dec := NewDecrypter(buf, key)
+
+	var inner SignedToken
+	if err := dec.Decrypt(&inner); err != nil || inner != signed {
+		t.Fatalf("Expected the signed token to be decrypted as is; got %s and %v", inner, err)
+	}
+
+	if err := NewDecoder(nil, v).Verify(string(inner), &Payload{}); err != nil {
+		t.Errorf("Didn't expect verifying the decrypted token to return an error: %s", err)
+	}
+
+	if err := dec.Decrypt(&inner); err != io.EOF {
+		t.Errorf("Expected an exhausted reader to return %s; got %v", io.EOF, err)
+	}
+}
+
+func TestDirectEncryptedTokens(t *testing.T) {
+	cek := []byte("0123456789abcdef0123456789abcdef")
+
+	token, err := NewDirectEncrypter(nil, cek).Seal(&Payload{Subject: "1234567890"})
+	if err != nil {
+		t.Fatalf("Didn't expect encrypting a token with a shared key to return an error: %s", err)
+	}
+
+	if segments := strings.Split(token, "."); len(segments) != 5 || segments[1] != "" {
+		t.Fatalf("Expected a direct token to have an empty encrypted key; got %s", token)
+	}
+
+	payload := &Payload{}
+	header, err := NewDirectDecrypter(nil, cek).Open(token, payload)
+	if err != nil || payload.Subject != "1234567890" || header.Algorithm != Direct {
+		t.Errorf("Expected the payload and header of the token; got %#v, %#v and %v", payload, header, err)
+	}
+
+	key := testRSValidator(t).PrivateKey
+	wrapped, _ := NewEncrypter(nil, &key.PublicKey).Seal(&Payload{})
+
+	cases := []struct {
+		ExpectedError error
+		Reason        string
+		Token         string
+		Decrypter     *Decrypter
+	}{
+		{ErrDecryptionFailed, "the shared key differs", token, NewDirectDecrypter(nil, []byte("fedcba9876543210fedcba9876543210"))},
+		{ErrDecryptionFailed, "the shared key is too short", token, NewDirectDecrypter(nil, cek[:16])},
+		{ErrAlgorithmNotImplemented, "a direct token is given to an RSA decrypter", token, NewDecrypter(nil, key)},
+		{ErrAlgorithmNotImplemented, "an RSA token is given to a direct decrypter", wrapped, NewDirectDecrypter(nil, cek)},
+	}
+
+	for _, c := range cases {
+		if _, err := c.Decrypter.Open(c.Token, &Payload{}); err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		}
+	}
+
+	if _, err := NewDirectEncrypter(nil, cek[:16]).Seal(&Payload{}); err != ErrAlgorithmNotImplemented {
+		t.Errorf("Expected a shared key unsuited to A256GCM to return %s; got %v", ErrAlgorithmNotImplemented, err)
+	}
+}
@@ -0,0 +1,266 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"math/big"
+)
+
+// A JSONWebKey is a key as described by RFC 7517. Keys made from private keys
+// carry their private members; use Public before publishing them.
+type JSONWebKey struct {
+	KeyType   string    `json:"kty"`
+	KeyID     string    `json:"kid,omitempty"`
+	Algorithm Algorithm `json:"alg,omitempty"`
+	Use       string    `json:"use,omitempty"`
+	Curve     string    `json:"crv,omitempty"`
+	X         string    `json:"x,omitempty"`
+	Y         string    `json:"y,omitempty"`
+	Exponent  string    `json:"e,omitempty"`
+	Modulus   string    `json:"n,omitempty"`
+
+	// Private members as described by RFC 7518 section 6.
+	D                string `json:"d,omitempty"`
+	FirstPrime       string `json:"p,omitempty"`
+	SecondPrime      string `json:"q,omitempty"`
+	FirstExponent    string `json:"dp,omitempty"`
+	SecondExponent   string `json:"dq,omitempty"`
+	FirstCoefficient string `json:"qi,omitempty"`
+}
+
+// NewJSONWebKey describes an RSA, elliptic curve or Ed25519 key as a
+// JSONWebKey. Private keys include their private members.
+func NewJSONWebKey(key interface{}) (*JSONWebKey, error) {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return &JSONWebKey{
+			KeyType:  "RSA",
+			Exponent: encodeSegment(big.NewInt(int64(k.E)).Bytes()),
+			Modulus:  encodeSegment(k.N.Bytes()),
+		}, nil
+	case *rsa.PrivateKey:
+		// Keys with more than two primes would need the oth member
+		if len(k.Primes) != 2 {
+			return nil, ErrAlgorithmNotImplemented
+		}
+
+		jwk, _ := NewJSONWebKey(&k.PublicKey)
+		p, q := k.Primes[0], k.Primes[1]
+		one := big.NewInt(1)
+
+		jwk.D = encodeSegment(k.D.Bytes())
+		jwk.FirstPrime = encodeSegment(p.Bytes())
+		jwk.SecondPrime = encodeSegment(q.Bytes())
+		jwk.FirstExponent = encodeSegment(new(big.Int).Mod(k.D, new(big.Int).Sub(p, one)).Bytes())
+		jwk.SecondExponent = encodeSegment(new(big.Int).Mod(k.D, new(big.Int).Sub(q, one)).Bytes())
+		jwk.FirstCoefficient = encodeSegment(new(big.Int).ModInverse(q, p).Bytes())
+
+		return jwk, nil
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+
+		return &JSONWebKey{
+			KeyType: "EC",
+			Curve:   k.Curve.Params().Name,
+			X:       encodeSegment(k.X.FillBytes(make([]byte, size))),
+			Y:       encodeSegment(k.Y.FillBytes(make([]byte, size))),
+		}, nil
+	case *ecdsa.PrivateKey:
+		jwk, _ := NewJSONWebKey(&k.PublicKey)
+		jwk.D = encodeSegment(k.D.FillBytes(make([]byte, (k.Curve.Params().BitSize+7)/8)))
+
+		return jwk, nil
+	case ed25519.PublicKey:
+		return &JSONWebKey{KeyType: "OKP", Curve: "Ed25519", X: encodeSegment(k)}, nil
+	case ed25519.PrivateKey:
+		jwk, _ := NewJSONWebKey(k.Public())
+		jwk.D = encodeSegment(k.Seed())
+
+		return jwk, nil
+	}
+
+	return nil, ErrAlgorithmNotImplemented
+}
+
+// Public returns a copy of the key without its private members.
+func (k *JSONWebKey) Public() *JSONWebKey {
+	return &JSONWebKey{
+		KeyType:   k.KeyType,
+		KeyID:     k.KeyID,
+		Algorithm: k.Algorithm,
+		Use:       k.Use,
+		Curve:     k.Curve,
+		X:         k.X,
+		Y:         k.Y,
+		Exponent:  k.Exponent,
+		Modulus:   k.Modulus,
+	}
+}
+
+// PublicKey returns the RSA or elliptic curve public key the JSONWebKey
+// describes.
+func (k *JSONWebKey) PublicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := parseField(k.Modulus)
+		if err != nil {
+			return nil, ErrMalformedToken
+		}
+
+		e, err := parseField(k.Exponent)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, ErrMalformedToken
+		}
+
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, ErrAlgorithmNotImplemented
+		}
+
+		x, err := parseField(k.X)
+		if err != nil {
+			return nil, ErrMalformedToken
+		}
+
+		y, err := parseField(k.Y)
+		if err != nil {
+			return nil, ErrMalformedToken
+		}
+
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, ErrMalformedToken
+		}
+
+		return key, nil
+	}
+
+	return nil, ErrAlgorithmNotImplemented
+}
+
+// Thumbprint returns the base64url encoded SHA-256 JWK thumbprint of the key
+// as described by RFC 7638.
+func (k *JSONWebKey) Thumbprint() string {
+	required := JSONWebKey{KeyType: k.KeyType, Curve: k.Curve, X: k.X, Y: k.Y, Exponent: k.Exponent, Modulus: k.Modulus}
+	b, _ := json.Marshal(required)
+
+	// The thumbprint is taken over the required members in lexicographic
+	// order, which is the canonical form of a key with no optional members
+	b, _ = CanonicalizeJCS(b)
+
+	sum := sha256.Sum256(b)
+	return encodeSegment(sum[:])
+}
+
+// A JWKSet is a JSON Web Key Set as described by RFC 7517, such as the
+// documents identity providers publish their signing keys in.
+type JWKSet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// ParseJWKSet parses a {"keys":[...]} document. Keys of types this package
+// cannot verify with are kept so they can still be looked up.
+func ParseJWKSet(b []byte) (*JWKSet, error) {
+	set := &JWKSet{}
+
+	if err := json.Unmarshal(b, set); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	return set, nil
+}
+
+// NewJWKSet builds a set from keys, keeping only their public members so the
+// set can be published, e.g. at a jwks_uri.
+func NewJWKSet(keys ...*JSONWebKey) *JWKSet {
+	set := &JWKSet{Keys: make([]JSONWebKey, 0, len(keys))}
+
+	for _, k := range keys {
+		set.Keys = append(set.Keys, *k.Public())
+	}
+
+	return set
+}
+
+// Key returns the key with a given kid.
+func (s *JWKSet) Key(kid string) (*JSONWebKey, bool) {
+	for i := range s.Keys {
+		if s.Keys[i].KeyID == kid {
+			return &s.Keys[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// KeysOfType returns the keys of a given kty, e.g. "RSA" or "EC".
+func (s *JWKSet) KeysOfType(kty string) []*JSONWebKey {
+	var keys []*JSONWebKey
+
+	for i := range s.Keys {
+		if s.Keys[i].KeyType == kty {
+			keys = append(keys, &s.Keys[i])
+		}
+	}
+
+	return keys
+}
+
+// KeysFor returns the keys that may verify tokens signed with a given
+// algorithm: keys of the matching type whose alg is the algorithm or unset.
+func (s *JWKSet) KeysFor(algorithm Algorithm) []*JSONWebKey {
+	var keys []*JSONWebKey
+
+	for i := range s.Keys {
+		k := &s.Keys[i]
+
+		if k.KeyType == keyTypeFor(algorithm) && (k.Algorithm == "" || k.Algorithm == algorithm) {
+			keys = append(keys, k)
+		}
+	}
+
+	return keys
+}
+
+// keyTypeFor returns the kty of the keys used with an algorithm
+func keyTypeFor(algorithm Algorithm) string {
+	switch algorithm {
+	case RS256, RS384, RS512:
+		return "RSA"
+	case ES256, ES384, ES512:
+		return "EC"
+	case HS256, HS384, HS512:
+		return "oct"
+	}
+
+	return ""
+}
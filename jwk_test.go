@@ -0,0 +1,161 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+func TestNewJSONWebKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Recieved error when generating test key: %s", err)
+	}
+
+	jwk, err := NewJSONWebKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("Didn't expect describing an EC key to return an error: %s", err)
+	}
+
+	if jwk.KeyType != "EC" || jwk.Curve != "P-256" || len(jwk.X) != 43 || len(jwk.Y) != 43 {
+		t.Errorf("Expected a P-256 key with 32 byte coordinates; got %#v", jwk)
+	}
+
+	rs := testRSValidator(t)
+	if jwk, err = NewJSONWebKey(rs.PublicKey); err != nil || jwk.KeyType != "RSA" || jwk.Exponent != "AQAB" {
+		t.Errorf("Expected an RSA key with exponent AQAB; got %#v and %v", jwk, err)
+	}
+
+	if _, err = NewJSONWebKey([]byte("bogokey")); err != ErrAlgorithmNotImplemented {
+		t.Errorf("Expected a symmetric key to return %s; got %v", ErrAlgorithmNotImplemented, err)
+	}
+}
+
+func TestNewPrivateJSONWebKey(t *testing.T) {
+	// Test vector from RFC 8037 appendix A.1
+	seed, _ := parseField("nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A")
+
+	jwk, err := NewJSONWebKey(ed25519.NewKeyFromSeed(seed))
+	if err != nil {
+		t.Fatalf("Didn't expect describing an Ed25519 key to return an error: %s", err)
+	}
+
+	if jwk.KeyType != "OKP" || jwk.Curve != "Ed25519" || jwk.X != "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo" || jwk.D != "nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A" {
+		t.Errorf("Expected the Ed25519 key from RFC 8037; got %#v", jwk)
+	}
+
+	if thumbprint := jwk.Thumbprint(); thumbprint != "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k" {
+		t.Errorf("Expected the thumbprint from RFC 8037; got %s", thumbprint)
+	}
+
+	ec, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if jwk, err = NewJSONWebKey(ec); err != nil || len(jwk.D) != 43 || len(jwk.X) != 43 {
+		t.Errorf("Expected a P-256 key with a 32 byte private scalar; got %#v and %v", jwk, err)
+	}
+
+	rs := testRSValidator(t)
+	if jwk, err = NewJSONWebKey(rs.PrivateKey); err != nil {
+		t.Fatalf("Didn't expect describing an RSA private key to return an error: %s", err)
+	}
+
+	var fields []*big.Int
+	for _, f := range []string{jwk.Modulus, jwk.D, jwk.FirstPrime, jwk.SecondPrime, jwk.FirstExponent, jwk.SecondExponent, jwk.FirstCoefficient} {
+		b, _ := parseField(f)
+		fields = append(fields, new(big.Int).SetBytes(b))
+	}
+
+	n, p, q := fields[0], fields[2], fields[3]
+	if new(big.Int).Mul(p, q).Cmp(n) != 0 {
+		t.Errorf("Expected the primes of the key to multiply to its modulus")
+	}
+
+	if qi := new(big.Int).Mul(fields[6], q); qi.Mod(qi, p).Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("Expected qi to be the inverse of q modulo p")
+	}
+
+	public := jwk.Public()
+	if public.D != "" || public.FirstPrime != "" || public.FirstCoefficient != "" || public.Modulus != jwk.Modulus {
+		t.Errorf("Expected the public half to drop only the private members; got %#v", public)
+	}
+}
+
+func TestNewJWKSet(t *testing.T) {
+	rs := testRSValidator(t)
+
+	jwk, _ := NewJSONWebKey(rs.PrivateKey)
+	jwk.KeyID, jwk.Algorithm, jwk.Use = "r1", RS256, "sig"
+
+	b, err := json.Marshal(NewJWKSet(jwk))
+	if err != nil {
+		t.Fatalf("Didn't expect marshaling a key set to return an error: %s", err)
+	}
+
+	set, err := ParseJWKSet(b)
+	if err != nil {
+		t.Fatalf("Didn't expect parsing a published key set to return an error: %s", err)
+	}
+
+	k, ok := set.Key("r1")
+	if !ok || k.Algorithm != RS256 || k.Use != "sig" || *k != *jwk.Public() {
+		t.Errorf("Expected the published key to be the public half of r1; got %#v", k)
+	}
+
+	if jwk.D == "" {
+		t.Errorf("Didn't expect publishing a key to modify it")
+	}
+}
+
+func TestParseJWKSet(t *testing.T) {
+	set, err := ParseJWKSet([]byte(`{"keys":[
+		{"kty":"RSA","kid":"r1","alg":"RS256","use":"sig","e":"AQAB","n":"0vx7"},
+		{"kty":"RSA","kid":"r2","e":"AQAB","n":"0vx7"},
+		{"kty":"EC","kid":"e1","alg":"ES256","crv":"P-256","x":"f83O","y":"x_FE"},
+		{"kty":"OKP","kid":"o1","crv":"Ed25519","x":"11qY"}
+	]}`))
+
+	if err != nil {
+		t.Fatalf("Didn't expect parsing a key set to return an error: %s", err)
+	}
+
+	if k, ok := set.Key("e1"); !ok || k.Curve != "P-256" || k.Algorithm != ES256 {
+		t.Errorf("Expected to find the P-256 key e1; got %#v", k)
+	}
+
+	if _, ok := set.Key("missing"); ok {
+		t.Errorf("Didn't expect to find a key with an unknown kid")
+	}
+
+	if keys := set.KeysOfType("OKP"); len(keys) != 1 || keys[0].KeyID != "o1" {
+		t.Errorf("Expected unsupported key types to be kept; got %v", keys)
+	}
+
+	if keys := set.KeysFor(RS256); len(keys) != 2 {
+		t.Errorf("Expected RS256 to match r1 and r2; got %v", keys)
+	}
+
+	if keys := set.KeysFor(RS384); len(keys) != 1 || keys[0].KeyID != "r2" {
+		t.Errorf("Expected RS384 to match only r2 which has no alg; got %v", keys)
+	}
+
+	if _, err := ParseJWKSet([]byte(`{"keys":{}}`)); err != ErrMalformedToken {
+		t.Errorf("Expected a malformed key set to return %s; got %v", ErrMalformedToken, err)
+	}
+}
@@ -15,8 +15,8 @@
 package jwt
 
 import (
-	"bufio"
 	"bytes"
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -33,25 +33,126 @@ var (
 	ErrBadSignature = errors.New("invalid Signature")
 	// ErrAlgorithmNotImplemented is thrown if a given jwt is using an algorithm not implemented
 	ErrAlgorithmNotImplemented = errors.New("requested algorithm is not implemented")
+	// ErrInvalidIssuer is returned when the iss claim is not one that is trusted
+	ErrInvalidIssuer = errors.New("invalid issuer")
+	// ErrInvalidAudience is returned when the aud claim does not name the expected recipient
+	ErrInvalidAudience = errors.New("invalid audience")
+	// ErrInvalidTenant is returned when a token was issued for a tenant that is not accepted
+	ErrInvalidTenant = errors.New("invalid tenant")
+	// ErrInvalidTokenUse is returned when a token is presented for a purpose it was not issued for
+	ErrInvalidTokenUse = errors.New("invalid token use")
+	// ErrTokenExpired is returned when the exp claim of a token has passed
+	ErrTokenExpired = errors.New("token is expired")
+	// ErrTokenNotYetValid is returned when a token claims to be issued in the future
+	ErrTokenNotYetValid = errors.New("token is not yet valid")
+	// ErrTokenUsedBeforeIssued is returned when the iat claim of a token is in the future
+	ErrTokenUsedBeforeIssued = errors.New("token used before issued")
+	// ErrUnknownKey is returned when no trusted key matches the key id of a token
+	ErrUnknownKey = errors.New("no key matches the token")
+	// ErrInvalidNonce is returned when the nonce header of a token is missing or not accepted
+	ErrInvalidNonce = errors.New("invalid nonce")
+	// ErrUnknownVersion is returned when no claims type is registered for the ver claim of a token
+	ErrUnknownVersion = errors.New("unknown claims version")
+	// ErrNoValidator is returned when an Encoder or Decoder has no validator to sign or verify with
+	ErrNoValidator = errors.New("no validator configured")
+	// ErrInvalidType is returned when the typ header of a token is missing or not one that is accepted
+	ErrInvalidType = errors.New("invalid token type")
 )
 
+// timeFunc is the source of the current time when checking time based claims
+var timeFunc = time.Now
+
 // A Payload in a jwt represents a set of claims for a given token.
 type Payload struct {
-	Issuer         string     `json:"iss,omitempty"`
-	Subject        string     `json:"sub,omitempty"`
-	Audience       string     `json:"aud,omitempty"`
-	ExpirationTime *time.Time `json:"exp,omitempty"`
-	NotBefore      *time.Time `json:"nbf,omitempty"`
-	IssuedAt       *time.Time `json:"iat,omitempty"`
-	JWTId          string     `json:"jti,omitempty"`
+	Issuer         string       `json:"iss,omitempty"`
+	Subject        string       `json:"sub,omitempty"`
+	Audience       Audience     `json:"aud,omitempty"`
+	ExpirationTime *NumericDate `json:"exp,omitempty"`
+	NotBefore      *NumericDate `json:"nbf,omitempty"`
+	IssuedAt       *NumericDate `json:"iat,omitempty"`
+	JWTId          string       `json:"jti,omitempty"`
 	raw            []byte
 }
 
+// An Audience lists the recipients a token is intended for. RFC 7519 allows
+// the aud claim to be either a single string or an array of strings; both are
+// accepted when decoding and a single recipient is encoded as a string.
+type Audience []string
+
 // A Decoder is a centeralized reader and key used to consume and verify a
 // given jwt token.
 type Decoder struct {
-	reader    io.Reader
-	validator Validator
+	source      TokenSourceReader
+	validator   Validator
+	validations []func(claims RawClaims) error
+	defaults    RawClaims
+	stats       decoderStats
+	// NonceFunc, if set, is called with the nonce header of each token once its
+	// signature is verified. Tokens whose nonce it does not accept, including
+	// tokens without a nonce, are rejected with ErrInvalidNonce.
+	NonceFunc func(nonce string) bool
+	// TagName, if set, names a struct tag that takes precedence over the json
+	// tag when mapping the top level claims of a payload, e.g. claim:"tid".
+	TagName string
+	// AcceptRFC3339Dates allows exp, nbf and iat claims encoded as RFC 3339
+	// strings rather than NumericDates to ease migrating from issuers that
+	// emit them. Encoders always emit NumericDates.
+	AcceptRFC3339Dates bool
+	// Policy, if set, is checked against the claims of each token once its
+	// signature is verified.
+	Policy *Policy
+	// Leeway allows for clock skew when checking the exp, nbf and iat claims
+	// of tokens, which are always checked when present. The Leeway of the
+	// Policy is used instead when one is set.
+	Leeway time.Duration
+	// Audience, if set, must be the aud claim or one of its members. The
+	// Audience of the Policy is used instead when one is set.
+	Audience string
+	// Issuers, if set, lists the trusted issuers. Tokens whose iss claim is
+	// not one of them are rejected with ErrInvalidIssuer. The Issuers of the
+	// Policy are used instead when set.
+	Issuers []string
+	// RequireAttestation, if set, lists the key protections accepted, e.g.
+	// ProtectionHSM. Tokens without a katt claim attesting that the key named
+	// by their kid header has one of them are rejected with
+	// ErrInvalidAttestation.
+	RequireAttestation []string
+	// Types, if set, lists the typ headers accepted, e.g. "at+jwt" for access
+	// tokens, so that ID, access and logout tokens cannot be confused with
+	// one another. Types are compared ignoring case and an "application/"
+	// prefix. Tokens with another or no typ are rejected with ErrInvalidType.
+	Types []string
+	// Verifiers, if set, verify the signatures of tokens in the general JSON
+	// serialization read by VerifyJSON in place of the validator.
+	Verifiers []Validator
+	// RequireAllVerifiers makes VerifyJSON accept a token only when each of
+	// the Verifiers matches a distinct one of its signatures rather than any.
+	RequireAllVerifiers bool
+	// AcceptArmor allows tokens wrapped by an Armor, which are unwrapped
+	// before they are verified.
+	AcceptArmor bool
+	// RequireCertificateBinding makes VerifyRequest accept a token only when
+	// its cnf claim binds it to the client certificate of the TLS connection
+	// of the request as RFC 8705 describes. Other tokens are rejected with
+	// ErrCertificateMismatch.
+	RequireCertificateBinding bool
+	// MaxInflatedSize limits how large a compressed payload may inflate to.
+	// DefaultMaxInflatedSize is used when it is zero.
+	MaxInflatedSize int
+	// KeyProvider, if set, selects the key that verifies each token from its
+	// header instead of the validator of the Decoder.
+	KeyProvider KeyProvider
+	// Reporter, if set, signs a report of the checks performed on each token
+	// verified, whether it is accepted or not.
+	Reporter *VerificationReporter
+	// ReplayStore, if set, is consulted with the jti claim of each token once
+	// every other check passes so that one-time tokens are rejected with
+	// ErrTokenReplayed when presented again. Tokens without a jti claim are
+	// rejected.
+	ReplayStore ReplayStore
+	// Cache, if set, remembers verified tokens so that identical tokens seen
+	// again before they expire skip signature verification.
+	Cache *VerifyCache
 }
 
 // An Encoder is a centeralized writer and key used to take a given payload and
@@ -59,87 +160,661 @@ type Decoder struct {
 type Encoder struct {
 	writer    io.Writer
 	validator Validator
+	// TagName, if set, names a struct tag that takes precedence over the json
+	// tag when mapping the top level claims of a payload, e.g. claim:"tid".
+	TagName string
+	// Canonicalize, if set, rewrites the JSON of each payload before it is
+	// signed, e.g. CanonicalizeJCS where signatures must be deterministic.
+	Canonicalize func(payload []byte) ([]byte, error)
+	// Compression, if set, is the algorithm payloads larger than
+	// CompressionThreshold bytes are compressed with, e.g. CompressionDeflate.
+	// Compressed tokens are only understood by this package so it is meant
+	// for internal tokens.
+	Compression string
+	// CompressionThreshold is the size in bytes of the JSON of a payload above
+	// which it is compressed
+	CompressionThreshold int
+	// Attestation, if set, is embedded in the katt claim of each payload and
+	// its KeyID is protected as the kid header unless one is given.
+	Attestation *KeyAttestation
+	// CertificateChain, if set, is embedded in the x5c header of each token.
+	// It starts with the certificate of the signing key.
+	CertificateChain []*x509.Certificate
+	// ThumbprintCertificate, if set, is the certificate of the signing key
+	// whose x5t and x5t#S256 thumbprints are embedded in the header of each
+	// token, so verifiers can select it from a CertificateStore.
+	ThumbprintCertificate *x509.Certificate
+	// Redact, if set, may remove or rewrite the claims of each payload before
+	// it is signed, e.g. DropClaims of personal data for tokens that leave the
+	// organization. Payloads must be JSON objects.
+	Redact func(claims RawClaims) error
+	// Armor, if set, wraps each token for a constrained transport, e.g.
+	// ArmorBase32 for QR codes. Decoders must set AcceptArmor to read them.
+	Armor Armor
+	// HeaderParams, if set, are added to the Params of the header of each
+	// token unless the header has a parameter of the same name.
+	HeaderParams map[string]interface{}
 }
 
-// A Header contains data related to the signature of the payload. This information
+// A Header contains data related to the signature of the payload. The algorithm
 // is a consequence of the signing process and is for reference only.
-type header struct {
-	Algorithm   Algorithm `json:"alg"`
-	ContentType string    `json:"typ"`
-	raw         []byte
+type Header struct {
+	Algorithm   Algorithm   `json:"alg"`
+	Type        string      `json:"typ,omitempty"`
+	KeyID       string      `json:"kid,omitempty"`
+	Nonce       string      `json:"nonce,omitempty"`
+	URL         string      `json:"url,omitempty"`
+	JWK         *JSONWebKey `json:"jwk,omitempty"`
+	IssuedAt    int64       `json:"iat,omitempty"`
+	Compression string      `json:"zip,omitempty"`
+	// CertificateChain is the base64 encoded DER of the certificate of the
+	// signing key followed by the certificates that issued it
+	CertificateChain []string `json:"x5c,omitempty"`
+	// X509Thumbprint is the base64url encoded SHA-1 digest of the DER encoded
+	// certificate of the signing key
+	X509Thumbprint string `json:"x5t,omitempty"`
+	// X509ThumbprintS256 is the base64url encoded SHA-256 digest of the DER
+	// encoded certificate of the signing key
+	X509ThumbprintS256 string `json:"x5t#S256,omitempty"`
+	// Encryption is the content encryption algorithm of an encrypted token
+	Encryption string `json:"enc,omitempty"`
+	// PBES2Salt is the salt input of a key derived from a password
+	PBES2Salt string `json:"p2s,omitempty"`
+	// PBES2Count is the number of PBKDF2 iterations of a key derived from a
+	// password
+	PBES2Count int `json:"p2c,omitempty"`
+	// KeySalt is the salt the content encryption key of a token was derived
+	// with by a KeyDerivationFunc
+	KeySalt string `json:"kds,omitempty"`
+	// ContentType is "JWT" when the payload is itself a token
+	ContentType string `json:"cty,omitempty"`
+	// JWKSetURL is the location of a key set holding the key of the signer
+	JWKSetURL string `json:"jku,omitempty"`
+	// Params are protected parameters this package does not define, e.g.
+	// extensions of a payment API, added to the header of a token when it is
+	// signed. Parameters defined above take precedence. Params of parsed
+	// tokens are read with Param instead.
+	Params map[string]interface{} `json:"-"`
+	raw    []byte
+}
+
+// MarshalJSON encodes the header with its Params.
+func (h Header) MarshalJSON() ([]byte, error) {
+	type header Header
+
+	b, err := json.Marshal(header(h))
+	if err != nil || len(h.Params) == 0 {
+		return b, err
+	}
+
+	var params map[string]json.RawMessage
+	if err := json.Unmarshal(b, &params); err != nil {
+		return nil, err
+	}
+
+	for name, value := range h.Params {
+		if _, ok := params[name]; ok {
+			continue
+		}
+
+		if params[name], err = json.Marshal(value); err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(params)
+}
+
+// Param returns the JSON of a parameter of the header of a parsed token by
+// name, including parameters this package does not define.
+func (h Header) Param(name string) (json.RawMessage, bool) {
+	var params map[string]json.RawMessage
+	if json.Unmarshal(h.raw, &params) != nil {
+		return nil, false
+	}
+
+	param, ok := params[name]
+
+	return param, ok
+}
+
+// A DecodeResult describes a verified token. It carries what gateways and audit
+// logs need to know about a token without parsing it a second time.
+type DecodeResult struct {
+	// Claims is the value the payload of the token was decoded into
+	Claims interface{}
+	// Header is the protected header of the token
+	Header Header
+	// Algorithm is the algorithm the signature was verified with
+	Algorithm Algorithm
+	// KeyID is the id of the key that verified the signature, if the token
+	// named one
+	KeyID string
+	// Duration is the time spent verifying the signature
+	Duration time.Duration
+	// Violations lists how the token breaks the Policy of the Decoder. It is
+	// only populated when the policy is a dry run.
+	Violations []error
+	// Stale reports that the token expired within the StaleGrace of the Policy
+	// of the Decoder and was accepted regardless.
+	Stale bool
+	// Cached reports that the token was found in the Cache of the Decoder and
+	// its signature was not verified again.
+	Cached bool
+	// Attestation is the katt claim of the token, if it has one
+	Attestation *KeyAttestation
+	// Confirmation is the cnf claim of the token, if it has one
+	Confirmation *Confirmation
+	// Defaulted are the sorted names of the claims the token did not have
+	// that were decoded from the defaults of the Decoder
+	Defaulted []string
+
+	// claimsRaw is the decoded payload of the token
+	claimsRaw []byte
 }
 
 // A jwt is a unified structure of the components of a jwt. This structure is
-//used internally to aggregate components during encoding and decoding.
+// used internally to aggregate components during encoding and decoding.
 type jwt struct {
-	Header            *header
+	Header            *Header
 	headerRaw         []byte
 	Payload           interface{}
 	claimsPayload     *Payload
 	payloadRaw        []byte
+	claimsRaw         []byte
 	registeredPayload Payload
 	Signature         []byte
 }
 
 // NewDecoder creates an underlying Decoder with a given key and input reader
+// of white space separated tokens.
 func NewDecoder(r io.Reader, v Validator) *Decoder {
-	return &Decoder{reader: r, validator: v}
+	return &Decoder{source: StreamSource(r), validator: v}
 }
 
-// Decode consumes the next available token from the given reader and populates
+// NewSourceDecoder creates a Decoder with a given key that decodes the tokens
+// of a given source, e.g. RequestSource(r).
+func NewSourceDecoder(s TokenSourceReader, v Validator) *Decoder {
+	return &Decoder{source: s, validator: v}
+}
+
+// Decode consumes the next available token from the given source and populates
 // a given interface with the matching values in the the token. The signature
 // of the given token is verified and will return an error if a bad signature is
 // found. In addition if the jwt is using an unimplemented algorithm an error will
-// be returned as well.
+// be returned as well. io.EOF is returned when no tokens are left.
 func (dec *Decoder) Decode(v interface{}) error {
+	_, err := dec.DecodeResult(v)
+	return err
+}
+
+// DecodeResult decodes and verifies the next available token like Decode and
+// reports details about the token and its verification.
+func (dec *Decoder) DecodeResult(v interface{}) (*DecodeResult, error) {
+	input, err := dec.source.ReadToken()
+
+	if err != nil {
+		return nil, err
+	}
 
-	buf := bufio.NewReader(dec.reader)
-	input, err := buf.ReadString(byte(' '))
+	return dec.verify(input, v)
+}
+
+// DecodeWithHeader decodes and verifies the next available token like Decode
+// and returns its header, e.g. to learn the kid of the key that signed it.
+func (dec *Decoder) DecodeWithHeader(v interface{}) (*Header, error) {
+	result, err := dec.DecodeResult(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result.Header, nil
+}
+
+// Verify verifies a given token and populates a given interface with the
+// matching values in the token. Unlike Decode the underlying source is not
+// consumed, so a single Decoder may verify tokens from many goroutines.
+func (dec *Decoder) Verify(token string, v interface{}) error {
+	_, err := dec.verify(token, v)
+	return err
+}
+
+func (dec *Decoder) verify(input string, v interface{}) (*DecodeResult, error) {
+	return dec.verifyWith(dec.verifier(), input, v)
+}
+
+// verifyWith verifies a token with a given validator, recording it in the
+// stats and report of the Decoder
+func (dec *Decoder) verifyWith(validator Validator, input string, v interface{}) (*DecodeResult, error) {
+	var checks *verificationChecks
+	if dec.Reporter != nil {
+		checks = &verificationChecks{}
+	}
+
+	result, err := dec.decode(validator, input, v, checks)
+	dec.stats.record(result, err)
+
+	if dec.Reporter != nil {
+		dec.Reporter.report(input, checks, err)
+	}
+
+	return result, err
+}
+
+// decode verifies a token with a given validator and records the checks
+// performed in checks, which may be nil.
+func (dec *Decoder) decode(validator Validator, input string, v interface{}, checks *verificationChecks) (*DecodeResult, error) {
 
-	jwt, err := parseJWT(input, v)
+	if validator == nil {
+		return nil, ErrNoValidator
+	}
+
+	if dec.AcceptArmor {
+		var err error
+		if input, err = Unarmor(input); err != nil {
+			return nil, err
+		}
+	}
+
+	payload := dec.payloadFor(v)
+
+	jwt, err := parseInflatingJWT(input, payload, dec.maxInflatedSize())
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if valid, err := dec.validator.validate(jwt); !valid || err != nil {
+	checks.observe(jwt.Header)
 
-		if err != nil {
+	start := time.Now()
+	cached := dec.Cache != nil && dec.Cache.verified(jwt)
+	if dec.Cache != nil {
+		dec.stats.recordCache(cached)
+	}
+
+	if err := checks.record(CheckSignature, verifySignature(validator, jwt, cached)); err != nil {
+		return nil, err
+	}
+
+	if dec.NonceFunc != nil {
+		var nonceErr error
+		if jwt.Header.Nonce == "" || !dec.NonceFunc(jwt.Header.Nonce) {
+			nonceErr = ErrInvalidNonce
+		}
+
+		if err := checks.record(CheckNonce, nonceErr); err != nil {
+			return nil, err
+		}
+	}
+
+	duration := time.Since(start)
+
+	if len(dec.Types) > 0 {
+		if err := checks.record(CheckType, dec.checkType(jwt.Header.Type)); err != nil {
+			return nil, err
+		}
+	}
+
+	policy := dec.policy()
+
+	violations, stale, err := policy.check(jwt.claimsRaw)
+	if err := checks.record(CheckClaims, err); err != nil {
+		return nil, err
+	}
+
+	attestation, err := dec.checkAttestation(jwt)
+	if len(dec.RequireAttestation) > 0 {
+		checks.record(CheckAttestation, err)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(dec.validations) > 0 {
+		if err := checks.record(CheckValidations, dec.runValidations(jwt.claimsRaw)); err != nil {
+			return nil, err
+		}
+	}
+
+	if dec.ReplayStore != nil {
+		if err := checks.record(CheckReplay, dec.checkReplay(jwt, policy)); err != nil {
+			return nil, err
+		}
+	}
+
+	var defaulted []string
+	if len(dec.defaults) > 0 {
+		if defaulted, err = dec.applyDefaults(payload, jwt.claimsRaw); err != nil {
+			return nil, err
+		}
+	}
+
+	if dec.Cache != nil && !cached {
+		dec.Cache.add(jwt)
+	}
+
+	return &DecodeResult{
+		Claims:       v,
+		Header:       *jwt.Header,
+		Algorithm:    jwt.Header.Algorithm,
+		KeyID:        jwt.Header.KeyID,
+		Duration:     duration,
+		Violations:   violations,
+		Stale:        stale,
+		Cached:       cached,
+		Attestation:  attestation,
+		Confirmation: confirmationOf(jwt.claimsRaw),
+		Defaulted:    defaulted,
+		claimsRaw:    jwt.claimsRaw,
+	}, nil
+}
+
+// payloadFor wraps v in the payloads decoding the claims of a token as
+// configured by the Decoder
+func (dec *Decoder) payloadFor(v interface{}) interface{} {
+	payload := v
+	if dec.TagName != "" {
+		payload = &taggedPayload{v: v, tag: dec.TagName}
+	}
+
+	if dec.AcceptRFC3339Dates {
+		payload = &rfc3339Payload{v: payload}
+	}
+
+	return payload
+}
+
+// decodeClaims decodes the claims of a token verified before into v as decode
+// does, including the defaults of the Decoder
+func (dec *Decoder) decodeClaims(claimsRaw []byte, v interface{}) error {
+	payload := dec.payloadFor(v)
+
+	if err := json.NewDecoder(bytes.NewReader(claimsRaw)).Decode(payload); err != nil {
+		return err
+	}
+
+	if len(dec.defaults) > 0 {
+		if _, err := dec.applyDefaults(payload, claimsRaw); err != nil {
 			return err
 		}
+	}
+
+	return nil
+}
 
+// EncodeStage names a stage of encoding a token
+type EncodeStage string
+
+const (
+	// EncodeStageMarshal is the stage a payload is encoded to JSON in
+	EncodeStageMarshal EncodeStage = "marshal"
+	// EncodeStageSign is the stage a token is signed in
+	EncodeStageSign EncodeStage = "sign"
+	// EncodeStageWrite is the stage a token is written to the underlying
+	// writer in
+	EncodeStageWrite EncodeStage = "write"
+)
+
+// An EncodeError is returned by Encode and reports the stage that failed.
+type EncodeError struct {
+	Stage EncodeStage
+	Err   error
+	// Written is the number of bytes of the token written before a write
+	// failed. A partially written token must be discarded by the reader.
+	Written int64
+}
+
+func (e *EncodeError) Error() string {
+	if e.Written > 0 {
+		return fmt.Sprintf("%s: %s after %d bytes", e.Stage, e.Err, e.Written)
+	}
+
+	return fmt.Sprintf("%s: %s", e.Stage, e.Err)
+}
+
+// Unwrap returns the error that caused the stage to fail.
+func (e *EncodeError) Unwrap() error {
+	return e.Err
+}
+
+// verifySignature verifies the signature of a token unless a cache has
+// verified it before
+func verifySignature(v Validator, jwt *jwt, cached bool) error {
+	if cached {
+		return nil
+	}
+
+	valid, err := v.validate(jwt)
+
+	if err != nil {
+		return err
+	}
+
+	if !valid {
 		return ErrBadSignature
 	}
 
 	return nil
 }
 
+// checkType asserts a typ header is one of the Types of the Decoder
+func (dec *Decoder) checkType(typ string) error {
+	for _, accepted := range dec.Types {
+		if typ != "" && normalizeType(typ) == normalizeType(accepted) {
+			return nil
+		}
+	}
+
+	return ErrInvalidType
+}
+
+// normalizeType returns a typ header in lower case without the application/
+// prefix that RFC 7515 allows to be omitted
+func normalizeType(typ string) string {
+	return strings.TrimPrefix(strings.ToLower(typ), "application/")
+}
+
+// maxInflatedSize returns the size compressed payloads may inflate to
+func (dec *Decoder) maxInflatedSize() int {
+	if dec.MaxInflatedSize == 0 {
+		return DefaultMaxInflatedSize
+	}
+
+	return dec.MaxInflatedSize
+}
+
+// policy returns the Policy claims are checked against, which is the Policy of
+// the Decoder completed by the checks configured on the Decoder itself.
+func (dec *Decoder) policy() *Policy {
+	policy := Policy{Leeway: dec.Leeway}
+	if dec.Policy != nil {
+		policy = *dec.Policy
+	}
+
+	if policy.Audience == "" {
+		policy.Audience = dec.Audience
+	}
+
+	if len(policy.Issuers) == 0 {
+		policy.Issuers = dec.Issuers
+	}
+
+	return &policy
+}
+
 // NewEncoder creates an underlying Encoder with a given key and output writer
 func NewEncoder(w io.Writer, v Validator) *Encoder {
 	return &Encoder{writer: w, validator: v}
 }
 
 // Encode takes a given payload and algorithm and composes a new signed jwt
-// in the underlying writer. This will return an error in the event that the
-// given payload cannot be encoded to JSON.
+// in the underlying writer. Errors are returned as an *EncodeError naming the
+// stage that failed, e.g. when the given payload cannot be encoded to JSON or
+// the token is only partially written.
 func (enc *Encoder) Encode(v interface{}) error {
+	token, err := enc.signHeader(Header{Type: "JWT"}, v)
 
-	jwt := jwt{
-		Header: &header{
-			ContentType: "JWT",
-		},
-		Payload: v,
-	}
-
-	if err := enc.validator.sign(&jwt); err != nil {
+	if err != nil {
 		return err
 	}
 
-	fmt.Fprintf(enc.writer, "%s", jwt.token())
+	if n, err := token.WriteTo(enc.writer); err != nil {
+		return &EncodeError{Stage: EncodeStageWrite, Err: err, Written: n}
+	}
 
 	return nil
 }
 
+// Sign takes a given payload and composes a new signed jwt without writing it
+// to the underlying writer. A payload that is already serialized may be given
+// as a json.RawMessage or an io.Reader of JSON and is signed as is.
+func (enc *Encoder) Sign(v interface{}) (SignedToken, error) {
+	return enc.SignHeader(Header{Type: "JWT"}, v)
+}
+
+// SignHeader is like Sign but protects the given header, e.g. to include a key
+// id or a server provided nonce. The algorithm of the header is always set by
+// the validator.
+func (enc *Encoder) SignHeader(h Header, v interface{}) (SignedToken, error) {
+	token, err := enc.signHeader(h, v)
+
+	if err != nil {
+		return "", err.Err
+	}
+
+	return token, nil
+}
+
+func (enc *Encoder) signHeader(h Header, v interface{}) (SignedToken, *EncodeError) {
+
+	if enc.validator == nil {
+		return "", &EncodeError{Stage: EncodeStageSign, Err: ErrNoValidator}
+	}
+
+	jwt, encErr := enc.prepare(h, v)
+	if encErr != nil {
+		return "", encErr
+	}
+
+	if err := enc.validator.sign(jwt); err != nil {
+		return "", &EncodeError{Stage: EncodeStageSign, Err: err}
+	}
+
+	if enc.Armor != "" {
+		armored, err := enc.Armor.Wrap(jwt.token())
+		if err != nil {
+			return "", &EncodeError{Stage: EncodeStageSign, Err: err}
+		}
+
+		return SignedToken(armored), nil
+	}
+
+	return SignedToken(jwt.token()), nil
+}
+
+// prepare returns the unsigned token of a given header and payload with the
+// headers and encoding of the Encoder applied
+func (enc *Encoder) prepare(h Header, v interface{}) (*jwt, *EncodeError) {
+	payload, err := enc.marshal(v)
+	if err != nil {
+		return nil, &EncodeError{Stage: EncodeStageMarshal, Err: err}
+	}
+
+	if len(enc.HeaderParams) > 0 {
+		params := make(map[string]interface{}, len(enc.HeaderParams)+len(h.Params))
+		for name, value := range enc.HeaderParams {
+			params[name] = value
+		}
+
+		for name, value := range h.Params {
+			params[name] = value
+		}
+
+		h.Params = params
+	}
+
+	// Signing cannot fail on the header so parameters are checked up front
+	if len(h.Params) > 0 {
+		if _, err := json.Marshal(h); err != nil {
+			return nil, &EncodeError{Stage: EncodeStageMarshal, Err: err}
+		}
+	}
+
+	if enc.Attestation != nil && h.KeyID == "" {
+		h.KeyID = enc.Attestation.KeyID
+	}
+
+	if len(enc.CertificateChain) > 0 && len(h.CertificateChain) == 0 {
+		h.CertificateChain = EncodeCertificateChain(enc.CertificateChain)
+	}
+
+	if enc.ThumbprintCertificate != nil && h.X509Thumbprint == "" && h.X509ThumbprintS256 == "" {
+		h.X509Thumbprint = CertificateThumbprint(enc.ThumbprintCertificate)
+		h.X509ThumbprintS256 = CertificateThumbprintS256(enc.ThumbprintCertificate)
+	}
+
+	if enc.Compression != "" && len(payload) > enc.CompressionThreshold {
+		if payload, err = compress(payload, enc.Compression); err != nil {
+			return nil, &EncodeError{Stage: EncodeStageMarshal, Err: err}
+		}
+
+		h.Compression = enc.Compression
+	}
+
+	return &jwt{
+		Header:  &h,
+		Payload: rawPayload(payload),
+	}, nil
+}
+
+// marshal returns the JSON of a payload as it is signed
+func (enc *Encoder) marshal(v interface{}) ([]byte, error) {
+	var payload []byte
+	var err error
+
+	switch p := v.(type) {
+	case json.RawMessage:
+		payload, err = compactPayload(p)
+	case io.Reader:
+		if payload, err = io.ReadAll(p); err == nil {
+			payload, err = compactPayload(payload)
+		}
+	default:
+		if enc.TagName != "" {
+			v = &taggedPayload{v: v, tag: enc.TagName}
+		}
+
+		payload, err = json.Marshal(v)
+	}
+
+	if err == nil && enc.Redact != nil {
+		payload, err = redact(payload, enc.Redact)
+	}
+
+	if err == nil && enc.Attestation != nil {
+		payload, err = embedAttestation(payload, enc.Attestation)
+	}
+
+	if err != nil || enc.Canonicalize == nil {
+		return payload, err
+	}
+
+	return enc.Canonicalize(payload)
+}
+
+// compactPayload checks a pre-serialized payload is JSON and removes
+// insignificant white space from it so it can be signed as is.
+func compactPayload(b []byte) (rawPayload, error) {
+	buf := bytes.NewBuffer(nil)
+
+	if err := json.Compact(buf, b); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	return rawPayload(buf.Bytes()), nil
+}
+
 func (jwt *jwt) parseHeader(raw string) error {
 	var err error
 	var value []byte
@@ -149,6 +824,7 @@ func (jwt *jwt) parseHeader(raw string) error {
 	}
 
 	jwt.headerRaw = []byte(raw)
+	jwt.Header.raw = value
 
 	if err = json.NewDecoder(bytes.NewReader(value)).Decode(jwt.Header); err != nil {
 		return ErrMalformedToken
@@ -158,9 +834,15 @@ func (jwt *jwt) parseHeader(raw string) error {
 }
 
 func parseJWT(input string, payload interface{}) (*jwt, error) {
+	return parseInflatingJWT(input, payload, 0)
+}
+
+// parseInflatingJWT is like parseJWT but accepts compressed payloads that
+// inflate to at most limit bytes.
+func parseInflatingJWT(input string, payload interface{}, limit int) (*jwt, error) {
 	var err error
 	jwt := &jwt{
-		Header:        &header{},
+		Header:        &Header{},
 		claimsPayload: &Payload{},
 	}
 
@@ -174,7 +856,11 @@ func parseJWT(input string, payload interface{}) (*jwt, error) {
 		return jwt, ErrMalformedToken
 	}
 
-	if err = jwt.parsePayload(fields[1], payload); err != nil {
+	if err = jwt.parsePayload(fields[1], payload, limit); err != nil {
+		if errors.Is(err, ErrUnknownVersion) || err == ErrPayloadTooLarge {
+			return jwt, err
+		}
+
 		return jwt, ErrMalformedToken
 	}
 
@@ -184,14 +870,10 @@ func parseJWT(input string, payload interface{}) (*jwt, error) {
 }
 
 func (jwt *jwt) token() string {
-	header := strings.Trim(string(jwt.headerRaw), "=")
-	payload := strings.Trim(string(jwt.payloadRaw), "=")
-	signature := strings.Trim(string(jwt.Signature), "=")
-
-	return fmt.Sprintf("%s.%s.%s", header, payload, signature)
+	return fmt.Sprintf("%s.%s.%s", jwt.headerRaw, jwt.payloadRaw, jwt.Signature)
 }
 
-func (jwt *jwt) parsePayload(raw string, v interface{}) error {
+func (jwt *jwt) parsePayload(raw string, v interface{}, limit int) error {
 	jwt.payloadRaw = []byte(raw)
 	value, err := parseField(raw)
 
@@ -199,6 +881,18 @@ func (jwt *jwt) parsePayload(raw string, v interface{}) error {
 		return err
 	}
 
+	if jwt.Header.Compression != "" {
+		if limit == 0 {
+			return ErrAlgorithmNotImplemented
+		}
+
+		if value, err = inflate(value, jwt.Header.Compression, limit); err != nil {
+			return err
+		}
+	}
+
+	jwt.claimsRaw = value
+
 	// TODO: How to deal with json encoder errors?
 	err = json.NewDecoder(bytes.NewReader(value)).Decode(v)
 
@@ -217,3 +911,40 @@ func parseField(b64Value string) ([]byte, error) {
 	}
 	return base64.URLEncoding.DecodeString(b64Value)
 }
+
+// encodeSegment encodes a segment of a token as unpadded base64url
+func encodeSegment(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Contains reports whether the given recipient is a member of the audience.
+func (a Audience) Contains(recipient string) bool {
+	return containsString(a, recipient)
+}
+
+// MarshalJSON encodes a single recipient as a string and anything else as an
+// array.
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+
+	return json.Marshal([]string(a))
+}
+
+// UnmarshalJSON accepts either a string or an array of strings.
+func (a *Audience) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+
+	var many []string
+	if err := json.Unmarshal(b, &many); err != nil {
+		return err
+	}
+
+	*a = Audience(many)
+	return nil
+}
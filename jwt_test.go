@@ -15,12 +15,21 @@ package jwt
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
+	"io"
+	"reflect"
 	"testing"
+	"time"
 )
 
 var ErrTestValidator = errors.New("A fake validator error")
+var ErrTestWriter = errors.New("A fake writer error")
 
 type TestValidator struct{}
 
@@ -48,7 +57,7 @@ func TestDecodeErrors(t *testing.T) {
 	v.Key = []byte("bogokey")
 
 	for _, c := range cases {
-		decoder := NewDecoder(bytes.NewBufferString(c.Token), v)
+		decoder := NewSourceDecoder(StringSource(c.Token), v)
 		payload := &struct{}{}
 
 		err := decoder.Decode(payload)
@@ -109,21 +118,391 @@ func TestDecodingValidating(t *testing.T) {
 	}
 }
 
+func TestDecodeResult(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	token := signTestToken(t, v, Header{Type: "JWT", KeyID: "k1"}, &Payload{Subject: "1234567890"})
+	payload := &Payload{}
+
+	result, err := NewDecoder(bytes.NewBufferString(token), v).DecodeResult(payload)
+
+	if err != nil {
+		t.Fatalf("Didn't expect decoding a valid token to return an error: %s", err)
+	}
+
+	if result.Claims != payload || payload.Subject != "1234567890" {
+		t.Errorf("Expected the result to carry the decoded claims; got %#v", result.Claims)
+	}
+
+	if result.Algorithm != HS256 || result.Header.Algorithm != HS256 || result.Header.Type != "JWT" {
+		t.Errorf("Expected the result to carry the token header; got %#v", result.Header)
+	}
+
+	if result.KeyID != "k1" {
+		t.Errorf("Expected the verifying key id to be k1; got %s", result.KeyID)
+	}
+
+	if result.Duration < 0 {
+		t.Errorf("Expected a non negative verification duration; got %s", result.Duration)
+	}
+
+	v.Key = []byte("otherkey")
+	result, err = NewDecoder(bytes.NewBufferString(token), v).DecodeResult(&Payload{})
+
+	if err != ErrBadSignature || result != nil {
+		t.Errorf("Expected a bad signature to return %s and no result; got %s and %#v", ErrBadSignature, err, result)
+	}
+}
+
+func TestDecodeWithHeader(t *testing.T) {
+	key := []byte("bogokey")
+
+	v := NewHSValidator(HS256)
+	v.Key = key
+
+	input := encodeSegment([]byte(`{"alg":"HS256","typ":"JWT","kid":"k1","tenant":{"id":"contoso"}}`)) + "." + encodeSegment([]byte(`{"sub":"1234567890"}`))
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(input))
+	token := input + "." + encodeSegment(mac.Sum(nil))
+
+	payload := &Payload{}
+	h, err := NewDecoder(bytes.NewBufferString(token), v).DecodeWithHeader(payload)
+
+	if err != nil {
+		t.Fatalf("Didn't expect decoding a valid token to return an error: %s", err)
+	}
+
+	if h.KeyID != "k1" || h.Algorithm != HS256 || payload.Subject != "1234567890" {
+		t.Errorf("Expected the header and claims of the token; got %#v and %#v", h, payload)
+	}
+
+	if tenant, ok := h.Param("tenant"); !ok || string(tenant) != `{"id":"contoso"}` {
+		t.Errorf("Expected the custom tenant header parameter; got %s", tenant)
+	}
+
+	if _, ok := h.Param("missing"); ok {
+		t.Errorf("Didn't expect a header parameter the token does not have")
+	}
+
+	if _, err := NewDecoder(bytes.NewBufferString(""), v).DecodeWithHeader(&Payload{}); err != io.EOF {
+		t.Errorf("Expected %v when no tokens are left; got %v", io.EOF, err)
+	}
+}
+
+func TestEncodeHeaderParams(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	enc := NewEncoder(nil, v)
+	enc.HeaderParams = map[string]interface{}{"tenant": "contoso", "region": "eu"}
+
+	token, err := enc.SignHeader(Header{Type: "JWT", KeyID: "k1", Params: map[string]interface{}{"region": "us", "kid": "k2", "alg": "none"}}, &Payload{})
+	if err != nil {
+		t.Fatalf("Didn't expect signing with header parameters to return an error: %s", err)
+	}
+
+	h, err := NewDecoder(bytes.NewBufferString(string(token)), v).DecodeWithHeader(&Payload{})
+	if err != nil {
+		t.Fatalf("Didn't expect decoding a token with header parameters to return an error: %s", err)
+	}
+
+	cases := []struct {
+		Param    string
+		Expected string
+		Reason   string
+	}{
+		{"tenant", `"contoso"`, "the Encoder adds a parameter"},
+		{"region", `"us"`, "the header overrides a parameter of the Encoder"},
+		{"kid", `"k1"`, "a defined parameter takes precedence"},
+		{"alg", `"HS256"`, "the algorithm cannot be overridden"},
+	}
+
+	for _, c := range cases {
+		if param, _ := h.Param(c.Param); string(param) != c.Expected {
+			t.Errorf("Expected %s to be %s when %s; got %s", c.Param, c.Expected, c.Reason, param)
+		}
+	}
+
+	if _, err := enc.SignHeader(Header{Params: map[string]interface{}{"callback": func() {}}}, &Payload{}); err == nil {
+		t.Errorf("Expected a header parameter that cannot be marshaled to return an error")
+	}
+}
+
+func TestDecodeRegisteredClaims(t *testing.T) {
+	now := time.Unix(1516239022, 0)
+	timeFunc = func() time.Time { return now }
+	defer func() { timeFunc = time.Now }()
+
+	cases := []struct {
+		ExpectedError error
+		Reason        string
+		Leeway        time.Duration
+		Claims        map[string]interface{}
+	}{
+		{nil, "the token has no time based claims", 0, map[string]interface{}{"sub": "1234567890"}},
+		{nil, "the token is within its validity period", 0, map[string]interface{}{"nbf": now.Unix() - 60, "iat": now.Unix() - 60, "exp": now.Unix() + 60}},
+		{ErrTokenExpired, "the token has expired", 0, map[string]interface{}{"exp": now.Unix() - 1}},
+		{ErrTokenExpired, "the token expires now", 0, map[string]interface{}{"exp": now.Unix()}},
+		{nil, "the token expired within the leeway", time.Minute, map[string]interface{}{"exp": now.Unix() - 30}},
+		{ErrTokenNotYetValid, "the token is not yet valid", 0, map[string]interface{}{"nbf": now.Unix() + 1}},
+		{nil, "the token becomes valid within the leeway", time.Minute, map[string]interface{}{"nbf": now.Unix() + 30}},
+		{ErrTokenUsedBeforeIssued, "the token is issued in the future", 0, map[string]interface{}{"iat": now.Unix() + 1}},
+		{nil, "the token is issued within the leeway", time.Minute, map[string]interface{}{"iat": now.Unix() + 30}},
+	}
+
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	for _, c := range cases {
+		dec := NewDecoder(nil, v)
+		dec.Leeway = c.Leeway
+
+		err := dec.Verify(signTestToken(t, v, Header{Type: "JWT"}, c.Claims), &Payload{})
+
+		if err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		}
+	}
+
+	dec := NewDecoder(nil, v)
+	dec.Leeway = time.Hour
+	dec.Policy = &Policy{}
+
+	token := signTestToken(t, v, Header{Type: "JWT"}, map[string]interface{}{"exp": now.Unix() - 30})
+	if err := dec.Verify(token, &Payload{}); err != ErrTokenExpired {
+		t.Errorf("Expected the leeway of the policy to take precedence; got %v", err)
+	}
+}
+
+func TestDecodeAudience(t *testing.T) {
+	cases := []struct {
+		ExpectedError error
+		Reason        string
+		Claims        map[string]interface{}
+	}{
+		{nil, "aud is the expected string", map[string]interface{}{"aud": "api://mine"}},
+		{nil, "aud is an array containing the audience", map[string]interface{}{"aud": []string{"api://other", "api://mine"}}},
+		{ErrInvalidAudience, "aud is another string", map[string]interface{}{"aud": "api://other"}},
+		{ErrInvalidAudience, "aud is an array without the audience", map[string]interface{}{"aud": []string{"api://other"}}},
+		{ErrInvalidAudience, "aud is missing", map[string]interface{}{"sub": "1234567890"}},
+		{ErrMalformedToken, "aud is neither a string nor an array", map[string]interface{}{"aud": 42}},
+	}
+
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	dec := NewDecoder(nil, v)
+	dec.Audience = "api://mine"
+
+	for _, c := range cases {
+		err := dec.Verify(signTestToken(t, v, Header{Type: "JWT"}, c.Claims), &map[string]interface{}{})
+
+		if err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		}
+	}
+
+	dec.Policy = &Policy{Issuer: "https://issuer.example.com"}
+	token := signTestToken(t, v, Header{Type: "JWT"}, map[string]interface{}{"iss": "https://issuer.example.com", "aud": "api://other"})
+
+	if err := dec.Verify(token, &map[string]interface{}{}); err != ErrInvalidAudience {
+		t.Errorf("Expected the audience of the decoder to be checked alongside its policy; got %v", err)
+	}
+
+	if dec.Policy.Audience != "" {
+		t.Errorf("Didn't expect the policy of the decoder to be modified; got audience %s", dec.Policy.Audience)
+	}
+}
+
+func TestDecodeIssuers(t *testing.T) {
+	cases := []struct {
+		ExpectedError error
+		Reason        string
+		Issuer        string
+	}{
+		{nil, "iss is the first trusted issuer", "https://login.example.com"},
+		{nil, "iss is another trusted issuer", "https://sts.example.com"},
+		{ErrInvalidIssuer, "iss is not trusted", "https://evil.example.com"},
+		{ErrInvalidIssuer, "iss is missing", ""},
+	}
+
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	dec := NewDecoder(nil, v)
+	dec.Issuers = []string{"https://login.example.com", "https://sts.example.com"}
+
+	for _, c := range cases {
+		err := dec.Verify(signTestToken(t, v, Header{Type: "JWT"}, &Payload{Issuer: c.Issuer}), &Payload{})
+
+		if err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		}
+	}
+
+	dec.Policy = &Policy{Issuers: []string{"https://evil.example.com"}}
+	token := signTestToken(t, v, Header{Type: "JWT"}, &Payload{Issuer: "https://evil.example.com"})
+
+	if err := dec.Verify(token, &Payload{}); err != nil {
+		t.Errorf("Expected the issuers of the policy to take precedence; got %v", err)
+	}
+}
+
+func TestDecodeTypes(t *testing.T) {
+	cases := []struct {
+		ExpectedError error
+		Reason        string
+		Type          string
+	}{
+		{nil, "typ is accepted", "at+jwt"},
+		{nil, "typ differs in case", "AT+JWT"},
+		{nil, "typ has the application prefix", "application/at+jwt"},
+		{ErrInvalidType, "typ is an ID token", "JWT"},
+		{ErrInvalidType, "typ is a logout token", "logout+jwt"},
+		{ErrInvalidType, "typ is missing", ""},
+	}
+
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	dec := NewDecoder(nil, v)
+	dec.Types = []string{"at+jwt"}
+
+	for _, c := range cases {
+		err := dec.Verify(signTestToken(t, v, Header{Type: c.Type}, &Payload{}), &Payload{})
+
+		if err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		}
+	}
+}
+
+func TestNonce(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	token, err := NewEncoder(nil, v).SignHeader(Header{Type: "JWT", Nonce: "n-0001"}, &Payload{})
+	if err != nil {
+		t.Fatalf("Didn't expect signing with a nonce to return an error: %s", err)
+	}
+
+	unprotected, _ := NewEncoder(nil, v).Sign(&Payload{})
+
+	accept := func(nonce string) bool { return nonce == "n-0001" }
+	reject := func(nonce string) bool { return false }
+
+	cases := []struct {
+		Token         SignedToken
+		NonceFunc     func(string) bool
+		ExpectedError error
+		Reason        string
+	}{
+		{token, nil, nil, "a nonce should be ignored without a NonceFunc"},
+		{token, accept, nil, "an accepted nonce should be valid"},
+		{token, reject, ErrInvalidNonce, "a rejected nonce should be invalid"},
+		{unprotected, accept, ErrInvalidNonce, "a missing nonce should be invalid"},
+	}
+
+	for _, c := range cases {
+		decoder := NewDecoder(bytes.NewBufferString(string(c.Token)), v)
+		decoder.NonceFunc = c.NonceFunc
+
+		result, err := decoder.DecodeResult(&Payload{})
+
+		if err != c.ExpectedError {
+			t.Errorf("Expected %v; %s: got %v", c.ExpectedError, c.Reason, err)
+		}
+
+		if err == nil && result.Header.Nonce != "n-0001" {
+			t.Errorf("Expected the nonce header to be n-0001; got %s", result.Header.Nonce)
+		}
+	}
+}
+
+func TestSignPreserialized(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	enc := NewEncoder(nil, v)
+	expected, _ := enc.Sign(&Payload{Subject: "1234567890"})
+
+	cases := []struct {
+		Payload interface{}
+		Reason  string
+	}{
+		{json.RawMessage(`{"sub":"1234567890"}`), "a json.RawMessage should be signed as is"},
+		{bytes.NewBufferString("{\n  \"sub\": \"1234567890\"\n}\n"), "an io.Reader should be compacted and signed"},
+	}
+
+	for _, c := range cases {
+		token, err := enc.Sign(c.Payload)
+
+		if err != nil || token != expected {
+			t.Errorf("Expected %+v; %s: got %+v and %v", expected, c.Reason, token, err)
+		}
+	}
+
+	if _, err := enc.Sign(json.RawMessage(`{"sub":`)); err != ErrMalformedToken {
+		t.Errorf("Expected a payload that is not JSON to return %s; got %v", ErrMalformedToken, err)
+	}
+}
+
+// brokenWriter accepts a number of bytes and then fails
+type brokenWriter struct {
+	n int
+}
+
+func (w *brokenWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		n := w.n
+		w.n = 0
+		return n, ErrTestWriter
+	}
+
+	w.n -= len(p)
+	return len(p), nil
+}
+
 func TestEncodeErrors(t *testing.T) {
+	hs := NewHSValidator(HS256)
+	hs.Key = []byte("bogokey")
+
 	cases := []struct {
 		expectedError error
+		expectedStage EncodeStage
+		Reason        string
 		validator     Validator
+		payload       interface{}
+		writer        io.Writer
+		written       int64
 	}{
-		{ErrTestValidator, TestValidator{}},
+		{ErrTestValidator, EncodeStageSign, "the validator fails", TestValidator{}, &struct{}{}, bytes.NewBuffer(nil), 0},
+		{ErrNoValidator, EncodeStageSign, "there is no validator", nil, &struct{}{}, bytes.NewBuffer(nil), 0},
+		{ErrMalformedToken, EncodeStageMarshal, "a raw payload is not JSON", hs, json.RawMessage(`{"sub":`), bytes.NewBuffer(nil), 0},
+		{ErrTestWriter, EncodeStageWrite, "the writer fails", hs, &struct{}{}, &brokenWriter{}, 0},
+		{ErrTestWriter, EncodeStageWrite, "the writer fails part way", hs, &struct{}{}, &brokenWriter{n: 12}, 12},
 	}
 
 	for _, c := range cases {
-		buf := bytes.NewBuffer(nil)
-		enc := NewEncoder(buf, c.validator)
+		enc := NewEncoder(c.writer, c.validator)
 
-		if err := enc.Encode(&struct{}{}); err != c.expectedError {
-			t.Errorf("Expected %s error when encoding, recieved %s", c.expectedError, err)
+		err := enc.Encode(c.payload)
+
+		var encodeErr *EncodeError
+		if !errors.As(err, &encodeErr) || !errors.Is(err, c.expectedError) {
+			t.Errorf("Expected an EncodeError wrapping %s when %s; recieved %v", c.expectedError, c.Reason, err)
+			continue
 		}
+
+		if encodeErr.Stage != c.expectedStage || encodeErr.Written != c.written {
+			t.Errorf("Expected stage %s after %d bytes when %s; got %s after %d bytes", c.expectedStage, c.written, c.Reason, encodeErr.Stage, encodeErr.Written)
+		}
+	}
+
+	_, err := NewEncoder(nil, hs).Sign(map[string]interface{}{"ch": make(chan int)})
+	if _, ok := err.(*json.UnsupportedTypeError); !ok {
+		t.Errorf("Expected a payload that cannot be encoded to return its JSON error; got %v", err)
 	}
 }
 
@@ -200,5 +579,88 @@ func ExampleDecoder() {
 	}
 
 	fmt.Printf("%+v\n", payload)
-	// Output: &{Payload:{Issuer:Ben Campbell Subject: Audience: ExpirationTime:<nil> NotBefore:<nil> IssuedAt:<nil> JWTId: raw:[]} Admin:true UserID:1234}
+	// Output: &{Payload:{Issuer:Ben Campbell Subject: Audience:[] ExpirationTime:<nil> NotBefore:<nil> IssuedAt:<nil> JWTId: raw:[]} Admin:true UserID:1234}
+}
+
+func TestAudienceJSON(t *testing.T) {
+	cases := []struct {
+		JSON     string
+		Audience Audience
+	}{
+		{`"api"`, Audience{"api"}},
+		{`["api","web"]`, Audience{"api", "web"}},
+	}
+
+	for _, c := range cases {
+		var aud Audience
+
+		if err := json.Unmarshal([]byte(c.JSON), &aud); err != nil {
+			t.Errorf("Didn't expect unmarshaling %s to return an error: %s", c.JSON, err)
+		}
+
+		if !reflect.DeepEqual(aud, c.Audience) {
+			t.Errorf("Expected %s to unmarshal to %#v; got %#v", c.JSON, c.Audience, aud)
+		}
+
+		if b, _ := json.Marshal(c.Audience); string(b) != c.JSON {
+			t.Errorf("Expected %#v to marshal to %s; got %s", c.Audience, c.JSON, b)
+		}
+	}
+
+	if err := json.Unmarshal([]byte(`42`), &Audience{}); err == nil {
+		t.Errorf("Expected a number to be rejected as an audience")
+	}
+}
+
+func TestPayloadAudience(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	token := signTestToken(t, v, Header{Type: "JWT"}, map[string]interface{}{"sub": "1234567890", "aud": []string{"a", "b"}})
+
+	cases := []struct {
+		Reason string
+		Claims interface{}
+	}{
+		{"the claims are a Payload", &Payload{}},
+		{"the claims embed a Payload", &KeycloakClaims{}},
+	}
+
+	for _, c := range cases {
+		if err := NewDecoder(nil, v).Verify(token, c.Claims); err != nil {
+			t.Errorf("Didn't expect an array aud to return an error when %s: %s", c.Reason, err)
+		}
+	}
+
+	var payload Payload
+	if err := json.Unmarshal([]byte(`{"aud":["a","b"]}`), &payload); err != nil || !reflect.DeepEqual(payload.Audience, Audience{"a", "b"}) {
+		t.Errorf("Expected an array aud to decode into a Payload; got %#v and %v", payload.Audience, err)
+	}
+}
+
+// testRSValidator returns an RS256 validator holding the test key pair
+func testRSValidator(t *testing.T) RSValidator {
+	v, _ := NewRSValidator(RS256)
+
+	block, _ := pem.Decode([]byte(privateKey))
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatalf("Recieved error when parsing test private key: %s", err)
+	}
+
+	v.PrivateKey = key
+	v.PublicKey = &key.PublicKey
+
+	return v
+}
+
+// signTestToken signs a payload with a given header and returns the compact token
+func signTestToken(t *testing.T, v Validator, h Header, payload interface{}) string {
+	jwt := &jwt{Header: &h, Payload: payload}
+
+	if err := v.sign(jwt); err != nil {
+		t.Fatalf("Didn't expect signing a test token to return an error: %s", err)
+	}
+
+	return jwt.token()
 }
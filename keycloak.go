@@ -0,0 +1,67 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+// KeycloakAccess is the set of roles granted by a Keycloak realm or client.
+type KeycloakAccess struct {
+	Roles []string `json:"roles,omitempty"`
+}
+
+// KeycloakClaims are the claims found in tokens issued by a Keycloak realm.
+type KeycloakClaims struct {
+	Payload
+	AuthorizedParty   string                    `json:"azp,omitempty"`
+	PreferredUsername string                    `json:"preferred_username,omitempty"`
+	Scope             string                    `json:"scope,omitempty"`
+	RealmAccess       KeycloakAccess            `json:"realm_access"`
+	ResourceAccess    map[string]KeycloakAccess `json:"resource_access,omitempty"`
+}
+
+// Roles is a normalized view of the roles granted to a subject. Realm roles
+// apply everywhere while resource roles are scoped to a single client.
+type Roles struct {
+	Realm     []string
+	Resources map[string][]string
+}
+
+// Roles normalizes the realm_access and resource_access claims of a token.
+func (c KeycloakClaims) Roles() Roles {
+	roles := Roles{
+		Realm:     c.RealmAccess.Roles,
+		Resources: make(map[string][]string, len(c.ResourceAccess)),
+	}
+
+	for resource, access := range c.ResourceAccess {
+		roles.Resources[resource] = access.Roles
+	}
+
+	return roles
+}
+
+// HasRealmRole reports whether the realm granted the given role.
+func (r Roles) HasRealmRole(role string) bool {
+	return containsString(r.Realm, role)
+}
+
+// HasResourceRole reports whether the given client granted the given role.
+func (r Roles) HasResourceRole(resource, role string) bool {
+	return containsString(r.Resources[resource], role)
+}
+
+// HasRole reports whether the role was granted by the realm or by the given
+// client.
+func (r Roles) HasRole(resource, role string) bool {
+	return r.HasRealmRole(role) || r.HasResourceRole(resource, role)
+}
@@ -0,0 +1,59 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestKeycloakRoles(t *testing.T) {
+	raw := `{
+		"sub": "f1b0",
+		"realm_access": {"roles": ["offline_access", "user"]},
+		"resource_access": {"account": {"roles": ["manage-account"]}, "api": {"roles": ["admin"]}}
+	}`
+
+	claims := &KeycloakClaims{}
+	if err := json.NewDecoder(bytes.NewBufferString(raw)).Decode(claims); err != nil {
+		t.Fatalf("Didn't expect decoding keycloak claims to return an error: %s", err)
+	}
+
+	roles := claims.Roles()
+
+	cases := []struct {
+		Resource string
+		Role     string
+		Expected bool
+	}{
+		{"api", "user", true},
+		{"api", "admin", true},
+		{"account", "admin", false},
+		{"account", "manage-account", true},
+		{"unknown", "offline_access", true},
+		{"unknown", "admin", false},
+	}
+
+	for _, c := range cases {
+		if roles.HasRole(c.Resource, c.Role) != c.Expected {
+			t.Errorf("Expected HasRole(%s, %s) to be %t", c.Resource, c.Role, c.Expected)
+		}
+	}
+
+	if roles.HasRealmRole("admin") {
+		t.Errorf("Expected admin to only be granted by the api resource")
+	}
+}
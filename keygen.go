@@ -0,0 +1,91 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+)
+
+// GenerateHSKey generates a random HMAC key of a given size in bits, 256, 384
+// or 512, and returns the validator of the matching algorithm with it, e.g.
+// for tests and development environments.
+func GenerateHSKey(bits int) (hsValidator, error) {
+	var algorithm Algorithm
+
+	switch bits {
+	case 256:
+		algorithm = HS256
+	case 384:
+		algorithm = HS384
+	case 512:
+		algorithm = HS512
+	default:
+		return hsValidator{}, ErrAlgorithmNotImplemented
+	}
+
+	v := NewHSValidator(algorithm)
+	v.Key = make([]byte, bits/8)
+
+	if _, err := rand.Read(v.Key); err != nil {
+		return hsValidator{}, err
+	}
+
+	return v, nil
+}
+
+// GenerateRSKey generates an RSA key of a given size in bits and returns an
+// RS256 validator with it.
+func GenerateRSKey(bits int) (RSValidator, error) {
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return RSValidator{}, err
+	}
+
+	v, _ := NewRSValidator(RS256)
+	v.PrivateKey = key
+	v.PublicKey = &key.PublicKey
+
+	return v, nil
+}
+
+// GenerateESKey generates an ECDSA key on a given curve, P-256, P-384 or
+// P-521, and returns the validator of the matching algorithm with it.
+func GenerateESKey(curve elliptic.Curve) (ESValidator, error) {
+	var algorithm Algorithm
+
+	switch curve {
+	case elliptic.P256():
+		algorithm = ES256
+	case elliptic.P384():
+		algorithm = ES384
+	case elliptic.P521():
+		algorithm = ES512
+	default:
+		return ESValidator{}, ErrAlgorithmNotImplemented
+	}
+
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return ESValidator{}, err
+	}
+
+	v, _ := NewESValidator(algorithm)
+	v.PrivateKey = key
+	v.PublicKey = &key.PublicKey
+
+	return v, nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"crypto/elliptic"
+	"testing"
+)
+
+func TestGenerateKeys(t *testing.T) {
+	hs, err := GenerateHSKey(384)
+	if err != nil || len(hs.Key) != 48 {
+		t.Fatalf("Expected a 48 byte HS384 key; got %d bytes and %v", len(hs.Key), err)
+	}
+
+	rs, err := GenerateRSKey(2048)
+	if err != nil {
+		t.Fatalf("Didn't expect generating an RSA key to return an error: %s", err)
+	}
+
+	es, err := GenerateESKey(elliptic.P384())
+	if err != nil {
+		t.Fatalf("Didn't expect generating an ECDSA key to return an error: %s", err)
+	}
+
+	cases := []struct {
+		Algorithm Algorithm
+		Validator Validator
+	}{
+		{HS384, hs},
+		{RS256, rs},
+		{ES384, es},
+	}
+
+	for _, c := range cases {
+		token := signTestToken(t, c.Validator, Header{Type: "JWT"}, &Payload{})
+
+		result, err := NewDecoder(nil, c.Validator).verify(token, &Payload{})
+		if err != nil || result.Algorithm != c.Algorithm {
+			t.Errorf("Expected a generated key to verify %s tokens; got %v", c.Algorithm, err)
+		}
+	}
+
+	if _, err := GenerateHSKey(128); err != ErrAlgorithmNotImplemented {
+		t.Errorf("Expected %s for a 128 bit HMAC key; got %v", ErrAlgorithmNotImplemented, err)
+	}
+
+	if _, err := GenerateESKey(elliptic.P224()); err != ErrAlgorithmNotImplemented {
+		t.Errorf("Expected %s for a P-224 key; got %v", ErrAlgorithmNotImplemented, err)
+	}
+}
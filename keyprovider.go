@@ -0,0 +1,74 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+// A KeyProvider selects the key that verifies a token from its header, e.g. by
+// its kid in deployments with many keys or issuers.
+type KeyProvider interface {
+	// VerificationKey returns the key that verifies a token with a given
+	// header: an *rsa.PublicKey, an *ecdsa.PublicKey, an HMAC secret as a
+	// []byte or a *JSONWebKey. ErrUnknownKey should be returned when no key
+	// matches.
+	VerificationKey(h Header) (interface{}, error)
+}
+
+// KeyProviderFunc adapts a function to a KeyProvider.
+type KeyProviderFunc func(h Header) (interface{}, error)
+
+// VerificationKey implements KeyProvider.
+func (f KeyProviderFunc) VerificationKey(h Header) (interface{}, error) {
+	return f(h)
+}
+
+// keyProviderValidator verifies tokens with the keys of a KeyProvider
+type keyProviderValidator struct {
+	provider KeyProvider
+}
+
+// verifier returns the Validator tokens are verified with
+func (dec *Decoder) verifier() Validator {
+	if dec.KeyProvider != nil {
+		return keyProviderValidator{provider: dec.KeyProvider}
+	}
+
+	return dec.validator
+}
+
+func (v keyProviderValidator) validate(jwt *jwt) (bool, error) {
+	key, err := v.provider.VerificationKey(*jwt.Header)
+	if err != nil {
+		return false, err
+	}
+
+	if jwk, ok := key.(*JSONWebKey); ok {
+		pub, err := jwk.PublicKey()
+		if err != nil {
+			return false, err
+		}
+
+		return keyStoreEntry{jwk: *jwk, key: pub}.validate(jwt)
+	}
+
+	validator, err := validatorFor(jwt.Header.Algorithm, key)
+	if err != nil {
+		return false, err
+	}
+
+	return validator.validate(jwt)
+}
+
+// sign returns ErrVerifyOnly as a KeyProvider only provides verification keys
+func (v keyProviderValidator) sign(jwt *jwt) error {
+	return ErrVerifyOnly
+}
@@ -0,0 +1,74 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import "testing"
+
+func TestKeyProvider(t *testing.T) {
+	hs := NewHSValidator(HS256)
+	hs.Key = []byte("bogokey")
+	rs := testRSValidator(t)
+
+	jwk, _ := NewJSONWebKey(rs.PublicKey)
+	jwk.Algorithm = RS256
+
+	var seen []Header
+	provider := KeyProviderFunc(func(h Header) (interface{}, error) {
+		seen = append(seen, h)
+
+		switch h.KeyID {
+		case "hs":
+			return []byte("bogokey"), nil
+		case "rs":
+			return rs.PublicKey, nil
+		case "jwk":
+			return jwk, nil
+		}
+
+		return nil, ErrUnknownKey
+	})
+
+	dec := NewDecoder(nil, nil)
+	dec.KeyProvider = provider
+
+	cases := []struct {
+		ExpectedError error
+		Reason        string
+		Validator     Validator
+		KeyID         string
+	}{
+		{nil, "an HMAC secret is provided", hs, "hs"},
+		{nil, "an RSA key is provided", rs, "rs"},
+		{nil, "a JSON web key is provided", rs, "jwk"},
+		{ErrUnknownKey, "no key is provided", rs, "other"},
+		{ErrAlgorithmNotImplemented, "an HMAC token names an RSA key", hs, "rs"},
+		{ErrAlgorithmNotImplemented, "an RSA token names an HMAC secret", rs, "hs"},
+	}
+
+	for _, c := range cases {
+		token := signTestToken(t, c.Validator, Header{Type: "JWT", KeyID: c.KeyID}, &Payload{Subject: "1234567890"})
+
+		if err := dec.Verify(token, &Payload{}); err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		}
+	}
+
+	if len(seen) != len(cases) || seen[1].Algorithm != RS256 || seen[1].KeyID != "rs" {
+		t.Errorf("Expected the provider to be given the header of every token; got %v", seen)
+	}
+
+	if _, err := NewEncoder(nil, keyProviderValidator{provider: provider}).Sign(&Payload{}); err != ErrVerifyOnly {
+		t.Errorf("Expected signing with a key provider to return %s; got %v", ErrVerifyOnly, err)
+	}
+}
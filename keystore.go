@@ -0,0 +1,192 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"crypto"
+	"errors"
+	"hash/fnv"
+	"sync"
+)
+
+// ErrVerifyOnly is returned when a validator that only holds public keys is
+// asked to sign
+var ErrVerifyOnly = errors.New("validator can only verify")
+
+// keyStoreShards is the number of shards the issuers of a KeyStore are spread
+// over to reduce lock contention
+const keyStoreShards = 64
+
+// A KeyStore holds the trusted keys of many issuers, e.g. the tenants of a
+// multi-tenant service. Keys are partitioned by issuer and indexed by kid so a
+// lookup takes constant time however many keys are trusted. A KeyStore is a
+// Validator that verifies each token with the key its iss claim and kid header
+// name.
+type KeyStore struct {
+	shards [keyStoreShards]keyStoreShard
+}
+
+type keyStoreShard struct {
+	mu      sync.RWMutex
+	issuers map[string]map[string]keyStoreEntry
+}
+
+// A keyStoreEntry is a key and its parsed public key
+type keyStoreEntry struct {
+	jwk JSONWebKey
+	key crypto.PublicKey
+}
+
+// NewKeyStore constructs an empty KeyStore.
+func NewKeyStore() *KeyStore {
+	s := &KeyStore{}
+
+	for i := range s.shards {
+		s.shards[i].issuers = map[string]map[string]keyStoreEntry{}
+	}
+
+	return s
+}
+
+// Add trusts a key for tokens from a given issuer, replacing any key of the
+// issuer with the same kid.
+func (s *KeyStore) Add(issuer string, jwk JSONWebKey) error {
+	key, err := jwk.PublicKey()
+	if err != nil {
+		return err
+	}
+
+	shard := s.shard(issuer)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	keys, ok := shard.issuers[issuer]
+	if !ok {
+		keys = map[string]keyStoreEntry{}
+		shard.issuers[issuer] = keys
+	}
+
+	keys[jwk.KeyID] = keyStoreEntry{jwk: jwk, key: key}
+
+	return nil
+}
+
+// SetIssuer replaces the keys trusted for a given issuer with a key set. Keys
+// of types this package cannot verify with are skipped.
+func (s *KeyStore) SetIssuer(issuer string, set *JWKSet) {
+	keys := make(map[string]keyStoreEntry, len(set.Keys))
+
+	for _, jwk := range set.Keys {
+		if key, err := jwk.PublicKey(); err == nil {
+			keys[jwk.KeyID] = keyStoreEntry{jwk: jwk, key: key}
+		}
+	}
+
+	shard := s.shard(issuer)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	shard.issuers[issuer] = keys
+}
+
+// Remove stops trusting the key of an issuer with a given kid.
+func (s *KeyStore) Remove(issuer, kid string) {
+	shard := s.shard(issuer)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	delete(shard.issuers[issuer], kid)
+}
+
+// RemoveIssuer stops trusting every key of an issuer.
+func (s *KeyStore) RemoveIssuer(issuer string) {
+	shard := s.shard(issuer)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	delete(shard.issuers, issuer)
+}
+
+// Key returns the key of an issuer with a given kid.
+func (s *KeyStore) Key(issuer, kid string) (*JSONWebKey, bool) {
+	entry, ok := s.entry(issuer, kid)
+	if !ok {
+		return nil, false
+	}
+
+	return &entry.jwk, true
+}
+
+// Len returns the number of keys trusted across every issuer.
+func (s *KeyStore) Len() int {
+	n := 0
+
+	for i := range s.shards {
+		shard := &s.shards[i]
+		shard.mu.RLock()
+
+		for _, keys := range shard.issuers {
+			n += len(keys)
+		}
+
+		shard.mu.RUnlock()
+	}
+
+	return n
+}
+
+func (s *KeyStore) entry(issuer, kid string) (keyStoreEntry, bool) {
+	shard := s.shard(issuer)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+
+	entry, ok := shard.issuers[issuer][kid]
+	return entry, ok
+}
+
+func (s *KeyStore) shard(issuer string) *keyStoreShard {
+	h := fnv.New32a()
+	h.Write([]byte(issuer))
+
+	return &s.shards[h.Sum32()%keyStoreShards]
+}
+
+func (s *KeyStore) validate(jwt *jwt) (bool, error) {
+	entry, ok := s.entry(jwt.claimsPayload.Issuer, jwt.Header.KeyID)
+	if !ok {
+		return false, ErrUnknownKey
+	}
+
+	return entry.validate(jwt)
+}
+
+func (s *KeyStore) sign(jwt *jwt) error {
+	return ErrVerifyOnly
+}
+
+// validate verifies a token with the key, which must suit the algorithm the
+// token names.
+func (e keyStoreEntry) validate(jwt *jwt) (bool, error) {
+	if e.jwk.Algorithm != "" && e.jwk.Algorithm != jwt.Header.Algorithm {
+		return false, ErrAlgorithmNotImplemented
+	}
+
+	validator, err := validatorFor(jwt.Header.Algorithm, e.key)
+	if err != nil {
+		return false, err
+	}
+
+	return validator.validate(jwt)
+}
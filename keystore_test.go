@@ -0,0 +1,94 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestKeyStore(t *testing.T) {
+	signer := testRSValidator(t)
+
+	jwk, err := NewJSONWebKey(signer.PublicKey)
+	if err != nil {
+		t.Fatalf("Didn't expect describing the test key to return an error: %s", err)
+	}
+
+	store := NewKeyStore()
+
+	for i := 0; i < 1000; i++ {
+		jwk.KeyID = fmt.Sprintf("key-%d", i)
+		if err := store.Add(fmt.Sprintf("https://tenant-%d.example", i), *jwk); err != nil {
+			t.Fatalf("Didn't expect adding a key to return an error: %s", err)
+		}
+	}
+
+	if n := store.Len(); n != 1000 {
+		t.Errorf("Expected 1000 trusted keys; got %d", n)
+	}
+
+	if err := store.Add("https://tenant-0.example", JSONWebKey{KeyType: "OKP"}); err != ErrAlgorithmNotImplemented {
+		t.Errorf("Expected an unsupported key to return %s; got %v", ErrAlgorithmNotImplemented, err)
+	}
+
+	decode := func(issuer, kid string) error {
+		token := signTestToken(t, signer, Header{Type: "JWT", KeyID: kid}, &Payload{Issuer: issuer})
+		return NewDecoder(bytes.NewBufferString(token), store).Decode(&Payload{})
+	}
+
+	cases := []struct {
+		Issuer        string
+		KeyID         string
+		ExpectedError error
+		Reason        string
+	}{
+		{"https://tenant-42.example", "key-42", nil, "a token signed by a trusted key should be valid"},
+		{"https://tenant-42.example", "key-43", ErrUnknownKey, "a key of another issuer should not be trusted"},
+		{"https://tenant-1000.example", "key-42", ErrUnknownKey, "an unknown issuer should not be trusted"},
+	}
+
+	for _, c := range cases {
+		if err := decode(c.Issuer, c.KeyID); err != c.ExpectedError {
+			t.Errorf("Expected %v; %s: got %v", c.ExpectedError, c.Reason, err)
+		}
+	}
+
+	store.Remove("https://tenant-42.example", "key-42")
+	if err := decode("https://tenant-42.example", "key-42"); err != ErrUnknownKey {
+		t.Errorf("Expected a removed key to return %s; got %v", ErrUnknownKey, err)
+	}
+
+	jwk.KeyID, jwk.Algorithm = "rotated", RS256
+	store.SetIssuer("https://tenant-7.example", &JWKSet{Keys: []JSONWebKey{*jwk, {KeyType: "OKP", KeyID: "o1"}}})
+
+	if _, ok := store.Key("https://tenant-7.example", "key-7"); ok {
+		t.Errorf("Expected setting the keys of an issuer to replace its old keys")
+	}
+
+	if err := decode("https://tenant-7.example", "rotated"); err != nil {
+		t.Errorf("Expected a token signed by a rotated key to be valid; got %s", err)
+	}
+
+	store.RemoveIssuer("https://tenant-7.example")
+	if n := store.Len(); n != 998 {
+		t.Errorf("Expected 998 trusted keys after removals; got %d", n)
+	}
+
+	if _, err := NewEncoder(nil, store).Sign(&Payload{}); err != ErrVerifyOnly {
+		t.Errorf("Expected signing with a KeyStore to return %s; got %v", ErrVerifyOnly, err)
+	}
+}
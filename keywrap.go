@@ -0,0 +1,111 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"crypto/aes"
+	"crypto/subtle"
+	"encoding/binary"
+)
+
+// keyWrapIV is the initial value of RFC 3394
+var keyWrapIV = []byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+
+// keyWrapAlgorithm returns the AES Key Wrap algorithm of a key encryption key,
+// or an empty Algorithm when it is not of a size AES accepts
+func keyWrapAlgorithm(kek []byte) Algorithm {
+	switch len(kek) {
+	case 16:
+		return A128KW
+	case 24:
+		return A192KW
+	case 32:
+		return A256KW
+	}
+
+	return ""
+}
+
+// wrapKey wraps a key whose size is a multiple of 8 bytes under a key
+// encryption key as described by RFC 3394
+func wrapKey(kek, key []byte) ([]byte, error) {
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(key) < 16 || len(key)%8 != 0 {
+		return nil, ErrAlgorithmNotImplemented
+	}
+
+	n := len(key) / 8
+	wrapped := make([]byte, 8+len(key))
+	copy(wrapped, keyWrapIV)
+	copy(wrapped[8:], key)
+
+	b := make([]byte, 16)
+
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			copy(b, wrapped[:8])
+			copy(b[8:], wrapped[i*8:i*8+8])
+			block.Encrypt(b, b)
+
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(wrapped[:8], binary.BigEndian.Uint64(b[:8])^t)
+			copy(wrapped[i*8:i*8+8], b[8:])
+		}
+	}
+
+	return wrapped, nil
+}
+
+// unwrapKey reverses wrapKey. ErrDecryptionFailed is returned when the key
+// was not wrapped under the key encryption key or was tampered with.
+func unwrapKey(kek, wrapped []byte) ([]byte, error) {
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(wrapped) < 24 || len(wrapped)%8 != 0 {
+		return nil, ErrDecryptionFailed
+	}
+
+	n := len(wrapped)/8 - 1
+	a := make([]byte, 8)
+	key := make([]byte, len(wrapped)-8)
+	copy(a, wrapped[:8])
+	copy(key, wrapped[8:])
+
+	b := make([]byte, 16)
+
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(b[:8], binary.BigEndian.Uint64(a)^t)
+			copy(b[8:], key[(i-1)*8:i*8])
+			block.Decrypt(b, b)
+
+			copy(a, b[:8])
+			copy(key[(i-1)*8:i*8], b[8:])
+		}
+	}
+
+	if subtle.ConstantTimeCompare(a, keyWrapIV) != 1 {
+		return nil, ErrDecryptionFailed
+	}
+
+	return key, nil
+}
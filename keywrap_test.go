@@ -0,0 +1,93 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestKeyWrap(t *testing.T) {
+	// Test vectors from RFC 3394 section 4
+	cases := []struct {
+		KEK     string
+		Key     string
+		Wrapped string
+	}{
+		{"000102030405060708090A0B0C0D0E0F", "00112233445566778899AABBCCDDEEFF", "1FA68B0A8112B447AEF34BD8FB5A7B829D3E862371D2CFE5"},
+		{"000102030405060708090A0B0C0D0E0F1011121314151617", "00112233445566778899AABBCCDDEEFF", "96778B25AE6CA435F92B5B97C050AED2468AB8A17AD84E5D"},
+		{"000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F", "00112233445566778899AABBCCDDEEFF000102030405060708090A0B0C0D0E0F", "28C9F404C4B810F4CBCCB35CFB87F8263F5786E2D80ED326CBC7F0E71A99F43BFB988B9B7A02DD21"},
+	}
+
+	for _, c := range cases {
+		kek, _ := hex.DecodeString(c.KEK)
+		key, _ := hex.DecodeString(c.Key)
+		expected, _ := hex.DecodeString(c.Wrapped)
+
+		wrapped, err := wrapKey(kek, key)
+		if err != nil || !bytes.Equal(wrapped, expected) {
+			t.Errorf("Expected %s wrapped under %s to be %s; got %X and %v", c.Key, c.KEK, c.Wrapped, wrapped, err)
+		}
+
+		if unwrapped, err := unwrapKey(kek, expected); err != nil || !bytes.Equal(unwrapped, key) {
+			t.Errorf("Expected %s to unwrap to %s; got %X and %v", c.Wrapped, c.Key, unwrapped, err)
+		}
+
+		expected[len(expected)-1] ^= 1
+		if _, err := unwrapKey(kek, expected); err != ErrDecryptionFailed {
+			t.Errorf("Expected a tampered key to return %s; got %v", ErrDecryptionFailed, err)
+		}
+	}
+}
+
+func TestKeyWrapEncryptedTokens(t *testing.T) {
+	kek := []byte("0123456789abcdef")
+
+	token, err := NewKeyWrapEncrypter(nil, kek).Seal(&Payload{Subject: "1234567890"})
+	if err != nil {
+		t.Fatalf("Didn't expect encrypting a token with a wrapped key to return an error: %s", err)
+	}
+
+	if header, _ := parseField(strings.Split(token, ".")[0]); !strings.Contains(string(header), `"alg":"A128KW"`) {
+		t.Errorf("Expected a 16 byte key to select A128KW; got %s", header)
+	}
+
+	payload := &Payload{}
+	if _, err := NewKeyWrapDecrypter(nil, kek).Open(token, payload); err != nil || payload.Subject != "1234567890" {
+		t.Errorf("Expected the payload of the token; got %#v and %v", payload, err)
+	}
+
+	cases := []struct {
+		ExpectedError error
+		Reason        string
+		Decrypter     *Decrypter
+	}{
+		{ErrDecryptionFailed, "the key encryption key differs", NewKeyWrapDecrypter(nil, []byte("fedcba9876543210"))},
+		{ErrAlgorithmNotImplemented, "the key encryption key is of another size", NewKeyWrapDecrypter(nil, []byte("0123456789abcdef01234567"))},
+		{ErrAlgorithmNotImplemented, "the key encryption key is of no AES size", NewKeyWrapDecrypter(nil, []byte("short"))},
+		{ErrAlgorithmNotImplemented, "the decrypter expects a direct key", NewDirectDecrypter(nil, []byte("0123456789abcdef0123456789abcdef"))},
+	}
+
+	for _, c := range cases {
+		if _, err := c.Decrypter.Open(token, &Payload{}); err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		}
+	}
+
+	if _, err := NewKeyWrapEncrypter(nil, []byte("short")).Seal(&Payload{}); err != ErrAlgorithmNotImplemented {
+		t.Errorf("Expected a key encryption key of no AES size to return %s; got %v", ErrAlgorithmNotImplemented, err)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"crypto/rand"
+	"errors"
+	"sync"
+)
+
+// ErrBrokenLineage is returned when a chain of tokens does not link each token
+// to the token it was reissued from
+var ErrBrokenLineage = errors.New("broken token lineage")
+
+// A Lineage links a token to the token it was reissued or down-scoped from by
+// the jti of the parent in its prt claim.
+type Lineage struct {
+	JWTId  string `json:"jti"`
+	Parent string `json:"prt,omitempty"`
+}
+
+// NewLineage returns the Lineage of a token reissued from the token with a
+// given jti. The token is given a new random jti, which must be used as its
+// jti claim alongside the prt claim.
+func NewLineage(parent string) (Lineage, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return Lineage{}, err
+	}
+
+	return Lineage{JWTId: encodeSegment(id), Parent: parent}, nil
+}
+
+// VerifyLineage verifies a chain of tokens ordered from the newest token to
+// the root it was derived from and returns their lineage in the same order.
+// The newest token is verified like Verify; its ancestors have usually
+// expired so only their signatures are verified. Every token must name the
+// next by its prt claim and the root must have no parent.
+func (dec *Decoder) VerifyLineage(tokens ...string) ([]Lineage, error) {
+	if len(tokens) == 0 {
+		return nil, ErrBrokenLineage
+	}
+
+	lineage := make([]Lineage, len(tokens))
+	seen := make(map[string]bool, len(tokens))
+
+	if err := dec.Verify(tokens[0], &lineage[0]); err != nil {
+		return nil, err
+	}
+
+	for i := 1; i < len(tokens); i++ {
+		jwt, err := parseInflatingJWT(tokens[i], &lineage[i], dec.maxInflatedSize())
+		if err != nil {
+			return nil, err
+		}
+
+		if err := verifySignature(dec.verifier(), jwt, false); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, l := range lineage {
+		if l.JWTId == "" || seen[l.JWTId] {
+			return nil, ErrBrokenLineage
+		}
+
+		seen[l.JWTId] = true
+
+		if i < len(lineage)-1 && l.Parent != lineage[i+1].JWTId {
+			return nil, ErrBrokenLineage
+		}
+	}
+
+	if lineage[len(lineage)-1].Parent != "" {
+		return nil, ErrBrokenLineage
+	}
+
+	return lineage, nil
+}
+
+// A LineageLog records the lineage of issued tokens so the delegated access
+// chain of a token can be audited. It is safe for concurrent use.
+type LineageLog struct {
+	mu      sync.RWMutex
+	parents map[string]string
+}
+
+// NewLineageLog constructs an empty LineageLog.
+func NewLineageLog() *LineageLog {
+	return &LineageLog{parents: map[string]string{}}
+}
+
+// Record records the lineage of an issued token.
+func (l *LineageLog) Record(lineage Lineage) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.parents[lineage.JWTId] = lineage.Parent
+}
+
+// Ancestors returns the jti of every recorded ancestor of the token with a
+// given jti, nearest first.
+func (l *LineageLog) Ancestors(jti string) []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var ancestors []string
+	seen := map[string]bool{jti: true}
+
+	for parent := l.parents[jti]; parent != "" && !seen[parent]; parent = l.parents[parent] {
+		seen[parent] = true
+		ancestors = append(ancestors, parent)
+	}
+
+	return ancestors
+}
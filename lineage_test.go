@@ -0,0 +1,92 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"testing"
+	"time"
+)
+
+// lineageClaims are the claims of a token in a delegation chain
+type lineageClaims struct {
+	Payload
+	Parent string   `json:"prt,omitempty"`
+	Scopes []string `json:"scp"`
+}
+
+func TestVerifyLineage(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	log := NewLineageLog()
+	issue := func(parent string, expiry time.Duration, scopes ...string) (string, string) {
+		lineage, err := NewLineage(parent)
+		if err != nil {
+			t.Fatalf("Recieved error when creating a lineage: %s", err)
+		}
+
+		log.Record(lineage)
+
+		claims := &lineageClaims{Parent: parent, Scopes: scopes}
+		claims.JWTId = lineage.JWTId
+		claims.ExpirationTime = NewNumericDate(time.Now().Add(expiry))
+
+		return signTestToken(t, v, Header{Type: "JWT"}, claims), lineage.JWTId
+	}
+
+	root, rootID := issue("", -time.Hour, "read", "write")
+	refreshed, refreshedID := issue(rootID, -time.Minute, "read", "write")
+	scoped, scopedID := issue(refreshedID, time.Hour, "read")
+	other, _ := issue("", time.Hour, "read")
+
+	dec := NewDecoder(nil, v)
+
+	lineage, err := dec.VerifyLineage(scoped, refreshed, root)
+	if err != nil {
+		t.Fatalf("Didn't expect verifying a lineage to return an error: %s", err)
+	}
+
+	if len(lineage) != 3 || lineage[0].JWTId != scopedID || lineage[1].JWTId != refreshedID || lineage[2].JWTId != rootID {
+		t.Errorf("Expected the lineage from the scoped token to the root; got %v", lineage)
+	}
+
+	cases := []struct {
+		ExpectedError error
+		Reason        string
+		Tokens        []string
+	}{
+		{ErrBrokenLineage, "no tokens are given", nil},
+		{ErrBrokenLineage, "the chain does not reach the root", []string{scoped, refreshed}},
+		{ErrBrokenLineage, "a token is skipped", []string{scoped, root}},
+		{ErrBrokenLineage, "the parent is another token", []string{scoped, other}},
+		{ErrBrokenLineage, "a token is repeated", []string{other, other}},
+		{ErrTokenExpired, "the newest token has expired", []string{refreshed, root}},
+		{ErrBadSignature, "an ancestor is forged", []string{scoped, refreshed, signTestToken(t, NewHSValidator(HS256), Header{Type: "JWT"}, &Payload{JWTId: rootID})}},
+	}
+
+	for _, c := range cases {
+		if _, err := dec.VerifyLineage(c.Tokens...); err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		}
+	}
+
+	if ancestors := log.Ancestors(scopedID); len(ancestors) != 2 || ancestors[0] != refreshedID || ancestors[1] != rootID {
+		t.Errorf("Expected the recorded ancestors of the scoped token; got %v", ancestors)
+	}
+
+	log.Record(Lineage{JWTId: rootID, Parent: scopedID})
+	if ancestors := log.Ancestors(scopedID); len(ancestors) != 2 {
+		t.Errorf("Expected walking a cyclic lineage to stop; got %v", ancestors)
+	}
+}
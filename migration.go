@@ -0,0 +1,127 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"sync"
+	"time"
+)
+
+// An AlgorithmMigration is a Validator that moves an issuer from one algorithm
+// to another without downtime, e.g. from RS256 to ES256. It signs with the new
+// validator while verifying tokens signed with either, and counts the tokens
+// of each algorithm it verifies so it can tell when tokens signed with the old
+// algorithm have stopped arriving.
+//
+// An AlgorithmMigration is safe for concurrent use.
+type AlgorithmMigration struct {
+	oldAlgorithm  Algorithm
+	old           Validator
+	nextAlgorithm Algorithm
+	next          Validator
+	quiet         time.Duration
+
+	mu      sync.Mutex
+	started time.Time
+	stats   MigrationStats
+}
+
+// MigrationStats are the tokens an AlgorithmMigration has verified.
+type MigrationStats struct {
+	// Old is the number of valid tokens signed with the old algorithm
+	Old uint64
+	// New is the number of valid tokens signed with the new algorithm
+	New uint64
+	// LastOld is when a token signed with the old algorithm was last verified
+	LastOld time.Time
+}
+
+// OldShare returns the share of verified tokens that were signed with the old
+// algorithm, between 0 and 1.
+func (s MigrationStats) OldShare() float64 {
+	if s.Old+s.New == 0 {
+		return 0
+	}
+
+	return float64(s.Old) / float64(s.Old+s.New)
+}
+
+// NewAlgorithmMigration constructs an AlgorithmMigration from the validator of
+// an old algorithm to that of a new algorithm. It is safe to drop the old
+// validator once no token signed with it has been verified for the quiet
+// period, which should be at least the lifetime of tokens.
+func NewAlgorithmMigration(oldAlgorithm Algorithm, old Validator, nextAlgorithm Algorithm, next Validator, quiet time.Duration) *AlgorithmMigration {
+	return &AlgorithmMigration{
+		oldAlgorithm:  oldAlgorithm,
+		old:           old,
+		nextAlgorithm: nextAlgorithm,
+		next:          next,
+		quiet:         quiet,
+		started:       timeFunc(),
+	}
+}
+
+// Stats returns the tokens the migration has verified.
+func (m *AlgorithmMigration) Stats() MigrationStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.stats
+}
+
+// Safe reports whether the old validator may be dropped: no token signed with
+// the old algorithm has been verified for the quiet period, counted from when
+// the migration started.
+func (m *AlgorithmMigration) Safe() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	since := m.started
+	if m.stats.LastOld.After(since) {
+		since = m.stats.LastOld
+	}
+
+	return timeFunc().Sub(since) >= m.quiet
+}
+
+func (m *AlgorithmMigration) validate(jwt *jwt) (bool, error) {
+	switch jwt.Header.Algorithm {
+	case m.nextAlgorithm:
+		valid, err := m.next.validate(jwt)
+		if valid && err == nil {
+			m.mu.Lock()
+			m.stats.New++
+			m.mu.Unlock()
+		}
+
+		return valid, err
+	case m.oldAlgorithm:
+		valid, err := m.old.validate(jwt)
+		if valid && err == nil {
+			m.mu.Lock()
+			m.stats.Old++
+			m.stats.LastOld = timeFunc()
+			m.mu.Unlock()
+		}
+
+		return valid, err
+	}
+
+	return false, ErrAlgorithmNotImplemented
+}
+
+// sign signs with the validator of the new algorithm
+func (m *AlgorithmMigration) sign(jwt *jwt) error {
+	return m.next.sign(jwt)
+}
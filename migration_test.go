@@ -0,0 +1,70 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAlgorithmMigration(t *testing.T) {
+	now := time.Unix(1516239022, 0)
+	timeFunc = func() time.Time { return now }
+	defer func() { timeFunc = time.Now }()
+
+	rs := testRSValidator(t)
+	hs := NewHSValidator(HS512)
+	hs.Key = []byte("bogokey")
+
+	migration := NewAlgorithmMigration(RS256, rs, HS512, hs, time.Hour)
+	dec := NewDecoder(nil, migration)
+
+	oldToken := signTestToken(t, rs, Header{Type: "JWT"}, &Payload{Subject: "1234567890"})
+	newToken := signTestToken(t, migration, Header{Type: "JWT"}, &Payload{Subject: "1234567890"})
+
+	if result, err := dec.verify(newToken, &Payload{}); err != nil || result.Algorithm != HS512 {
+		t.Fatalf("Expected the migration to sign with the new algorithm; got %v and %v", result, err)
+	}
+
+	if err := dec.Verify(oldToken, &Payload{}); err != nil {
+		t.Errorf("Didn't expect a token signed with the old algorithm to return an error: %s", err)
+	}
+
+	other := NewHSValidator(HS256)
+	if err := dec.Verify(signTestToken(t, other, Header{Type: "JWT"}, &Payload{}), &Payload{}); err != ErrAlgorithmNotImplemented {
+		t.Errorf("Expected a token signed with a third algorithm to return %s; got %v", ErrAlgorithmNotImplemented, err)
+	}
+
+	dec.Verify(newToken, &Payload{})
+	dec.Verify(newToken, &Payload{})
+
+	stats := migration.Stats()
+	if stats.Old != 1 || stats.New != 3 || stats.OldShare() != 0.25 || !stats.LastOld.Equal(now) {
+		t.Errorf("Expected 1 old and 3 new tokens; got %#v with an old share of %v", stats, stats.OldShare())
+	}
+
+	now = now.Add(59 * time.Minute)
+	if migration.Safe() {
+		t.Errorf("Didn't expect the migration to be safe within the quiet period of an old token")
+	}
+
+	now = now.Add(time.Minute)
+	if !migration.Safe() {
+		t.Errorf("Expected the migration to be safe once no old tokens were seen for the quiet period")
+	}
+
+	if NewAlgorithmMigration(RS256, rs, HS512, hs, time.Hour).Safe() {
+		t.Errorf("Didn't expect a migration to be safe before its quiet period has passed")
+	}
+}
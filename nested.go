@@ -0,0 +1,99 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"strings"
+)
+
+// NestedContentType is the cty header of a token whose payload is itself a
+// token
+const NestedContentType = "JWT"
+
+// Nest signs a token whose payload is a given token, e.g. to countersign a
+// token issued by another party. The outer token is signed by the validator of
+// the Encoder and has a cty header of NestedContentType.
+func (enc *Encoder) Nest(inner SignedToken) (SignedToken, error) {
+	if enc.validator == nil {
+		return "", ErrNoValidator
+	}
+
+	if strings.Count(string(inner), ".") != 2 {
+		return "", ErrMalformedToken
+	}
+
+	jwt := jwt{
+		Header:  &Header{Type: "JWT", ContentType: NestedContentType},
+		Payload: rawPayload(inner),
+	}
+
+	if err := enc.validator.sign(&jwt); err != nil {
+		return "", err
+	}
+
+	return SignedToken(jwt.token()), nil
+}
+
+// VerifyNested verifies a token produced by Nest in one call: the signature of
+// the outer token is verified as by Verify, and the token it carries is
+// verified with inner and every other check of the Decoder before its claims
+// are decoded into v.
+func (dec *Decoder) VerifyNested(token string, inner Validator, v interface{}) error {
+	payload, err := dec.unwrap(token)
+	if err != nil {
+		dec.stats.record(nil, err)
+		return err
+	}
+
+	_, err = dec.verifyWith(inner, payload, v)
+
+	return err
+}
+
+// unwrap verifies the signature of a nested token and returns the token it
+// carries
+func (dec *Decoder) unwrap(input string) (string, error) {
+	validator := dec.verifier()
+	if validator == nil {
+		return "", ErrNoValidator
+	}
+
+	fields := strings.Split(input, ".")
+	if len(fields) != 3 {
+		return "", ErrMalformedToken
+	}
+
+	jwt := &jwt{Header: &Header{}}
+	if err := jwt.parseHeader(fields[0]); err != nil {
+		return "", ErrMalformedToken
+	}
+
+	if !strings.EqualFold(jwt.Header.ContentType, NestedContentType) {
+		return "", ErrMalformedToken
+	}
+
+	payload, err := parseField(fields[1])
+	if err != nil {
+		return "", ErrMalformedToken
+	}
+
+	jwt.payloadRaw = []byte(fields[1])
+	jwt.Signature = []byte(fields[2])
+
+	if err := verifySignature(validator, jwt, false); err != nil {
+		return "", err
+	}
+
+	return string(payload), nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNestedTokens(t *testing.T) {
+	issuer := NewHSValidator(HS256)
+	issuer.Key = []byte("issuerkey")
+
+	gateway := testRSValidator(t)
+
+	inner, err := NewEncoder(nil, issuer).Sign(&Payload{Subject: "1234567890"})
+	if err != nil {
+		t.Fatalf("Didn't expect signing the inner token to return an error: %s", err)
+	}
+
+	nested, err := NewEncoder(nil, gateway).Nest(inner)
+	if err != nil {
+		t.Fatalf("Didn't expect nesting a token to return an error: %s", err)
+	}
+
+	other := NewHSValidator(HS256)
+	other.Key = []byte("otherkey")
+
+	forged := strings.Split(string(nested), ".")
+	forged[1] = encodeSegment([]byte(signTestToken(t, other, Header{Type: "JWT"}, &Payload{Subject: "admin"})))
+
+	cases := []struct {
+		ExpectedError error
+		Reason        string
+		Token         string
+		Inner         Validator
+	}{
+		{nil, "both signatures are valid", string(nested), issuer},
+		{ErrBadSignature, "the inner signature is from another key", string(nested), other},
+		{ErrBadSignature, "the inner token was replaced", strings.Join(forged, "."), other},
+		{ErrMalformedToken, "the token is not nested", string(inner), issuer},
+		{ErrNoValidator, "no inner validator is given", string(nested), nil},
+	}
+
+	dec := NewDecoder(nil, gateway)
+
+	for _, c := range cases {
+		if err := dec.VerifyNested(c.Token, c.Inner, &Payload{}); err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		}
+	}
+
+	dec.Types = []string{"at+jwt"}
+
+	if err := dec.VerifyNested(string(nested), issuer, &Payload{}); err != ErrInvalidType {
+		t.Errorf("Expected the checks of the Decoder to apply to the inner token; got %v", err)
+	}
+
+	dec.Types = nil
+	payload := &Payload{}
+
+	if err := dec.VerifyNested(string(nested), issuer, payload); err != nil || payload.Subject != "1234567890" {
+		t.Errorf("Expected the claims of the inner token; got %#v and %v", payload, err)
+	}
+
+	if _, err := NewEncoder(nil, gateway).Nest("not a token"); err != ErrMalformedToken {
+		t.Errorf("Expected nesting something other than a token to return %s; got %v", ErrMalformedToken, err)
+	}
+}
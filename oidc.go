@@ -0,0 +1,92 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// OIDCDiscoveryPath is where an OpenID Connect provider publishes its metadata
+// relative to its issuer
+const OIDCDiscoveryPath = "/.well-known/openid-configuration"
+
+// OIDCMetadata is the part of the metadata of an OpenID Connect provider that
+// concerns its tokens.
+type OIDCMetadata struct {
+	Issuer        string `json:"issuer"`
+	JWKSURI       string `json:"jwks_uri"`
+	TokenEndpoint string `json:"token_endpoint,omitempty"`
+	// SigningAlgorithms are the algorithms ID tokens may be signed with
+	SigningAlgorithms []Algorithm `json:"id_token_signing_alg_values_supported,omitempty"`
+}
+
+// An OIDCProvider verifies the tokens of an OpenID Connect provider found by
+// discovery. Its keys are a RemoteKeySet, which caches them and fetches them
+// again when the provider rotates them.
+type OIDCProvider struct {
+	Metadata OIDCMetadata
+	Keys     *RemoteKeySet
+}
+
+// DiscoverOIDCProvider fetches the metadata of the OpenID Connect provider of
+// a given issuer, e.g. https://accounts.google.com, with a given client, or
+// one with a timeout of DefaultFetchTimeout when nil. The metadata must name
+// the issuer exactly so that one provider cannot stand in for another.
+func DiscoverOIDCProvider(issuer string, client *http.Client) (*OIDCProvider, error) {
+	if client == nil {
+		client = defaultClient
+	}
+
+	resp, err := client.Get(strings.TrimSuffix(issuer, "/") + OIDCDiscoveryPath)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching provider metadata of %s: %s", issuer, resp.Status)
+	}
+
+	var metadata OIDCMetadata
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxKeySetSize)).Decode(&metadata); err != nil {
+		return nil, err
+	}
+
+	if metadata.Issuer != issuer {
+		return nil, ErrInvalidIssuer
+	}
+
+	if metadata.JWKSURI == "" {
+		return nil, fmt.Errorf("provider metadata of %s has no jwks_uri", issuer)
+	}
+
+	keys := NewRemoteKeySet(metadata.JWKSURI)
+	keys.Client = client
+
+	return &OIDCProvider{Metadata: metadata, Keys: keys}, nil
+}
+
+// Decoder returns a Decoder verifying the tokens of the provider with its
+// keys and accepting its issuer only. Other checks, e.g. an Audience, are
+// configured on the Decoder as usual.
+func (p *OIDCProvider) Decoder() *Decoder {
+	dec := NewDecoder(nil, p.Keys)
+	dec.Issuers = []string{p.Metadata.Issuer}
+
+	return dec
+}
@@ -0,0 +1,76 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDiscoverOIDCProvider(t *testing.T) {
+	signer := testRSValidator(t)
+	jwk, _ := NewJSONWebKey(signer.PublicKey)
+	jwk.KeyID = "k1"
+
+	var issuer string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(OIDCDiscoveryPath, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(OIDCMetadata{Issuer: issuer, JWKSURI: issuer + "/keys", SigningAlgorithms: []Algorithm{RS256}})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(NewJWKSet(jwk))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	issuer = server.URL
+
+	if _, err := DiscoverOIDCProvider(issuer+"/", server.Client()); err != ErrInvalidIssuer {
+		t.Errorf("Expected %s when the metadata names another issuer; got %v", ErrInvalidIssuer, err)
+	}
+
+	provider, err := DiscoverOIDCProvider(issuer, server.Client())
+	if err != nil {
+		t.Fatalf("Didn't expect discovering a provider to return an error: %s", err)
+	}
+
+	if provider.Metadata.JWKSURI != issuer+"/keys" || len(provider.Metadata.SigningAlgorithms) != 1 {
+		t.Errorf("Expected the metadata of the provider; got %#v", provider.Metadata)
+	}
+
+	cases := []struct {
+		ExpectedError error
+		Reason        string
+		Issuer        string
+	}{
+		{nil, "the token is issued by the provider", issuer},
+		{ErrInvalidIssuer, "the token is issued by another provider", "https://other.example"},
+	}
+
+	for _, c := range cases {
+		token := signTestToken(t, signer, Header{Type: "JWT", KeyID: "k1"}, &Payload{Issuer: c.Issuer})
+
+		if err := provider.Decoder().Verify(token, &Payload{}); err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		}
+	}
+
+	if _, err := DiscoverOIDCProvider(issuer+"/missing", server.Client()); err == nil {
+		t.Errorf("Expected an error when the provider has no metadata")
+	}
+}
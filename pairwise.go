@@ -0,0 +1,79 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// ErrInvalidSectorIdentifier is returned when a sector identifier cannot be
+// derived from a URI
+var ErrInvalidSectorIdentifier = errors.New("invalid sector identifier")
+
+// A SubjectType is how an issuer derives the sub claims of a subject as
+// described by OpenID Connect Core section 8.
+type SubjectType string
+
+const (
+	// SubjectTypePublic gives every relying party the same sub
+	SubjectTypePublic SubjectType = "public"
+	// SubjectTypePairwise gives each sector a different sub so relying
+	// parties cannot correlate subjects
+	SubjectTypePairwise SubjectType = "pairwise"
+)
+
+// PairwiseSubjects derives pairwise subject identifiers as described by
+// OpenID Connect Core section 8.1. The salt must be kept secret and must not
+// change, otherwise the sub of every subject changes.
+type PairwiseSubjects struct {
+	salt []byte
+}
+
+// NewPairwiseSubjects constructs a PairwiseSubjects with a given secret salt.
+func NewPairwiseSubjects(salt []byte) *PairwiseSubjects {
+	return &PairwiseSubjects{salt: salt}
+}
+
+// Subject returns the sub of a local account for relying parties of a given
+// sector, the base64url encoded SHA-256 of the sector identifier, the local
+// account id and the salt.
+func (p *PairwiseSubjects) Subject(sector, localID string) string {
+	h := sha256.New()
+	h.Write([]byte(sector))
+	h.Write([]byte(localID))
+	h.Write(p.salt)
+
+	return encodeSegment(h.Sum(nil))
+}
+
+// Verify reports whether a sub is the pairwise subject of a local account for
+// a given sector.
+func (p *PairwiseSubjects) Verify(sub, sector, localID string) bool {
+	return subtle.ConstantTimeCompare([]byte(sub), []byte(p.Subject(sector, localID))) == 1
+}
+
+// SectorIdentifier returns the sector identifier of a relying party, the host
+// of its sector_identifier_uri or, if it has none, of its redirect_uri.
+func SectorIdentifier(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Hostname() == "" {
+		return "", ErrInvalidSectorIdentifier
+	}
+
+	return strings.ToLower(u.Hostname()), nil
+}
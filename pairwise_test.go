@@ -0,0 +1,68 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"crypto/sha256"
+	"testing"
+)
+
+func TestPairwiseSubjects(t *testing.T) {
+	subjects := NewPairwiseSubjects([]byte("s3cr3t-salt"))
+
+	sum := sha256.Sum256([]byte("client.example.org" + "248289761001" + "s3cr3t-salt"))
+	expected := encodeSegment(sum[:])
+
+	sub := subjects.Subject("client.example.org", "248289761001")
+	if sub != expected {
+		t.Errorf("Expected the hash of the sector, account and salt %s; got %s", expected, sub)
+	}
+
+	if other := subjects.Subject("other.example.net", "248289761001"); other == sub {
+		t.Errorf("Expected different sectors to get different subjects; got %s for both", sub)
+	}
+
+	if resalted := NewPairwiseSubjects([]byte("other-salt")).Subject("client.example.org", "248289761001"); resalted == sub {
+		t.Errorf("Expected different salts to give different subjects; got %s for both", sub)
+	}
+
+	if !subjects.Verify(sub, "client.example.org", "248289761001") {
+		t.Errorf("Expected %s to be verified as the subject of the account", sub)
+	}
+
+	if subjects.Verify(sub, "client.example.org", "248289761002") {
+		t.Errorf("Didn't expect %s to be verified as the subject of another account", sub)
+	}
+}
+
+func TestSectorIdentifier(t *testing.T) {
+	cases := []struct {
+		URI      string
+		Expected string
+		Err      error
+	}{
+		{"https://client.example.org/callback", "client.example.org", nil},
+		{"https://Client.Example.org:8443/sectors.json", "client.example.org", nil},
+		{"/callback", "", ErrInvalidSectorIdentifier},
+		{"://bad", "", ErrInvalidSectorIdentifier},
+	}
+
+	for _, c := range cases {
+		sector, err := SectorIdentifier(c.URI)
+
+		if sector != c.Expected || err != c.Err {
+			t.Errorf("Expected %q and %v for %s; got %q and %v", c.Expected, c.Err, c.URI, sector, err)
+		}
+	}
+}
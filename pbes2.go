@@ -0,0 +1,150 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"hash"
+	"io"
+)
+
+const (
+	// PBES2HS256A128KW wraps content encryption keys with A128KW under a key
+	// derived from a password with PBKDF2 and HMAC SHA-256
+	PBES2HS256A128KW = "PBES2-HS256+A128KW"
+	// PBES2HS384A192KW wraps content encryption keys with A192KW under a key
+	// derived from a password with PBKDF2 and HMAC SHA-384
+	PBES2HS384A192KW = "PBES2-HS384+A192KW"
+	// PBES2HS512A256KW wraps content encryption keys with A256KW under a key
+	// derived from a password with PBKDF2 and HMAC SHA-512
+	PBES2HS512A256KW = "PBES2-HS512+A256KW"
+	// DefaultPBES2Count is the number of PBKDF2 iterations an Encrypter
+	// derives keys with by default
+	DefaultPBES2Count = 600000
+	// MaxPBES2Count is the most PBKDF2 iterations a Decrypter derives a key
+	// with, so that a token cannot make it spin
+	MaxPBES2Count = 2000000
+	// pbes2 marks a Decrypter accepting any PBES2 algorithm
+	pbes2 Algorithm = "PBES2"
+)
+
+// NewPasswordEncrypter creates an Encrypter that writes tokens whose content
+// encryption keys are wrapped under a key derived from a password to w, e.g.
+// for exports of user data. The algorithm is one of PBES2HS256A128KW,
+// PBES2HS384A192KW or PBES2HS512A256KW.
+func NewPasswordEncrypter(w io.Writer, alg Algorithm, password []byte) *Encrypter {
+	return &Encrypter{w: w, alg: alg, secret: password}
+}
+
+// NewPasswordDecrypter creates a Decrypter of the white space separated tokens
+// read from r whose content encryption keys are wrapped under a key derived
+// from a password with any of the PBES2 algorithms.
+func NewPasswordDecrypter(r io.Reader, password []byte) *Decrypter {
+	return &Decrypter{source: StreamSource(r), alg: pbes2, secret: password}
+}
+
+// isPBES2 reports whether an algorithm is one of the PBES2 algorithms
+func isPBES2(alg Algorithm) bool {
+	return alg == PBES2HS256A128KW || alg == PBES2HS384A192KW || alg == PBES2HS512A256KW
+}
+
+// passwordWrap wraps a content encryption key under a key derived from the
+// password of the Encrypter with a new salt, which is added to the header
+func (e *Encrypter) passwordWrap(header *Header, cek []byte) ([]byte, error) {
+	salt := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+
+	header.PBES2Salt = encodeSegment(salt)
+	header.PBES2Count = e.PBES2Count
+
+	if header.PBES2Count == 0 {
+		header.PBES2Count = DefaultPBES2Count
+	}
+
+	return wrapKey(pbes2Key(header.Algorithm, e.secret, salt, header.PBES2Count), cek)
+}
+
+// passwordUnwrap unwraps a content encryption key under a key derived from the
+// password of the Decrypter with the parameters of a header
+func (d *Decrypter) passwordUnwrap(header *Header, encryptedKey []byte) ([]byte, error) {
+	if header.PBES2Count < 1000 || header.PBES2Count > MaxPBES2Count {
+		return nil, ErrDecryptionFailed
+	}
+
+	salt, err := parseField(header.PBES2Salt)
+	if err != nil || len(salt) < 8 {
+		return nil, ErrDecryptionFailed
+	}
+
+	cek, err := unwrapKey(pbes2Key(header.Algorithm, d.secret, salt, header.PBES2Count), encryptedKey)
+	if err != nil || len(cek) != 32 {
+		return nil, ErrDecryptionFailed
+	}
+
+	return cek, nil
+}
+
+// pbes2Key derives the key encryption key of a PBES2 algorithm from a password
+// as described by RFC 7518 section 4.8
+func pbes2Key(alg Algorithm, password, salt []byte, count int) []byte {
+	h, size := sha256.New, 16
+
+	switch alg {
+	case PBES2HS384A192KW:
+		h, size = sha512.New384, 24
+	case PBES2HS512A256KW:
+		h, size = sha512.New, 32
+	}
+
+	input := append(append([]byte(alg), 0), salt...)
+
+	return pbkdf2(h, password, input, count, size)
+}
+
+// pbkdf2 derives a key of a given size with PBKDF2 as described by RFC 8018
+func pbkdf2(h func() hash.Hash, password, salt []byte, count, size int) []byte {
+	prf := hmac.New(h, password)
+	key := make([]byte, 0, size+prf.Size())
+
+	block := make([]byte, 4)
+	for i := uint32(1); len(key) < size; i++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(block, i)
+		prf.Write(block)
+
+		u := prf.Sum(nil)
+		t := append([]byte(nil), u...)
+
+		for n := 1; n < count; n++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+
+		key = append(key, t...)
+	}
+
+	return key[:size]
+}
@@ -0,0 +1,88 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestPBKDF2(t *testing.T) {
+	cases := []struct {
+		Count    int
+		Expected string
+	}{
+		{1, "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b"},
+		{2, "ae4d0c95af6b46d32d0adff928f06dd02a303f8ef3c251dfd6e2d85a95474c43"},
+		{4096, "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"},
+	}
+
+	for _, c := range cases {
+		if key := hex.EncodeToString(pbkdf2(sha256.New, []byte("password"), []byte("salt"), c.Count, 32)); key != c.Expected {
+			t.Errorf("Expected PBKDF2 with %d iterations to derive %s; got %s", c.Count, c.Expected, key)
+		}
+	}
+}
+
+func TestPasswordEncryptedTokens(t *testing.T) {
+	password := []byte("correct horse battery staple")
+
+	for _, alg := range []Algorithm{PBES2HS256A128KW, PBES2HS384A192KW, PBES2HS512A256KW} {
+		enc := NewPasswordEncrypter(nil, alg, password)
+		enc.PBES2Count = 1000
+
+		token, err := enc.Seal(&Payload{Subject: "1234567890"})
+		if err != nil {
+			t.Fatalf("Didn't expect encrypting a token with %s to return an error: %s", alg, err)
+		}
+
+		payload := &Payload{}
+		header, err := NewPasswordDecrypter(nil, password).Open(token, payload)
+		if err != nil || payload.Subject != "1234567890" || header.Algorithm != alg || header.PBES2Count != 1000 {
+			t.Errorf("Expected the payload of a token encrypted with %s; got %#v, %#v and %v", alg, payload, header, err)
+		}
+
+		if _, err := NewPasswordDecrypter(nil, []byte("wrong")).Open(token, payload); err != ErrDecryptionFailed {
+			t.Errorf("Expected a wrong password to return %s; got %v", ErrDecryptionFailed, err)
+		}
+	}
+
+	cases := []struct {
+		ExpectedError error
+		Reason        string
+		Count         int
+	}{
+		{ErrDecryptionFailed, "the count is too low", 999},
+		{ErrDecryptionFailed, "the count is too high", MaxPBES2Count + 1},
+	}
+
+	enc := NewPasswordEncrypter(nil, PBES2HS256A128KW, password)
+	enc.PBES2Count = 1000
+
+	token, _ := enc.Seal(&Payload{})
+	segments := strings.Split(token, ".")
+
+	for _, c := range cases {
+		header, _ := json.Marshal(Header{Algorithm: PBES2HS256A128KW, Encryption: A256GCM, PBES2Salt: encodeSegment([]byte("saltsalt")), PBES2Count: c.Count})
+		segments[0] = encodeSegment(header)
+		token := strings.Join(segments, ".")
+
+		if _, err := NewPasswordDecrypter(nil, password).Open(token, &Payload{}); err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		}
+	}
+}
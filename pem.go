@@ -0,0 +1,250 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"hash"
+)
+
+var (
+	// ErrInvalidPrivateKey is returned when a PEM document holds no private
+	// key this package can sign with
+	ErrInvalidPrivateKey = errors.New("invalid private key")
+	// ErrIncorrectPassphrase is returned when an encrypted private key cannot
+	// be decrypted with the passphrase given
+	ErrIncorrectPassphrase = errors.New("incorrect passphrase")
+)
+
+// A PassphraseFunc returns the passphrase of an encrypted private key, e.g. by
+// prompting for it or reading it from a secret store.
+type PassphraseFunc func() ([]byte, error)
+
+// Passphrase returns a PassphraseFunc of a fixed passphrase.
+func Passphrase(passphrase []byte) PassphraseFunc {
+	return func() ([]byte, error) { return passphrase, nil }
+}
+
+var (
+	oidPBES2      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+	oidHMACSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+	oidAES128CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+// encryptedPrivateKeyInfo is an encrypted PKCS #8 private key
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+// pbes2Params are the parameters of PBES2 encryption as defined by RFC 8018
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+// pbkdf2Params are the parameters of PBKDF2 as defined by RFC 8018
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// ParsePrivateKeyPEM parses the first private key of a PEM document to sign
+// tokens with, e.g. through a SignerValidator. PKCS #1, SEC 1 and PKCS #8 keys
+// are understood, including keys encrypted with a passphrase as legacy
+// Proc-Type and DEK-Info blocks or as PBES2 encrypted PKCS #8. passphrase is
+// only called for encrypted keys and may be nil otherwise.
+func ParsePrivateKeyPEM(data []byte, passphrase PassphraseFunc) (crypto.Signer, error) {
+	for {
+		var block *pem.Block
+		if block, data = pem.Decode(data); block == nil {
+			return nil, ErrInvalidPrivateKey
+		}
+
+		switch block.Type {
+		case "RSA PRIVATE KEY", "EC PRIVATE KEY", "PRIVATE KEY", "ENCRYPTED PRIVATE KEY":
+			return parsePrivateKeyBlock(block, passphrase)
+		}
+	}
+}
+
+// parsePrivateKeyBlock decrypts a PEM block if needed and parses its key
+func parsePrivateKeyBlock(block *pem.Block, passphrase PassphraseFunc) (crypto.Signer, error) {
+	der := block.Bytes
+	encrypted := block.Type == "ENCRYPTED PRIVATE KEY" || x509.IsEncryptedPEMBlock(block)
+
+	if encrypted {
+		if passphrase == nil {
+			return nil, ErrIncorrectPassphrase
+		}
+
+		secret, err := passphrase()
+		if err != nil {
+			return nil, err
+		}
+
+		if block.Type == "ENCRYPTED PRIVATE KEY" {
+			der, err = decryptPKCS8(der, secret)
+		} else {
+			der, err = x509.DecryptPEMBlock(block, secret)
+		}
+
+		if err != nil {
+			return nil, ErrIncorrectPassphrase
+		}
+	}
+
+	key, err := parsePrivateKeyDER(block.Type, der)
+	if err != nil && encrypted {
+		// Wrong passphrases are mostly caught by the padding of the key, but
+		// may decrypt to garbage that happens to be padded correctly
+		return nil, ErrIncorrectPassphrase
+	}
+
+	return key, err
+}
+
+// parsePrivateKeyDER parses the DER of a private key of a PEM block type
+func parsePrivateKeyDER(blockType string, der []byte) (crypto.Signer, error) {
+	var key interface{}
+	var err error
+
+	switch blockType {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(der)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(der)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(der)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if err != nil || !ok {
+		return nil, ErrInvalidPrivateKey
+	}
+
+	return signer, nil
+}
+
+// decryptPKCS8 decrypts a PBES2 encrypted PKCS #8 private key using PBKDF2
+// and AES-CBC, which is what OpenSSL produces by default
+func decryptPKCS8(der, passphrase []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil || !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, ErrInvalidPrivateKey
+	}
+
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil || !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, ErrInvalidPrivateKey
+	}
+
+	var kdf pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		return nil, ErrInvalidPrivateKey
+	}
+
+	if kdf.IterationCount < 1 || kdf.IterationCount > MaxPBES2Count {
+		return nil, ErrInvalidPrivateKey
+	}
+
+	prf, size := pkcs8PRF(kdf.PRF.Algorithm), pkcs8KeySize(params.EncryptionScheme.Algorithm)
+	if prf == nil || size == 0 {
+		return nil, ErrInvalidPrivateKey
+	}
+
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil || len(iv) != aes.BlockSize {
+		return nil, ErrInvalidPrivateKey
+	}
+
+	if len(info.EncryptedData) == 0 || len(info.EncryptedData)%aes.BlockSize != 0 {
+		return nil, ErrInvalidPrivateKey
+	}
+
+	block, err := aes.NewCipher(pbkdf2(prf, passphrase, kdf.Salt, kdf.IterationCount, size))
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, info.EncryptedData)
+
+	return unpad(plaintext)
+}
+
+// pkcs8PRF returns the hash of the PRF of PBKDF2, which is HMAC-SHA1 when it
+// is absent
+func pkcs8PRF(oid asn1.ObjectIdentifier) func() hash.Hash {
+	switch {
+	case len(oid) == 0, oid.Equal(oidHMACSHA1):
+		return sha1.New
+	case oid.Equal(oidHMACSHA256):
+		return sha256.New
+	case oid.Equal(oidHMACSHA384):
+		return sha512.New384
+	case oid.Equal(oidHMACSHA512):
+		return sha512.New
+	default:
+		return nil
+	}
+}
+
+// pkcs8KeySize returns the key size of an AES-CBC encryption scheme
+func pkcs8KeySize(oid asn1.ObjectIdentifier) int {
+	switch {
+	case oid.Equal(oidAES128CBC):
+		return 16
+	case oid.Equal(oidAES192CBC):
+		return 24
+	case oid.Equal(oidAES256CBC):
+		return 32
+	default:
+		return 0
+	}
+}
+
+// unpad removes the PKCS #7 padding of a decrypted key
+func unpad(b []byte) ([]byte, error) {
+	n := int(b[len(b)-1])
+	if n == 0 || n > aes.BlockSize || n > len(b) {
+		return nil, ErrIncorrectPassphrase
+	}
+
+	for _, c := range b[len(b)-n:] {
+		if int(c) != n {
+			return nil, ErrIncorrectPassphrase
+		}
+	}
+
+	return b[:len(b)-n], nil
+}
@@ -0,0 +1,262 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrMissingClaim is returned when a token lacks a claim a Policy requires
+	ErrMissingClaim = errors.New("missing required claim")
+	// ErrTokenTooOld is returned when the iat claim of a token is older than a Policy allows
+	ErrTokenTooOld = errors.New("token is too old")
+	// ErrClaimTooLarge is returned when a claim of a token is longer than a Policy allows
+	ErrClaimTooLarge = errors.New("claim is too large")
+)
+
+// A Policy describes the claims a Decoder requires of a token once its
+// signature is verified. The exp, nbf and iat claims are always checked when
+// present.
+type Policy struct {
+	// Issuer, if set, must equal the iss claim
+	Issuer string
+	// Issuers, if set, lists the trusted issuers, one of which must equal the
+	// iss claim
+	Issuers []string
+	// Audience, if set, must be a member of the aud claim
+	Audience string
+	// RequiredClaims names claims that must be present
+	RequiredClaims []string
+	// MaxClaimSizes limits the length in bytes of claims by name, e.g. to
+	// protect systems that store them in fixed size columns. Each member of
+	// an array of strings, such as aud, is limited individually; other claims
+	// that are not strings are limited by the length of their JSON.
+	MaxClaimSizes map[string]int
+	// MaxAge, if set, limits how long ago the iat claim may be. Tokens
+	// without an iat claim are rejected.
+	MaxAge time.Duration
+	// Leeway allows for clock skew when checking exp, nbf and iat
+	Leeway time.Duration
+	// StaleGrace, if set, accepts tokens that expired less than StaleGrace
+	// ago rather than rejecting them. Such tokens are marked Stale in the
+	// DecodeResult so that, e.g., read only endpoints can serve degraded
+	// responses while an identity provider is unavailable.
+	StaleGrace time.Duration
+	// DryRun reports violations of the rules of the policy without rejecting
+	// tokens so the effect of a stricter policy can be observed before it is
+	// enforced. Tokens that are expired, not yet valid or of another issuer
+	// or audience are rejected regardless.
+	DryRun bool
+	// AllViolations rejects tokens with a *ValidationError listing every
+	// violation rather than with the first one, e.g. for APIs that report
+	// every problem with a token back to their clients.
+	AllViolations bool
+	// Report, if set, is called with the violations found in each token
+	Report func(violations []error)
+}
+
+// A ValidationError lists every way the claims of a token break a Policy. It
+// matches each of its violations with errors.Is, e.g. ErrTokenExpired.
+type ValidationError struct {
+	Violations []error
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, err := range e.Violations {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap returns the violations.
+func (e *ValidationError) Unwrap() []error {
+	return e.Violations
+}
+
+// registeredClaims are the claims a Policy checks
+type registeredClaims struct {
+	Issuer         string       `json:"iss"`
+	Audience       Audience     `json:"aud"`
+	ExpirationTime *NumericDate `json:"exp"`
+	NotBefore      *NumericDate `json:"nbf"`
+	IssuedAt       *NumericDate `json:"iat"`
+}
+
+// Violations lists every way the claims of a given payload break the policy.
+func (p *Policy) Violations(payload []byte) []error {
+	violations, _ := p.violations(payload)
+	return violations
+}
+
+// violations lists the violations of a given payload and reports whether it
+// expired within the stale grace window.
+func (p *Policy) violations(payload []byte) ([]error, bool) {
+	var claims registeredClaims
+	var present map[string]json.RawMessage
+
+	// Claims are decoded like payloads, which may be followed by trailing data
+	if err := json.NewDecoder(bytes.NewReader(payload)).Decode(&present); err != nil {
+		return []error{ErrMalformedToken}, false
+	}
+
+	if err := json.NewDecoder(bytes.NewReader(payload)).Decode(&rfc3339Payload{v: &claims}); err != nil {
+		return []error{ErrMalformedToken}, false
+	}
+
+	var violations []error
+	var stale bool
+	now := timeFunc()
+
+	if p.Issuer != "" && claims.Issuer != p.Issuer {
+		violations = append(violations, ErrInvalidIssuer)
+	} else if len(p.Issuers) > 0 && !containsString(p.Issuers, claims.Issuer) {
+		violations = append(violations, ErrInvalidIssuer)
+	}
+
+	if p.Audience != "" && !claims.Audience.Contains(p.Audience) {
+		violations = append(violations, ErrInvalidAudience)
+	}
+
+	for _, name := range p.RequiredClaims {
+		if _, ok := present[name]; !ok {
+			violations = append(violations, fmt.Errorf("%w: %s", ErrMissingClaim, name))
+		}
+	}
+
+	names := make([]string, 0, len(p.MaxClaimSizes))
+	for name := range p.MaxClaimSizes {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if raw, ok := present[name]; ok && claimSize(raw) > p.MaxClaimSizes[name] {
+			violations = append(violations, fmt.Errorf("%w: %s", ErrClaimTooLarge, name))
+		}
+	}
+
+	if claims.ExpirationTime != nil && !now.Before(claims.ExpirationTime.Add(p.Leeway)) {
+		if now.Before(claims.ExpirationTime.Add(p.Leeway + p.StaleGrace)) {
+			stale = true
+		} else {
+			violations = append(violations, ErrTokenExpired)
+		}
+	}
+
+	if claims.NotBefore != nil && now.Add(p.Leeway).Before(claims.NotBefore.Time) {
+		violations = append(violations, ErrTokenNotYetValid)
+	}
+
+	if claims.IssuedAt != nil && now.Add(p.Leeway).Before(claims.IssuedAt.Time) {
+		violations = append(violations, ErrTokenUsedBeforeIssued)
+	}
+
+	if p.MaxAge > 0 {
+		if claims.IssuedAt == nil {
+			violations = append(violations, fmt.Errorf("%w: %s", ErrMissingClaim, "iat"))
+		} else if claims.IssuedAt.Add(p.MaxAge + p.Leeway).Before(now) {
+			violations = append(violations, ErrTokenTooOld)
+		}
+	}
+
+	return violations, stale
+}
+
+// claimSize returns the length a claim is limited by: the length of a string,
+// the length of the longest member of an array of strings or otherwise the
+// length of its JSON.
+func claimSize(raw json.RawMessage) int {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return len(s)
+	}
+
+	var members []string
+	if json.Unmarshal(raw, &members) == nil {
+		size := 0
+
+		for _, m := range members {
+			if len(m) > size {
+				size = len(m)
+			}
+		}
+
+		return size
+	}
+
+	return len(raw)
+}
+
+// enforcedViolations are rejected even when a policy is a dry run
+var enforcedViolations = []error{
+	ErrMalformedToken,
+	ErrInvalidIssuer,
+	ErrInvalidAudience,
+	ErrTokenExpired,
+	ErrTokenNotYetValid,
+	ErrTokenUsedBeforeIssued,
+}
+
+// check reports the violations of a given payload and returns the first one.
+// A dry run only returns the violations of enforcedViolations.
+func (p *Policy) check(payload []byte) ([]error, bool, error) {
+	violations, stale := p.violations(payload)
+
+	if len(violations) > 0 && p.Report != nil {
+		p.Report(violations)
+	}
+
+	rejected := violations
+	if p.DryRun {
+		rejected = nil
+
+		for _, violation := range violations {
+			if isEnforced(violation) {
+				rejected = append(rejected, violation)
+			}
+		}
+	}
+
+	if len(rejected) > 0 {
+		if p.AllViolations {
+			return violations, stale, &ValidationError{Violations: rejected}
+		}
+
+		return violations, stale, rejected[0]
+	}
+
+	return violations, stale, nil
+}
+
+// isEnforced reports whether a violation is one of enforcedViolations
+func isEnforced(violation error) bool {
+	for _, err := range enforcedViolations {
+		if errors.Is(violation, err) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,236 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPolicyViolations(t *testing.T) {
+	defer func() { timeFunc = time.Now }()
+
+	now := time.Unix(1700000000, 0)
+	timeFunc = func() time.Time { return now }
+
+	policy := &Policy{
+		Issuer:         "https://issuer.example",
+		Audience:       "api",
+		RequiredClaims: []string{"sub"},
+		MaxAge:         time.Hour,
+		Leeway:         time.Minute,
+	}
+
+	cases := []struct {
+		Payload  string
+		Expected []error
+		Reason   string
+	}{
+		{`{"iss":"https://issuer.example","aud":["web","api"],"sub":"a","iat":1699999000,"exp":1700000030}`, nil, "a conforming token should have no violations"},
+		{`{"iss":"https://issuer.example","aud":"api","sub":"a","iat":"2023-11-14T22:00:00Z"}`, nil, "an RFC 3339 iat should be understood"},
+		{`{"iss":"https://other.example","aud":"web","iat":1699999000}`, []error{ErrInvalidIssuer, ErrInvalidAudience, ErrMissingClaim}, "every violation should be reported"},
+		{`{"iss":"https://issuer.example","aud":"api","sub":"a","iat":1699999000,"exp":1699999900}`, []error{ErrTokenExpired}, "an expired token should be reported"},
+		{`{"iss":"https://issuer.example","aud":"api","sub":"a","iat":1699999000,"nbf":1700000100}`, []error{ErrTokenNotYetValid}, "a token used before nbf should be reported"},
+		{`{"iss":"https://issuer.example","aud":"api","sub":"a","iat":1699990000}`, []error{ErrTokenTooOld}, "a token older than MaxAge should be reported"},
+		{`{"iss":"https://issuer.example","aud":"api","sub":"a"}`, []error{ErrMissingClaim}, "a token without iat should be reported when MaxAge is set"},
+	}
+
+	for _, c := range cases {
+		violations := policy.Violations([]byte(c.Payload))
+
+		if len(violations) != len(c.Expected) {
+			t.Errorf("Expected %v; %s: got %v", c.Expected, c.Reason, violations)
+			continue
+		}
+
+		for i, err := range violations {
+			if !errors.Is(err, c.Expected[i]) {
+				t.Errorf("Expected %v; %s: got %v", c.Expected, c.Reason, violations)
+			}
+		}
+	}
+}
+
+func TestDecodePolicyDryRun(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	token := signTestToken(t, v, Header{Type: "JWT"}, &Payload{Issuer: "https://issuer.example"})
+
+	var reported []error
+	policy := &Policy{
+		RequiredClaims: []string{"sub"},
+		Report:         func(violations []error) { reported = violations },
+	}
+
+	dec := NewDecoder(bytes.NewBufferString(token), v)
+	dec.Policy = policy
+
+	if err := dec.Decode(&Payload{}); !errors.Is(err, ErrMissingClaim) {
+		t.Errorf("Expected an enforced policy to return %s; got %v", ErrMissingClaim, err)
+	}
+
+	if len(reported) != 1 {
+		t.Errorf("Expected an enforced policy to report its violations; got %v", reported)
+	}
+
+	policy.DryRun = true
+	reported = nil
+
+	dec = NewDecoder(bytes.NewBufferString(token), v)
+	dec.Policy = policy
+
+	result, err := dec.DecodeResult(&Payload{})
+	if err != nil {
+		t.Fatalf("Didn't expect a dry run policy to return an error: %s", err)
+	}
+
+	if len(result.Violations) != 1 || !errors.Is(result.Violations[0], ErrMissingClaim) || len(reported) != 1 {
+		t.Errorf("Expected a dry run to report %s; got %v and %v", ErrMissingClaim, result.Violations, reported)
+	}
+}
+
+func TestDecodePolicyDryRunEnforced(t *testing.T) {
+	defer func() { timeFunc = time.Now }()
+
+	now := time.Unix(1700000000, 0)
+	timeFunc = func() time.Time { return now }
+
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	expired := NewNumericDate(now.Add(-time.Hour))
+
+	cases := []struct {
+		ExpectedError error
+		Reason        string
+		Payload       *Payload
+	}{
+		{ErrTokenExpired, "the token is expired", &Payload{Issuer: "https://issuer.example", Audience: Audience{"api"}, ExpirationTime: expired}},
+		{ErrInvalidIssuer, "the token is of another issuer", &Payload{Issuer: "https://other.example", Audience: Audience{"api"}, ExpirationTime: expired}},
+		{ErrInvalidAudience, "the token is meant for another audience", &Payload{Issuer: "https://issuer.example", Audience: Audience{"web"}}},
+		{nil, "the token only breaks the rules of the policy", &Payload{Issuer: "https://issuer.example", Audience: Audience{"api"}}},
+	}
+
+	for _, c := range cases {
+		dec := NewDecoder(nil, v)
+		dec.Issuers = []string{"https://issuer.example"}
+		dec.Audience = "api"
+		dec.Policy = &Policy{RequiredClaims: []string{"sub"}, DryRun: true}
+
+		if err := dec.Verify(signTestToken(t, v, Header{Type: "JWT"}, c.Payload), &Payload{}); err != c.ExpectedError {
+			t.Errorf("Expected %v under a dry run when %s; got %v", c.ExpectedError, c.Reason, err)
+		}
+	}
+}
+
+func TestDecodePolicyAllViolations(t *testing.T) {
+	defer func() { timeFunc = time.Now }()
+
+	now := time.Unix(1700000000, 0)
+	timeFunc = func() time.Time { return now }
+
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	token := signTestToken(t, v, Header{Type: "JWT"}, &Payload{Audience: Audience{"web"}, ExpirationTime: NewNumericDate(now.Add(-time.Hour))})
+
+	dec := NewDecoder(nil, v)
+	dec.Policy = &Policy{Audience: "api", AllViolations: true}
+
+	err := dec.Verify(token, &Payload{})
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Violations) != 2 {
+		t.Fatalf("Expected a ValidationError listing both violations; got %v", err)
+	}
+
+	if !errors.Is(err, ErrInvalidAudience) || !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("Expected the ValidationError to match %s and %s; got %v", ErrInvalidAudience, ErrTokenExpired, err)
+	}
+
+	if message := err.Error(); message != ErrInvalidAudience.Error()+"; "+ErrTokenExpired.Error() {
+		t.Errorf("Expected the message to list both violations; got %q", message)
+	}
+
+	dec.Policy.AllViolations = false
+
+	if err := dec.Verify(token, &Payload{}); err != ErrInvalidAudience {
+		t.Errorf("Expected the first violation without AllViolations; got %v", err)
+	}
+}
+
+func TestDecodePolicyStaleGrace(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	policy := &Policy{StaleGrace: 10 * time.Minute}
+
+	decode := func(expiry time.Duration) (*DecodeResult, error) {
+		token := signTestToken(t, v, Header{Type: "JWT"}, &Payload{ExpirationTime: NewNumericDate(time.Now().Add(expiry))})
+
+		dec := NewDecoder(bytes.NewBufferString(token), v)
+		dec.Policy = policy
+
+		return dec.DecodeResult(&Payload{})
+	}
+
+	if result, err := decode(time.Minute); err != nil || result.Stale {
+		t.Errorf("Expected an unexpired token to be fresh; got %#v and %v", result, err)
+	}
+
+	if result, err := decode(-5 * time.Minute); err != nil || !result.Stale {
+		t.Errorf("Expected a token expired within the grace window to be stale; got %#v and %v", result, err)
+	}
+
+	if _, err := decode(-15 * time.Minute); err != ErrTokenExpired {
+		t.Errorf("Expected a token expired beyond the grace window to return %s; got %v", ErrTokenExpired, err)
+	}
+}
+
+func TestPolicyMaxClaimSizes(t *testing.T) {
+	policy := &Policy{MaxClaimSizes: map[string]int{"sub": 8, "aud": 4, "roles": 16, "name": 4}}
+
+	cases := []struct {
+		Payload  string
+		Expected []string
+		Reason   string
+	}{
+		{`{"sub":"12345678","aud":["api","web"],"roles":["a","b"]}`, nil, "claims within their sizes should have no violations"},
+		{`{"sub":"123456789"}`, []string{"sub"}, "a string longer than its size should be reported"},
+		{`{"sub":"é1234567"}`, []string{"sub"}, "sizes should be measured in bytes"},
+		{`{"aud":"web"}`, nil, "a single aud should be limited like a member"},
+		{`{"aud":["api","admin"]}`, []string{"aud"}, "every member of an array should be limited"},
+		{`{"roles":{"admin":true,"auditor":true}}`, []string{"roles"}, "a claim that is not a string should be limited by its JSON"},
+		{`{"sub":"123456789","aud":"admin"}`, []string{"aud", "sub"}, "violations should be reported in the order of the claim names"},
+	}
+
+	for _, c := range cases {
+		violations := policy.Violations([]byte(c.Payload))
+
+		if len(violations) != len(c.Expected) {
+			t.Errorf("Expected %v; %s: got %v", c.Expected, c.Reason, violations)
+			continue
+		}
+
+		for i, err := range violations {
+			if !errors.Is(err, ErrClaimTooLarge) || err.Error() != ErrClaimTooLarge.Error()+": "+c.Expected[i] {
+				t.Errorf("Expected %v; %s: got %v", c.Expected, c.Reason, violations)
+			}
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"time"
+)
+
+// ProofType is the typ header of a proof of possession
+const ProofType = "pop+jwt"
+
+var (
+	// ErrKeyMismatch is returned when a proof is signed by a key other than the
+	// one a token was bound to
+	ErrKeyMismatch = errors.New("proof key does not match the token confirmation")
+	// ErrInvalidProof is returned when a proof was not made for the request it
+	// accompanies or has been used before
+	ErrInvalidProof = errors.New("invalid proof of possession")
+)
+
+// ProofClaims are the claims of a proof of possession. A proof is signed by the
+// ephemeral key of a client for each request it makes with a bound token.
+type ProofClaims struct {
+	Method    string `json:"htm"`
+	URL       string `json:"htu"`
+	IssuedAt  int64  `json:"iat"`
+	ID        string `json:"jti"`
+	TokenHash string `json:"ath"`
+}
+
+// A ProofSigner signs proofs of possession with an ephemeral key generated by
+// a client. The server binds tokens to the key with NewKeyConfirmation.
+type ProofSigner struct {
+	validator Validator
+	// JWK is the public half of the ephemeral key
+	JWK *JSONWebKey
+}
+
+// A ProofVerifier verifies the proofs of possession accompanying requests made
+// with bound tokens.
+type ProofVerifier struct {
+	// MaxAge is how far the iat claim of a proof may be from the current time
+	MaxAge time.Duration
+	// Seen, if set, reports whether a proof id has been used before so that
+	// proofs cannot be replayed.
+	Seen func(id string) bool
+}
+
+// NewProofSigner constructs a ProofSigner for the ephemeral key whose public
+// half is given. The validator must hold the matching private key.
+func NewProofSigner(v Validator, key crypto.PublicKey) (*ProofSigner, error) {
+	jwk, err := NewJSONWebKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProofSigner{validator: v, JWK: jwk.Public()}, nil
+}
+
+// Sign returns a proof of possession for a request with a given method and url
+// made with a given token.
+func (s *ProofSigner) Sign(method, url, token string) (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	jwt := &jwt{
+		Header: &Header{Type: ProofType, JWK: s.JWK},
+		Payload: &ProofClaims{
+			Method:    method,
+			URL:       url,
+			IssuedAt:  timeFunc().Unix(),
+			ID:        encodeSegment(id),
+			TokenHash: tokenHash(token),
+		},
+	}
+
+	if err := s.validator.sign(jwt); err != nil {
+		return "", err
+	}
+
+	return jwt.token(), nil
+}
+
+// NewProofVerifier constructs a ProofVerifier accepting proofs up to a given
+// age.
+func NewProofVerifier(maxAge time.Duration) *ProofVerifier {
+	return &ProofVerifier{MaxAge: maxAge}
+}
+
+// Verify asserts a proof was signed by the key a token is bound to for a
+// request with a given method and url made with the token.
+func (v *ProofVerifier) Verify(proof string, cnf *Confirmation, method, url, token string) (*ProofClaims, error) {
+	claims := &ProofClaims{}
+
+	jwt, err := parseJWT(proof, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if jwt.Header.Type != ProofType || jwt.Header.JWK == nil {
+		return nil, ErrInvalidProof
+	}
+
+	if cnf == nil || cnf.JWKThumbprint == "" || subtle.ConstantTimeCompare([]byte(jwt.Header.JWK.Thumbprint()), []byte(cnf.JWKThumbprint)) != 1 {
+		return nil, ErrKeyMismatch
+	}
+
+	key, err := jwt.Header.JWK.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+
+	validator, err := validatorFor(jwt.Header.Algorithm, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if valid, err := validator.validate(jwt); !valid || err != nil {
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, ErrBadSignature
+	}
+
+	if claims.Method != method || claims.URL != url || claims.TokenHash != tokenHash(token) {
+		return nil, ErrInvalidProof
+	}
+
+	issued := time.Unix(claims.IssuedAt, 0)
+	now := timeFunc()
+
+	if issued.Before(now.Add(-v.MaxAge)) {
+		return nil, ErrTokenExpired
+	}
+
+	if issued.After(now.Add(v.MaxAge)) {
+		return nil, ErrTokenNotYetValid
+	}
+
+	if v.Seen != nil && v.Seen(claims.ID) {
+		return nil, ErrInvalidProof
+	}
+
+	return claims, nil
+}
+
+// tokenHash returns the ath claim of a proof for a given token
+func tokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return encodeSegment(sum[:])
+}
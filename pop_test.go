@@ -0,0 +1,145 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+	"time"
+)
+
+// ephemeralSigner generates an ES256 key pair and a ProofSigner holding it
+func ephemeralSigner(t *testing.T) *ProofSigner {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Recieved error when generating test key: %s", err)
+	}
+
+	v, _ := NewESValidator(ES256)
+	v.PrivateKey = key
+
+	signer, err := NewProofSigner(v, &key.PublicKey)
+	if err != nil {
+		t.Fatalf("Didn't expect constructing a proof signer to return an error: %s", err)
+	}
+
+	return signer
+}
+
+func TestJSONWebKeyThumbprint(t *testing.T) {
+	// The example key of RFC 7638 section 3.1
+	jwk := &JSONWebKey{
+		KeyType:  "RSA",
+		Exponent: "AQAB",
+		Modulus:  "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+	}
+
+	if tp := jwk.Thumbprint(); tp != "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs" {
+		t.Errorf("Expected the RFC 7638 thumbprint; got %s", tp)
+	}
+
+	jwk.KeyID, jwk.Use = "2011-04-29", "sig"
+	if tp := jwk.Thumbprint(); tp != "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs" {
+		t.Errorf("Expected optional members to be left out of the thumbprint; got %s", tp)
+	}
+
+	signer := ephemeralSigner(t)
+
+	key, err := signer.JWK.PublicKey()
+	if err != nil {
+		t.Fatalf("Didn't expect reading a JWK to return an error: %s", err)
+	}
+
+	if roundtrip, _ := NewJSONWebKey(key); *roundtrip != *signer.JWK {
+		t.Errorf("Expected a JWK to describe the key it was read from; got %#v", roundtrip)
+	}
+}
+
+func TestProofOfPossession(t *testing.T) {
+	client := ephemeralSigner(t)
+
+	// The server issues a token bound to the ephemeral key of the client
+	server := NewHSValidator(HS256)
+	server.Key = []byte("bogokey")
+
+	claims := &struct {
+		Payload
+		Confirmation *Confirmation `json:"cnf"`
+	}{Confirmation: NewKeyConfirmation(client.JWK)}
+
+	token := signTestToken(t, server, Header{Type: "JWT"}, claims)
+
+	issued := &struct {
+		Payload
+		Confirmation *Confirmation `json:"cnf"`
+	}{}
+
+	if err := NewDecoder(bytes.NewBufferString(token), server).Decode(issued); err != nil {
+		t.Fatalf("Didn't expect decoding a bound token to return an error: %s", err)
+	}
+
+	proof, err := client.Sign("GET", "https://api.example/resource", token)
+	if err != nil {
+		t.Fatalf("Didn't expect signing a proof to return an error: %s", err)
+	}
+
+	seen := map[string]bool{}
+	verifier := NewProofVerifier(time.Minute)
+	verifier.Seen = func(id string) bool {
+		defer func() { seen[id] = true }()
+		return seen[id]
+	}
+
+	if _, err := verifier.Verify(proof, issued.Confirmation, "GET", "https://api.example/resource", token); err != nil {
+		t.Errorf("Expected a proof by the bound key to be valid; got %s", err)
+	}
+
+	if _, err := verifier.Verify(proof, issued.Confirmation, "GET", "https://api.example/resource", token); err != ErrInvalidProof {
+		t.Errorf("Expected a replayed proof to return %s; got %v", ErrInvalidProof, err)
+	}
+
+	verifier.Seen = nil
+	other, _ := ephemeralSigner(t).Sign("GET", "https://api.example/resource", token)
+
+	cases := []struct {
+		Proof         string
+		Method        string
+		URL           string
+		Token         string
+		ExpectedError error
+		Reason        string
+	}{
+		{other, "GET", "https://api.example/resource", token, ErrKeyMismatch, "a proof by another key should be rejected"},
+		{proof, "POST", "https://api.example/resource", token, ErrInvalidProof, "a proof for another method should be rejected"},
+		{proof, "GET", "https://api.example/other", token, ErrInvalidProof, "a proof for another url should be rejected"},
+		{proof, "GET", "https://api.example/resource", "other", ErrInvalidProof, "a proof for another token should be rejected"},
+	}
+
+	for _, c := range cases {
+		if _, err := verifier.Verify(c.Proof, issued.Confirmation, c.Method, c.URL, c.Token); err != c.ExpectedError {
+			t.Errorf("Expected %v; %s: got %v", c.ExpectedError, c.Reason, err)
+		}
+	}
+
+	defer func() { timeFunc = time.Now }()
+	timeFunc = func() time.Time { return time.Now().Add(2 * time.Minute) }
+
+	if _, err := verifier.Verify(proof, issued.Confirmation, "GET", "https://api.example/resource", token); err != ErrTokenExpired {
+		t.Errorf("Expected a stale proof to return %s; got %v", ErrTokenExpired, err)
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// DefaultQRMaxSize is the capacity in characters of the largest QR code in
+// alphanumeric mode with medium error correction
+const DefaultQRMaxSize = 3391
+
+// A QRProfile encodes tokens compactly enough to be carried by QR codes, e.g.
+// tickets that are verified offline. Tokens are signed with ES256, the most
+// compact algorithm this package implements, with no typ header, their claim
+// names shortened and armored with ArmorBase32. EdDSA is not supported as the
+// package has no Ed25519 validator.
+type QRProfile struct {
+	// MaxSize is the most characters an encoded token may have.
+	// DefaultQRMaxSize is used when it is zero.
+	MaxSize int
+	// ClaimNames maps the names of claims to the shorter names they are
+	// encoded with, e.g. "ticket_id" to "t". Registered claims such as exp
+	// should not be shortened so that they are still checked when decoding.
+	ClaimNames map[string]string
+
+	validator Validator
+}
+
+// NewQRProfile constructs a QRProfile signing and verifying with a given
+// validator, which must be an ES256 ESValidator.
+func NewQRProfile(v Validator) (*QRProfile, error) {
+	var algorithm Algorithm
+
+	switch es := v.(type) {
+	case ESValidator:
+		algorithm = es.algorithm
+	case *ESValidator:
+		algorithm = es.algorithm
+	}
+
+	if algorithm != ES256 {
+		return nil, ErrAlgorithmNotImplemented
+	}
+
+	return &QRProfile{validator: v}, nil
+}
+
+// EncodeForQR returns a token with a given payload encoded for a QR code.
+// ErrPayloadTooLarge is returned when it is larger than MaxSize.
+func (p *QRProfile) EncodeForQR(v interface{}) (string, error) {
+	enc := NewEncoder(nil, p.validator)
+	enc.Redact = p.shorten
+	enc.Armor = ArmorBase32
+
+	token, err := enc.SignHeader(Header{}, v)
+	if err != nil {
+		return "", err
+	}
+
+	if len(token) > p.maxSize() {
+		return "", ErrPayloadTooLarge
+	}
+
+	return string(token), nil
+}
+
+// DecodeFromQR verifies a token read from a QR code and decodes its payload
+// into v with the claim names restored.
+func (p *QRProfile) DecodeFromQR(token string, v interface{}) error {
+	if len(token) > p.maxSize() {
+		return ErrPayloadTooLarge
+	}
+
+	dec := NewDecoder(nil, p.validator)
+	dec.AcceptArmor = true
+
+	return dec.Verify(token, &expandedPayload{v: v, names: p.ClaimNames})
+}
+
+// maxSize returns the most characters an encoded token may have
+func (p *QRProfile) maxSize() int {
+	if p.MaxSize == 0 {
+		return DefaultQRMaxSize
+	}
+
+	return p.MaxSize
+}
+
+// shorten renames claims to their short names
+func (p *QRProfile) shorten(claims RawClaims) error {
+	for name, short := range p.ClaimNames {
+		raw, ok := claims[name]
+		if !ok {
+			continue
+		}
+
+		if _, taken := claims[short]; taken {
+			return fmt.Errorf("%w: %s is already a claim", ErrMalformedToken, short)
+		}
+
+		delete(claims, name)
+		claims[short] = raw
+	}
+
+	return nil
+}
+
+// An expandedPayload restores the names of claims shortened by a QRProfile
+// before decoding into v.
+type expandedPayload struct {
+	v     interface{}
+	names map[string]string
+}
+
+func (p *expandedPayload) UnmarshalJSON(b []byte) error {
+	var claims RawClaims
+	if err := json.Unmarshal(b, &claims); err != nil {
+		return err
+	}
+
+	for name, short := range p.names {
+		if raw, ok := claims[short]; ok {
+			delete(claims, short)
+			claims[name] = raw
+		}
+	}
+
+	b, err := json.Marshal(claims)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, p.v)
+}
@@ -0,0 +1,72 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+)
+
+func TestQRProfile(t *testing.T) {
+	v, _ := NewESValidator(ES256)
+	v.PrivateKey, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	v.PublicKey = &v.PrivateKey.PublicKey
+
+	profile, err := NewQRProfile(v)
+	if err != nil {
+		t.Fatalf("Didn't expect an ES256 profile to return an error: %s", err)
+	}
+
+	profile.ClaimNames = map[string]string{"ticket_id": "t", "seat": "s"}
+
+	type ticket struct {
+		TicketID string `json:"ticket_id"`
+		Seat     string `json:"seat"`
+		Event    string `json:"event"`
+	}
+
+	token, err := profile.EncodeForQR(&ticket{"0001", "12A", "concert"})
+	if err != nil {
+		t.Fatalf("Didn't expect encoding a ticket to return an error: %s", err)
+	}
+
+	var claims RawClaims
+	unarmored, _ := Unarmor(token)
+	parseJWT(unarmored, &claims)
+
+	if _, ok := claims["t"]; !ok || len(claims["ticket_id"]) != 0 {
+		t.Errorf("Expected ticket_id to be encoded as t; got %v", claims)
+	}
+
+	decoded := &ticket{}
+	if err := profile.DecodeFromQR(token, decoded); err != nil || *decoded != (ticket{"0001", "12A", "concert"}) {
+		t.Errorf("Expected the ticket with its claim names restored; got %#v and %v", decoded, err)
+	}
+
+	profile.MaxSize = len(token) - 1
+
+	if _, err := profile.EncodeForQR(&ticket{"0001", "12A", "concert"}); err != ErrPayloadTooLarge {
+		t.Errorf("Expected a token larger than MaxSize to return %s; got %v", ErrPayloadTooLarge, err)
+	}
+
+	if err := profile.DecodeFromQR(token, decoded); err != ErrPayloadTooLarge {
+		t.Errorf("Expected decoding a token larger than MaxSize to return %s; got %v", ErrPayloadTooLarge, err)
+	}
+
+	if _, err := NewQRProfile(testRSValidator(t)); err != ErrAlgorithmNotImplemented {
+		t.Errorf("Expected a profile with an RSA validator to return %s; got %v", ErrAlgorithmNotImplemented, err)
+	}
+}
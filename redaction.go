@@ -0,0 +1,44 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import "encoding/json"
+
+// DropClaims returns a redaction for Encoder.Redact that removes the claims
+// with the given names.
+func DropClaims(names ...string) func(claims RawClaims) error {
+	return func(claims RawClaims) error {
+		for _, name := range names {
+			delete(claims, name)
+		}
+
+		return nil
+	}
+}
+
+// redact applies a redaction to the claims of a payload, which must be a JSON
+// object.
+func redact(payload []byte, f func(claims RawClaims) error) ([]byte, error) {
+	var claims RawClaims
+
+	if err := json.Unmarshal(payload, &claims); err != nil || claims == nil {
+		return nil, ErrMalformedToken
+	}
+
+	if err := f(claims); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(claims)
+}
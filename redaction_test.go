@@ -0,0 +1,79 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+var ErrTestRedaction = errors.New("A fake redaction error")
+
+// personClaims are claims including personal data
+type personClaims struct {
+	Payload
+	Email string `json:"email,omitempty"`
+	Name  string `json:"name,omitempty"`
+	Org   string `json:"org,omitempty"`
+}
+
+func TestRedact(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	enc := NewEncoder(nil, v)
+	enc.Redact = DropClaims("email", "name")
+
+	claims := &personClaims{Email: "jane@example.com", Name: "Jane", Org: "example"}
+	claims.Subject = "1234567890"
+
+	for _, payload := range []interface{}{claims, json.RawMessage(`{"sub":"1234567890","email":"jane@example.com","name":"Jane","org":"example"}`)} {
+		token, err := enc.Sign(payload)
+		if err != nil {
+			t.Fatalf("Didn't expect signing a redacted payload to return an error: %s", err)
+		}
+
+		decoded := map[string]interface{}{}
+		if err := NewDecoder(nil, v).Verify(string(token), &decoded); err != nil {
+			t.Fatalf("Didn't expect verifying a redacted token to return an error: %s", err)
+		}
+
+		if _, ok := decoded["email"]; ok || decoded["name"] != nil || decoded["sub"] != "1234567890" || decoded["org"] != "example" {
+			t.Errorf("Expected only email and name to be removed; got %v", decoded)
+		}
+	}
+
+	enc.Redact = func(claims RawClaims) error {
+		claims["org"] = json.RawMessage(`"external"`)
+		return nil
+	}
+
+	token, _ := enc.Sign(claims)
+	payload, _ := parseField(strings.Split(string(token), ".")[1])
+
+	if !strings.Contains(string(payload), `"org":"external"`) {
+		t.Errorf("Expected the org claim to be rewritten; got %s", payload)
+	}
+
+	enc.Redact = func(claims RawClaims) error { return ErrTestRedaction }
+	if _, err := enc.Sign(claims); err != ErrTestRedaction {
+		t.Errorf("Expected a failing redaction to return its error; got %v", err)
+	}
+
+	if _, err := enc.Sign(json.RawMessage(`"not an object"`)); err != ErrMalformedToken {
+		t.Errorf("Expected a payload that is not an object to return %s; got %v", ErrMalformedToken, err)
+	}
+}
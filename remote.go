@@ -0,0 +1,238 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultKeySetTTL is how long a RemoteKeySet trusts the keys it fetched
+	DefaultKeySetTTL = time.Hour
+	// DefaultKeySetRefreshInterval is how often a RemoteKeySet may fetch its
+	// keys again because a token named an unknown kid
+	DefaultKeySetRefreshInterval = time.Minute
+	// DefaultFetchTimeout limits how long fetching a document from a provider
+	// may take when no Client is given
+	DefaultFetchTimeout = 10 * time.Second
+	// maxKeySetSize limits the size of a fetched key set document
+	maxKeySetSize = 1 << 20
+)
+
+// defaultClient fetches from providers when no Client is given. Unlike
+// http.DefaultClient it gives up on providers that do not respond.
+var defaultClient = &http.Client{Timeout: DefaultFetchTimeout}
+
+// A RemoteKeySet is a Validator that verifies tokens with the keys of a JSON
+// Web Key Set published at a URL, such as those of Google, Auth0 or Okta. Keys
+// are cached for TTL and fetched again early when a token names an unknown
+// kid, so key rotations are picked up without a restart. Key sets are fetched
+// without blocking the verification of tokens whose keys are cached.
+type RemoteKeySet struct {
+	// URL is the location of the key set
+	URL string
+	// Client fetches the key set. A client with a timeout of
+	// DefaultFetchTimeout is used when nil.
+	Client *http.Client
+	// TTL is how long fetched keys are trusted before being fetched again.
+	// Expired keys are used for up to another TTL while they cannot be
+	// fetched. DefaultKeySetTTL is used when zero.
+	TTL time.Duration
+	// RefreshInterval is the least time between fetches caused by unknown
+	// kids, so that tokens with made up kids cannot flood the provider.
+	// DefaultKeySetRefreshInterval is used when zero.
+	RefreshInterval time.Duration
+
+	mu        sync.Mutex
+	keys      map[string]keyStoreEntry
+	fetched   time.Time
+	attempted time.Time
+	err       error
+	inflight  *keySetFetch
+}
+
+// keySetFetch is a fetch of a key set in flight, shared by every caller
+// waiting for it
+type keySetFetch struct {
+	done chan struct{}
+	err  error
+}
+
+// NewRemoteKeySet constructs a RemoteKeySet for the key set at a given URL
+// using the default TTL and refresh interval.
+func NewRemoteKeySet(url string) *RemoteKeySet {
+	return &RemoteKeySet{URL: url, TTL: DefaultKeySetTTL, RefreshInterval: DefaultKeySetRefreshInterval}
+}
+
+// Key returns the key with a given kid, fetching the key set if the cached
+// keys have expired or do not include the kid.
+func (s *RemoteKeySet) Key(kid string) (*JSONWebKey, error) {
+	entry, err := s.entry(kid)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entry.jwk, nil
+}
+
+func (s *RemoteKeySet) entry(kid string) (keyStoreEntry, error) {
+	now := timeFunc()
+
+	s.mu.Lock()
+	entry, ok := s.keys[kid]
+	expires := s.fetched.Add(s.ttl())
+
+	var f *keySetFetch
+	if !ok || now.After(expires) {
+		f = s.startFetch(now)
+	}
+	s.mu.Unlock()
+
+	// Expired keys are used while the key set is fetched again and for up to
+	// another TTL when it cannot be fetched, so that a slow or unavailable
+	// provider does not reject every token but revoked keys are dropped
+	if ok && !now.After(expires.Add(s.ttl())) {
+		return entry, nil
+	}
+
+	if f == nil {
+		return keyStoreEntry{}, ErrUnknownKey
+	}
+
+	<-f.done
+	if f.err != nil {
+		return keyStoreEntry{}, f.err
+	}
+
+	s.mu.Lock()
+	entry, ok = s.keys[kid]
+	s.mu.Unlock()
+
+	if !ok {
+		return keyStoreEntry{}, ErrUnknownKey
+	}
+
+	return entry, nil
+}
+
+// startFetch starts fetching the key set unless a fetch is in flight already,
+// in which case that one is returned, or RefreshInterval has not passed since
+// the last one, in which case nil is returned. It must be called with s.mu
+// held.
+func (s *RemoteKeySet) startFetch(now time.Time) *keySetFetch {
+	if s.inflight != nil {
+		return s.inflight
+	}
+
+	if !s.attempted.IsZero() && now.Before(s.attempted.Add(s.refreshInterval())) {
+		return nil
+	}
+
+	s.attempted = now
+	s.inflight = &keySetFetch{done: make(chan struct{})}
+
+	go s.fetch(s.inflight, now)
+
+	return s.inflight
+}
+
+// ttl returns TTL, or DefaultKeySetTTL when it is not set
+func (s *RemoteKeySet) ttl() time.Duration {
+	if s.TTL <= 0 {
+		return DefaultKeySetTTL
+	}
+
+	return s.TTL
+}
+
+// refreshInterval returns RefreshInterval, or DefaultKeySetRefreshInterval
+// when it is not set
+func (s *RemoteKeySet) refreshInterval() time.Duration {
+	if s.RefreshInterval <= 0 {
+		return DefaultKeySetRefreshInterval
+	}
+
+	return s.RefreshInterval
+}
+
+// fetch replaces the cached keys with those currently published
+func (s *RemoteKeySet) fetch(f *keySetFetch, now time.Time) {
+	keys, err := s.fetchKeys()
+
+	s.mu.Lock()
+	if err == nil {
+		s.keys, s.fetched = keys, now
+	}
+
+	s.err, s.inflight = err, nil
+	s.mu.Unlock()
+
+	f.err = err
+	close(f.done)
+}
+
+// fetchKeys fetches the keys currently published
+func (s *RemoteKeySet) fetchKeys() (map[string]keyStoreEntry, error) {
+	client := s.Client
+	if client == nil {
+		client = defaultClient
+	}
+
+	resp, err := client.Get(s.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching key set from %s: %s", s.URL, resp.Status)
+	}
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxKeySetSize))
+	if err != nil {
+		return nil, err
+	}
+
+	set, err := ParseJWKSet(b)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]keyStoreEntry, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if key, err := jwk.PublicKey(); err == nil {
+			keys[jwk.KeyID] = keyStoreEntry{jwk: jwk, key: key}
+		}
+	}
+
+	return keys, nil
+}
+
+func (s *RemoteKeySet) validate(jwt *jwt) (bool, error) {
+	entry, err := s.entry(jwt.Header.KeyID)
+	if err != nil {
+		return false, err
+	}
+
+	return entry.validate(jwt)
+}
+
+func (s *RemoteKeySet) sign(jwt *jwt) error {
+	return ErrVerifyOnly
+}
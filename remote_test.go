@@ -0,0 +1,235 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRemoteKeySet(t *testing.T) {
+	defer func() { timeFunc = time.Now }()
+
+	now := time.Unix(1700000000, 0)
+	timeFunc = func() time.Time { return now }
+
+	signer := testRSValidator(t)
+	jwk, _ := NewJSONWebKey(signer.PublicKey)
+
+	var mu sync.Mutex
+	fetches := 0
+	published := &JWKSet{}
+	healthy := true
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		fetches++
+		if !healthy {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		json.NewEncoder(w).Encode(published)
+	}))
+	defer server.Close()
+
+	publish := func(kids ...string) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		published.Keys = nil
+		for _, kid := range kids {
+			k := *jwk
+			k.KeyID = kid
+			published.Keys = append(published.Keys, k)
+		}
+	}
+
+	keys := NewRemoteKeySet(server.URL)
+	keys.Client = server.Client()
+
+	decode := func(kid string) error {
+		token := signTestToken(t, signer, Header{Type: "JWT", KeyID: kid}, &Payload{})
+		return NewDecoder(bytes.NewBufferString(token), keys).Decode(&Payload{})
+	}
+
+	publish("k1")
+
+	if err := decode("k1"); err != nil || fetches != 1 {
+		t.Errorf("Expected the first token to fetch the key set once; got %v after %d fetches", err, fetches)
+	}
+
+	if err := decode("k1"); err != nil || fetches != 1 {
+		t.Errorf("Expected cached keys to be used; got %v after %d fetches", err, fetches)
+	}
+
+	publish("k1", "k2")
+
+	if err := decode("k2"); err != ErrUnknownKey || fetches != 1 {
+		t.Errorf("Expected an unknown kid not to fetch within the refresh interval; got %v after %d fetches", err, fetches)
+	}
+
+	now = now.Add(2 * time.Minute)
+
+	if err := decode("k2"); err != nil || fetches != 2 {
+		t.Errorf("Expected an unknown kid to fetch the rotated keys; got %v after %d fetches", err, fetches)
+	}
+
+	if err := decode("k3"); err != ErrUnknownKey || fetches != 2 {
+		t.Errorf("Expected a made up kid not to fetch again; got %v after %d fetches", err, fetches)
+	}
+
+	mu.Lock()
+	healthy = false
+	mu.Unlock()
+	now = now.Add(90 * time.Minute)
+
+	if err := decode("k1"); err != nil {
+		t.Errorf("Expected expired keys to be used while the key set is fetched; got %v", err)
+	}
+
+	waitForFetch(keys)
+
+	if err := decode("k1"); err != nil || fetches != 3 {
+		t.Errorf("Expected expired keys to be used while the key set cannot be fetched; got %v after %d fetches", err, fetches)
+	}
+
+	now = now.Add(time.Hour)
+
+	if err := decode("k1"); err == nil || fetches != 4 {
+		t.Errorf("Expected keys expired for more than another TTL to be dropped; got %v after %d fetches", err, fetches)
+	}
+
+	mu.Lock()
+	healthy = true
+	mu.Unlock()
+	publish("k2")
+	now = now.Add(2 * time.Minute)
+
+	if err := decode("k1"); err != ErrUnknownKey || fetches != 5 {
+		t.Errorf("Expected a revoked key to be rejected once the key set is fetched; got %v after %d fetches", err, fetches)
+	}
+}
+
+func TestRemoteKeySetDefaults(t *testing.T) {
+	signer := testRSValidator(t)
+	jwk, _ := NewJSONWebKey(signer.PublicKey)
+	jwk.KeyID = "k1"
+
+	var fetches int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		json.NewEncoder(w).Encode(NewJWKSet(jwk))
+	}))
+	defer server.Close()
+
+	keys := &RemoteKeySet{URL: server.URL, Client: server.Client()}
+
+	for i := 0; i < 50; i++ {
+		if _, err := keys.Key("made-up"); err != ErrUnknownKey {
+			t.Fatalf("Expected %v for a made up kid; got %v", ErrUnknownKey, err)
+		}
+	}
+
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Errorf("Expected a RemoteKeySet without a TTL or RefreshInterval to use the defaults; got %d fetches", n)
+	}
+}
+
+func TestRemoteKeySetSlowProvider(t *testing.T) {
+	defer func() { timeFunc = time.Now }()
+
+	now := time.Unix(1700000000, 0)
+	timeFunc = func() time.Time { return now }
+
+	signer := testRSValidator(t)
+	jwk, _ := NewJSONWebKey(signer.PublicKey)
+	jwk.KeyID = "k1"
+
+	release := make(chan struct{})
+	arrived := make(chan struct{}, 4)
+	var fetches int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&fetches, 1) > 1 {
+			arrived <- struct{}{}
+			<-release
+		}
+
+		json.NewEncoder(w).Encode(NewJWKSet(jwk))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	keys := NewRemoteKeySet(server.URL)
+	keys.Client = server.Client()
+
+	token := signTestToken(t, signer, Header{Type: "JWT", KeyID: "k1"}, &Payload{})
+
+	if err := NewDecoder(nil, keys).Verify(token, &Payload{}); err != nil {
+		t.Fatalf("Didn't expect verifying a token to return an error: %s", err)
+	}
+
+	now = now.Add(2 * time.Hour)
+
+	// The provider hangs on the second fetch, which must not hold up tokens
+	// whose keys are cached, nor be repeated by each of them
+	done := make(chan error)
+	go func() {
+		for i := 0; i < 3; i++ {
+			if err := NewDecoder(nil, keys).Verify(token, &Payload{}); err != nil {
+				done <- err
+				return
+			}
+		}
+
+		done <- nil
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected expired keys to be used while the key set is fetched; got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected verification not to wait for a slow provider")
+	}
+
+	<-arrived
+
+	if n := atomic.LoadInt32(&fetches); n != 2 {
+		t.Errorf("Expected a single fetch in flight; got %d fetches", n)
+	}
+}
+
+// waitForFetch waits for the fetch of a key set in flight, if any
+func waitForFetch(s *RemoteKeySet) {
+	s.mu.Lock()
+	f := s.inflight
+	s.mu.Unlock()
+
+	if f != nil {
+		<-f.done
+	}
+}
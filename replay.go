@@ -0,0 +1,112 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrTokenReplayed is returned when a token whose jti was seen before is
+// presented again
+var ErrTokenReplayed = errors.New("token replayed")
+
+// replaySweepInterval is how often a MemoryReplayStore evicts expired ids
+const replaySweepInterval = time.Minute
+
+// A ReplayStore remembers the jti of the tokens a Decoder accepts.
+type ReplayStore interface {
+	// Seen reports whether a jti was seen before and records it otherwise.
+	// The jti must be remembered until at least exp, after which the token
+	// is rejected as expired. exp is zero for tokens without an exp claim.
+	Seen(jti string, exp time.Time) bool
+}
+
+// A MemoryReplayStore is a ReplayStore held in memory, which evicts ids once
+// their tokens expire. Ids of tokens without an exp claim are never evicted.
+// It is safe for concurrent use.
+type MemoryReplayStore struct {
+	mu    sync.Mutex
+	ids   map[string]time.Time
+	swept time.Time
+}
+
+// NewMemoryReplayStore constructs an empty MemoryReplayStore.
+func NewMemoryReplayStore() *MemoryReplayStore {
+	return &MemoryReplayStore{ids: map[string]time.Time{}}
+}
+
+// Seen implements ReplayStore.
+func (s *MemoryReplayStore) Seen(jti string, exp time.Time) bool {
+	now := timeFunc()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.swept) >= replaySweepInterval {
+		s.sweep(now)
+	}
+
+	if expires, ok := s.ids[jti]; ok && (expires.IsZero() || now.Before(expires)) {
+		return true
+	}
+
+	s.ids[jti] = exp
+
+	return false
+}
+
+// Len returns the number of ids in the store, including expired ids that have
+// not been evicted yet.
+func (s *MemoryReplayStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.ids)
+}
+
+// sweep evicts the ids of expired tokens
+func (s *MemoryReplayStore) sweep(now time.Time) {
+	for jti, expires := range s.ids {
+		if !expires.IsZero() && !now.Before(expires) {
+			delete(s.ids, jti)
+		}
+	}
+
+	s.swept = now
+}
+
+// checkReplay consults the ReplayStore of the Decoder with the jti of a token
+// that is otherwise accepted. The id is remembered for as long as the policy
+// accepts the token.
+func (dec *Decoder) checkReplay(jwt *jwt, policy *Policy) error {
+	claims := jwt.claimsPayload
+
+	if claims.JWTId == "" {
+		return fmt.Errorf("%w: %s", ErrMissingClaim, "jti")
+	}
+
+	var exp time.Time
+	if claims.ExpirationTime != nil {
+		exp = claims.ExpirationTime.Add(policy.Leeway + policy.StaleGrace)
+	}
+
+	if dec.ReplayStore.Seen(claims.JWTId, exp) {
+		return ErrTokenReplayed
+	}
+
+	return nil
+}
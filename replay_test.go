@@ -0,0 +1,67 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReplayStore(t *testing.T) {
+	now := time.Unix(1516239022, 0)
+	timeFunc = func() time.Time { return now }
+	defer func() { timeFunc = time.Now }()
+
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	store := NewMemoryReplayStore()
+	dec := NewDecoder(nil, v)
+	dec.ReplayStore = store
+	dec.Leeway = time.Minute
+
+	once := signTestToken(t, v, Header{Type: "JWT"}, &Payload{JWTId: "one-time", ExpirationTime: NewNumericDate(now.Add(time.Minute))})
+
+	if err := dec.Verify(once, &Payload{}); err != nil {
+		t.Fatalf("Didn't expect the first use of a token to return an error: %s", err)
+	}
+
+	if err := dec.Verify(once, &Payload{}); err != ErrTokenReplayed {
+		t.Errorf("Expected a replayed token to return %s; got %v", ErrTokenReplayed, err)
+	}
+
+	if err := dec.Verify(signTestToken(t, v, Header{Type: "JWT"}, &Payload{Subject: "1234567890"}), &Payload{}); !errors.Is(err, ErrMissingClaim) {
+		t.Errorf("Expected a token without a jti to return %s; got %v", ErrMissingClaim, err)
+	}
+
+	dec.Audience = "api"
+	rejected := signTestToken(t, v, Header{Type: "JWT"}, &Payload{JWTId: "rejected", Audience: Audience{"web"}})
+	dec.Verify(rejected, &Payload{})
+	dec.Audience = ""
+
+	if err := dec.Verify(rejected, &Payload{}); err != nil {
+		t.Errorf("Didn't expect a token rejected for another reason to be remembered; got %v", err)
+	}
+
+	now = now.Add(90 * time.Second)
+	if err := dec.Verify(once, &Payload{}); err != ErrTokenReplayed {
+		t.Errorf("Expected a token replayed within the leeway to return %s; got %v", ErrTokenReplayed, err)
+	}
+
+	now = now.Add(time.Minute)
+	if !store.Seen("rejected", time.Time{}) || store.Len() != 1 {
+		t.Errorf("Expected expired ids to be evicted and ids without an exp to be kept; got %d ids", store.Len())
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import "crypto/sha256"
+
+// VerificationReportType is the typ header of verification reports
+const VerificationReportType = "vr+jwt"
+
+// A VerificationCheck names a check a Decoder performs on a token
+type VerificationCheck string
+
+// Checks reported in a VerificationReport, in the order they are performed.
+// Checks that are not configured on a Decoder are not performed.
+const (
+	// CheckSignature verifies the signature of the token
+	CheckSignature VerificationCheck = "signature"
+	// CheckNonce asserts the NonceFunc accepts the nonce header
+	CheckNonce VerificationCheck = "nonce"
+	// CheckType asserts the typ header is one of the accepted Types
+	CheckType VerificationCheck = "type"
+	// CheckClaims asserts the claims satisfy the Policy, including exp, nbf
+	// and iat
+	CheckClaims VerificationCheck = "claims"
+	// CheckAttestation asserts the signing key is attested as required
+	CheckAttestation VerificationCheck = "attestation"
+	// CheckValidations runs the validations added with AddValidation
+	CheckValidations VerificationCheck = "validations"
+	// CheckReplay asserts the jti was not seen before
+	CheckReplay VerificationCheck = "replay"
+)
+
+// A CheckResult is the outcome of a check performed on a token.
+type CheckResult struct {
+	Check  VerificationCheck `json:"check"`
+	Passed bool              `json:"passed"`
+	// Code is the code of the error the check failed with
+	Code ErrorCode `json:"code,omitempty"`
+}
+
+// A VerificationReport records how a token was verified so that regulated
+// environments can archive evidence of access control being enforced. The
+// token itself is only identified by its fingerprint.
+type VerificationReport struct {
+	// Fingerprint is the base64url encoded SHA-256 digest of the token
+	Fingerprint string `json:"fpt"`
+	// Algorithm is the alg header of the token, if it could be parsed
+	Algorithm Algorithm `json:"alg,omitempty"`
+	// KeyID is the kid header of the token, if it has one
+	KeyID string `json:"kid,omitempty"`
+	// Checks lists the checks performed, stopping at the first failure
+	Checks []CheckResult `json:"checks"`
+	// Accepted reports whether the token was accepted
+	Accepted bool `json:"accepted"`
+	// Code is the code of the error the token was rejected with
+	Code ErrorCode `json:"code,omitempty"`
+	// VerifiedAt is when the token was verified
+	VerifiedAt *NumericDate `json:"iat"`
+}
+
+// A VerificationReporter signs a VerificationReport for each token verified
+// by the Decoders it is the Reporter of.
+type VerificationReporter struct {
+	encoder *Encoder
+	// Archive is called with each signed report, or with the error the report
+	// could not be signed with. It may be called from many goroutines.
+	Archive func(report SignedToken, err error)
+}
+
+// NewVerificationReporter constructs a VerificationReporter signing reports
+// with a given validator and passing them to archive.
+func NewVerificationReporter(v Validator, archive func(report SignedToken, err error)) *VerificationReporter {
+	return &VerificationReporter{encoder: NewEncoder(nil, v), Archive: archive}
+}
+
+// report signs and archives the report of a token
+func (r *VerificationReporter) report(token string, checks *verificationChecks, err error) {
+	sum := sha256.Sum256([]byte(token))
+
+	report := &VerificationReport{
+		Fingerprint: encodeSegment(sum[:]),
+		Checks:      checks.checks,
+		Accepted:    err == nil,
+		VerifiedAt:  NewNumericDate(timeFunc()),
+	}
+
+	if checks.header != nil {
+		report.Algorithm = checks.header.Algorithm
+		report.KeyID = checks.header.KeyID
+	}
+
+	if err != nil {
+		report.Code = CodeOf(err)
+	}
+
+	signed, err := r.encoder.SignHeader(Header{Type: VerificationReportType}, report)
+	r.Archive(signed, err)
+}
+
+// verificationChecks collects the checks performed on a token for its report
+type verificationChecks struct {
+	header *Header
+	checks []CheckResult
+}
+
+// observe records the header of the token. It does nothing on a nil receiver.
+func (c *verificationChecks) observe(h *Header) {
+	if c != nil {
+		c.header = h
+	}
+}
+
+// record records the outcome of a check and returns its error. It does
+// nothing on a nil receiver.
+func (c *verificationChecks) record(check VerificationCheck, err error) error {
+	if c == nil {
+		return err
+	}
+
+	result := CheckResult{Check: check, Passed: err == nil}
+	if err != nil {
+		result.Code = CodeOf(err)
+	}
+
+	c.checks = append(c.checks, result)
+
+	return err
+}
@@ -0,0 +1,96 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestVerificationReporter(t *testing.T) {
+	now := time.Unix(1516239022, 0)
+	timeFunc = func() time.Time { return now }
+	defer func() { timeFunc = time.Now }()
+
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	auditor := NewHSValidator(HS512)
+	auditor.Key = []byte("auditkey")
+
+	var archived []SignedToken
+	dec := NewDecoder(nil, v)
+	dec.Reporter = NewVerificationReporter(auditor, func(report SignedToken, err error) {
+		if err != nil {
+			t.Errorf("Didn't expect signing a report to return an error: %s", err)
+		}
+
+		archived = append(archived, report)
+	})
+	dec.ReplayStore = NewMemoryReplayStore()
+
+	token := signTestToken(t, v, Header{Type: "JWT", KeyID: "k1"}, &Payload{JWTId: "1", ExpirationTime: NewNumericDate(now.Add(time.Minute))})
+	forged := signTestToken(t, NewHSValidator(HS256), Header{Type: "JWT"}, &Payload{})
+
+	dec.Verify(token, &Payload{})
+	dec.Verify(token, &Payload{})
+	dec.Verify(forged, &Payload{})
+	dec.Verify("bogus", &Payload{})
+
+	expected := []VerificationReport{
+		{
+			Algorithm: HS256,
+			KeyID:     "k1",
+			Checks:    []CheckResult{{CheckSignature, true, ""}, {CheckClaims, true, ""}, {CheckReplay, true, ""}},
+			Accepted:  true,
+		},
+		{
+			Algorithm: HS256,
+			KeyID:     "k1",
+			Checks:    []CheckResult{{CheckSignature, true, ""}, {CheckClaims, true, ""}, {CheckReplay, false, CodeTokenReplayed}},
+			Code:      CodeTokenReplayed,
+		},
+		{
+			Algorithm: HS256,
+			Checks:    []CheckResult{{CheckSignature, false, CodeBadSignature}},
+			Code:      CodeBadSignature,
+		},
+		{
+			Code: CodeMalformedToken,
+		},
+	}
+
+	if len(archived) != len(expected) {
+		t.Fatalf("Expected a report for each token; got %d reports", len(archived))
+	}
+
+	for i, signed := range archived {
+		report := &VerificationReport{}
+		result, err := NewDecoder(nil, auditor).verify(string(signed), report)
+
+		if err != nil || result.Header.Type != VerificationReportType {
+			t.Fatalf("Expected a report signed by the auditor; got %v and %#v", err, result)
+		}
+
+		if report.Fingerprint == "" || report.VerifiedAt.Unix() != now.Unix() {
+			t.Errorf("Expected report %d to fingerprint the token when it was verified; got %#v", i, report)
+		}
+
+		report.Fingerprint, report.VerifiedAt = "", nil
+		if !reflect.DeepEqual(*report, expected[i]) {
+			t.Errorf("Expected report %d to be %#v; got %#v", i, expected[i], *report)
+		}
+	}
+}
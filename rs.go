@@ -18,9 +18,8 @@ import (
 	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
-	"encoding/base64"
+	"errors"
 	"io"
-	"strings"
 )
 
 // A RSValidator implments the validator interface and allows the singing and verification
@@ -57,10 +56,11 @@ func (v RSValidator) validate(jwt *jwt) (bool, error) {
 		return false, ErrBadSignature
 	}
 
-	jwt.Header.Algorithm = v.algorithm
-	jwt.rawEncode()
+	if jwt.Header.Algorithm != v.algorithm {
+		return false, ErrAlgorithmNotImplemented
+	}
 
-	signature, err := base64.URLEncoding.DecodeString(string(jwt.Signature))
+	signature, err := parseField(string(jwt.Signature))
 
 	if err != nil {
 		return false, err
@@ -79,7 +79,21 @@ func (v RSValidator) validate(jwt *jwt) (bool, error) {
 	return true, nil
 }
 
+// SetRandom sets the randomness source signatures are made with, e.g. a
+// CheckedRandom.
+func (v *RSValidator) SetRandom(r io.Reader) {
+	v.randReader = r
+}
+
 func (v RSValidator) sign(jwt *jwt) (err error) {
+	if v.PrivateKey == nil {
+		return errors.New("Cannot sign with a nil private key")
+	}
+
+	if err := checkRandom(v.randReader); err != nil {
+		return err
+	}
+
 	jwt.Header.Algorithm = v.algorithm
 	jwt.rawEncode()
 
@@ -87,8 +101,13 @@ func (v RSValidator) sign(jwt *jwt) (err error) {
 	hsh.Write([]byte(string(jwt.headerRaw) + "." + string(jwt.payloadRaw)))
 	hash := hsh.Sum(nil)
 
-	signature, _ := rsa.SignPKCS1v15(v.randReader, v.PrivateKey, v.hashType, hash)
-	jwt.Signature = []byte(strings.Trim(base64.URLEncoding.EncodeToString(signature), "="))
+	signature, err := rsa.SignPKCS1v15(v.randReader, v.PrivateKey, v.hashType, hash)
+
+	if err != nil {
+		return err
+	}
+
+	jwt.Signature = []byte(encodeSegment(signature))
 
 	return err
 }
@@ -20,6 +20,8 @@ import (
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
+	"math/big"
 	"testing"
 )
 
@@ -80,9 +82,9 @@ func TestRSValidate(t *testing.T) {
 	b64Signature := "e-mU_hjtyUkDZfe63d-WN2YlTXJkMdaR04sbORQQGKFtLYSvVVknU8rbhlGq4eWCCFnYgK9_vJ37DpIV-OBLZ1JoWvmdh1oIHJsY9PJLhw4fK6Hq20Vfde-AkCWQT3I4r93Ymc3J-sRUGrDeKLmnbWnPeC6TQS7f8vjLHnCcvOFNK7BmJadhRDfI3Wxh988KP71v9I6lSlN_zWXPbdlFljBQzF0bpyDgidCqr2EqeJpnBBeE_0Bs7J1d34N0jyEs6P5aMsoIlI07bl_zoEJ2aYWuUNR9qbyK1K-OpAGG7X7l4qLmPP1HdQmHO9JkchShLgj8soDgnZBaFAm1Us_nwA=="
 
 	jwt := &jwt{
-		Header: &header{
-			Algorithm:   RS256,
-			ContentType: "JWT",
+		Header: &Header{
+			Algorithm: RS256,
+			Type:      "JWT",
 		},
 		headerRaw: []byte(b64Header),
 		Payload: &Payload{
@@ -169,9 +171,9 @@ func TestRSSign(t *testing.T) {
 
 	b64Signature := "e-mU_hjtyUkDZfe63d-WN2YlTXJkMdaR04sbORQQGKFtLYSvVVknU8rbhlGq4eWCCFnYgK9_vJ37DpIV-OBLZ1JoWvmdh1oIHJsY9PJLhw4fK6Hq20Vfde-AkCWQT3I4r93Ymc3J-sRUGrDeKLmnbWnPeC6TQS7f8vjLHnCcvOFNK7BmJadhRDfI3Wxh988KP71v9I6lSlN_zWXPbdlFljBQzF0bpyDgidCqr2EqeJpnBBeE_0Bs7J1d34N0jyEs6P5aMsoIlI07bl_zoEJ2aYWuUNR9qbyK1K-OpAGG7X7l4qLmPP1HdQmHO9JkchShLgj8soDgnZBaFAm1Us_nwA"
 	jwt := &jwt{
-		Header: &header{
-			Algorithm:   RS256,
-			ContentType: "JWT",
+		Header: &Header{
+			Algorithm: RS256,
+			Type:      "JWT",
 		},
 		Payload: &Payload{
 			Subject: "1234567890",
@@ -204,3 +206,30 @@ func TestRSSign(t *testing.T) {
 		t.Errorf("An invalid key for hs256validator returned an unexpected value: %#v.", jwt.Signature)
 	}
 }
+
+func TestRSSignErrors(t *testing.T) {
+	RS512V, _ := NewRSValidator(RS512)
+
+	if err := RS512V.sign(&jwt{Header: &Header{}, Payload: &Payload{}}); err == nil {
+		t.Error("Expected signing without a private key to return an error")
+	}
+
+	// A 12 bit key cannot hold a SHA-512 digest
+	RS512V.PrivateKey = &rsa.PrivateKey{
+		PublicKey: rsa.PublicKey{N: big.NewInt(3233), E: 17},
+		D:         big.NewInt(2753),
+		Primes:    []*big.Int{big.NewInt(61), big.NewInt(53)},
+	}
+
+	token := &jwt{Header: &Header{}, Payload: &Payload{Subject: "1234567890"}}
+	err := RS512V.sign(token)
+
+	if err == nil || token.Signature != nil {
+		t.Errorf("Expected a key too small for the digest to fail without a signature; got %v and %q", err, token.Signature)
+	}
+
+	var encodeErr *EncodeError
+	if err := NewEncoder(bytes.NewBuffer(nil), RS512V).Encode(&Payload{}); !errors.As(err, &encodeErr) || encodeErr.Stage != EncodeStageSign {
+		t.Errorf("Expected an EncodeError from the sign stage when RSA signing fails; got %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import "strings"
+
+// SignAndEncrypt signs a payload with an Encoder and encrypts the signed token
+// with an Encrypter, so that the claims are both authenticated and
+// confidential. The result is a nested token read by DecryptAndVerify.
+func SignAndEncrypt(enc *Encoder, e *Encrypter, v interface{}) (string, error) {
+	signed, err := enc.Sign(v)
+	if err != nil {
+		return "", err
+	}
+
+	return e.Seal(signed)
+}
+
+// DecryptAndVerify decrypts a token made by SignAndEncrypt with a Decrypter
+// and verifies the signed token it carries with a Decoder, decoding its
+// payload into v. ErrMalformedToken is returned when the encrypted token does
+// not carry a signed token.
+func DecryptAndVerify(d *Decrypter, dec *Decoder, token string, v interface{}) error {
+	var signed SignedToken
+
+	header, err := d.Open(token, &signed)
+	if err != nil {
+		return err
+	}
+
+	if !strings.EqualFold(header.ContentType, NestedContentType) {
+		return ErrMalformedToken
+	}
+
+	return dec.Verify(string(signed), v)
+}
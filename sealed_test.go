@@ -0,0 +1,59 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import "testing"
+
+func TestSignAndEncrypt(t *testing.T) {
+	key := testRSValidator(t).PrivateKey
+
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	token, err := SignAndEncrypt(NewEncoder(nil, v), NewEncrypter(nil, &key.PublicKey), &Payload{Subject: "1234567890"})
+	if err != nil {
+		t.Fatalf("Didn't expect signing and encrypting a token to return an error: %s", err)
+	}
+
+	payload := &Payload{}
+	if err := DecryptAndVerify(NewDecrypter(nil, key), NewDecoder(nil, v), token, payload); err != nil || payload.Subject != "1234567890" {
+		t.Errorf("Expected the payload of the signed token; got %#v and %v", payload, err)
+	}
+
+	other := NewHSValidator(HS256)
+	other.Key = []byte("otherkey")
+
+	unsigned, _ := NewEncrypter(nil, &key.PublicKey).Seal(&Payload{Subject: "1234567890"})
+
+	cases := []struct {
+		ExpectedError error
+		Reason        string
+		Token         string
+		Validator     Validator
+	}{
+		{ErrBadSignature, "the signed token is from another key", token, other},
+		{ErrMalformedToken, "the encrypted token is not signed", unsigned, v},
+		{ErrMalformedToken, "the token is not encrypted", signTestToken(t, v, Header{Type: "JWT"}, &Payload{}), v},
+	}
+
+	for _, c := range cases {
+		if err := DecryptAndVerify(NewDecrypter(nil, key), NewDecoder(nil, c.Validator), c.Token, &Payload{}); err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		}
+	}
+
+	if _, err := SignAndEncrypt(NewEncoder(nil, nil), NewEncrypter(nil, &key.PublicKey), &Payload{}); err != ErrNoValidator {
+		t.Errorf("Expected signing without a validator to return %s; got %v", ErrNoValidator, err)
+	}
+}
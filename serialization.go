@@ -0,0 +1,212 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import "encoding/json"
+
+// A JSONToken is the general JSON serialization of RFC 7515, which carries
+// several signatures of one payload, each with its own protected header.
+type JSONToken struct {
+	Payload    string          `json:"payload"`
+	Signatures []JSONSignature `json:"signatures"`
+}
+
+// A JSONSignature is a signature of a JSONToken.
+type JSONSignature struct {
+	Protected string `json:"protected"`
+	Signature string `json:"signature"`
+}
+
+// ParseJSONToken parses the general JSON serialization of a token without
+// verifying it.
+func ParseJSONToken(b []byte) (*JSONToken, error) {
+	token := &JSONToken{}
+	if err := json.Unmarshal(b, token); err != nil || token.Payload == "" || len(token.Signatures) == 0 {
+		return nil, ErrMalformedToken
+	}
+
+	return token, nil
+}
+
+// Compact returns the compact serialization of the token with one of its
+// signatures.
+func (t *JSONToken) Compact(i int) SignedToken {
+	s := t.Signatures[i]
+	return SignedToken(s.Protected + "." + t.Payload + "." + s.Signature)
+}
+
+// SignJSON signs a payload with the validator of the Encoder and each of a
+// number of other validators, e.g. both the old and the new key during a key
+// migration. The token is the general JSON serialization of RFC 7515.
+func (enc *Encoder) SignJSON(v interface{}, others ...Validator) ([]byte, error) {
+	token, err := enc.signJSON(Header{Type: "JWT"}, v, append([]Validator{enc.validator}, others...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(token)
+}
+
+// VerifyJSON verifies a token in the general JSON serialization, e.g. one
+// made by SignJSON, with the Verifiers of the Decoder, or its validator when
+// there are none. A token is accepted when any verifier matches one of its
+// signatures, or only when every verifier matches a signature of its own if
+// RequireAllVerifiers is set. The payload is decoded into v once it passes
+// every other check of the Decoder.
+func (dec *Decoder) VerifyJSON(b []byte, v interface{}) error {
+	verifiers := dec.Verifiers
+	if len(verifiers) == 0 {
+		verifiers = []Validator{dec.verifier()}
+	}
+
+	return dec.verifyJSON(b, verifiers, dec.RequireAllVerifiers, v)
+}
+
+// verifyJSON verifies a token in the general JSON serialization with the
+// first of a number of verifiers matching one of its signatures, or only when
+// every verifier matches a signature of its own if all is set
+func (dec *Decoder) verifyJSON(b []byte, verifiers []Validator, all bool, v interface{}) error {
+	token, err := ParseJSONToken(b)
+	if err != nil {
+		return err
+	}
+
+	if all {
+		if len(token.Signatures) < len(verifiers) {
+			return ErrBadSignature
+		}
+
+		signatures := assignSignatures(token, verifiers)
+		if signatures == nil {
+			return ErrBadSignature
+		}
+
+		_, err = dec.verifyWith(verifiers[0], string(token.Compact(signatures[0])), v)
+
+		return err
+	}
+
+	for _, verifier := range verifiers {
+		for i := range token.Signatures {
+			if verifiesSignature(verifier, token.Compact(i)) {
+				_, err = dec.verifyWith(verifier, string(token.Compact(i)), v)
+
+				return err
+			}
+		}
+	}
+
+	return ErrBadSignature
+}
+
+// assignSignatures gives each of a number of verifiers a distinct signature of
+// a token that it matches, so that one signature cannot satisfy several
+// verifiers. It returns the index of the signature of each verifier, or nil
+// when there is no such assignment.
+func assignSignatures(token *JSONToken, verifiers []Validator) []int {
+	matches := make([][]bool, len(verifiers))
+	for i, verifier := range verifiers {
+		matches[i] = make([]bool, len(token.Signatures))
+		for j := range token.Signatures {
+			matches[i][j] = verifiesSignature(verifier, token.Compact(j))
+		}
+	}
+
+	// owners holds the verifier each signature is assigned to, which is moved
+	// to another of its signatures when a later verifier needs it
+	owners := make([]int, len(token.Signatures))
+	for j := range owners {
+		owners[j] = -1
+	}
+
+	var assign func(i int, seen []bool) bool
+	assign = func(i int, seen []bool) bool {
+		for j, ok := range matches[i] {
+			if !ok || seen[j] {
+				continue
+			}
+
+			seen[j] = true
+			if owners[j] < 0 || assign(owners[j], seen) {
+				owners[j] = i
+				return true
+			}
+		}
+
+		return false
+	}
+
+	for i := range verifiers {
+		if !assign(i, make([]bool, len(owners))) {
+			return nil
+		}
+	}
+
+	signatures := make([]int, len(verifiers))
+	for j, i := range owners {
+		if i >= 0 {
+			signatures[i] = j
+		}
+	}
+
+	return signatures
+}
+
+// signJSON signs a payload with each of a number of validators
+func (enc *Encoder) signJSON(h Header, v interface{}, validators ...Validator) (*JSONToken, error) {
+	unsigned, encErr := enc.prepare(h, v)
+	if encErr != nil {
+		return nil, encErr.Err
+	}
+
+	token := &JSONToken{}
+
+	for _, validator := range validators {
+		if validator == nil {
+			return nil, ErrNoValidator
+		}
+
+		header := *unsigned.Header
+		signed := &jwt{Header: &header, Payload: unsigned.Payload}
+
+		if err := validator.sign(signed); err != nil {
+			return nil, err
+		}
+
+		token.Payload = string(signed.payloadRaw)
+		token.Signatures = append(token.Signatures, JSONSignature{
+			Protected: string(signed.headerRaw),
+			Signature: string(signed.Signature),
+		})
+	}
+
+	return token, nil
+}
+
+// verifiesSignature reports whether the signature of a compact token is valid
+// for a given validator
+func verifiesSignature(v Validator, token SignedToken) bool {
+	if v == nil {
+		return false
+	}
+
+	var payload json.RawMessage
+
+	jwt, err := parseJWT(string(token), &payload)
+	if err != nil {
+		return false
+	}
+
+	return verifySignature(v, jwt, false) == nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import "testing"
+
+func TestMultipleSignatures(t *testing.T) {
+	rs := testRSValidator(t)
+
+	hs := NewHSValidator(HS256)
+	hs.Key = []byte("bogokey")
+
+	other := NewHSValidator(HS256)
+	other.Key = []byte("otherkey")
+
+	token, err := NewEncoder(nil, rs).SignJSON(&Payload{Subject: "1234567890"}, hs)
+	if err != nil {
+		t.Fatalf("Didn't expect signing a token with many validators to return an error: %s", err)
+	}
+
+	if _, err := NewEncoder(nil, rs).SignJSON(&Payload{}, nil); err != ErrNoValidator {
+		t.Errorf("Expected %v when a validator is missing; got %v", ErrNoValidator, err)
+	}
+
+	cases := []struct {
+		ExpectedError error
+		Reason        string
+		Verifiers     []Validator
+		RequireAll    bool
+	}{
+		{nil, "the validator of the Decoder matches", nil, false},
+		{nil, "a single verifier matches", []Validator{hs}, false},
+		{nil, "any verifier matches", []Validator{other, hs}, false},
+		{nil, "every verifier matches", []Validator{rs, hs}, true},
+		{ErrBadSignature, "not every verifier matches", []Validator{rs, other}, true},
+		{ErrBadSignature, "one signature matches every verifier", []Validator{rs, rs}, true},
+		{ErrBadSignature, "there are more verifiers than signatures", []Validator{rs, hs, hs}, true},
+		{ErrBadSignature, "no verifier matches", []Validator{other}, false},
+	}
+
+	for _, c := range cases {
+		dec := NewDecoder(nil, rs)
+		dec.Verifiers = c.Verifiers
+		dec.RequireAllVerifiers = c.RequireAll
+
+		payload := &Payload{}
+		err := dec.VerifyJSON(token, payload)
+
+		if err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		} else if err == nil && payload.Subject != "1234567890" {
+			t.Errorf("Expected the payload when %s; got %#v", c.Reason, payload)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const redacted = "[redacted]"
+
+// A SignedToken is the compact serialization of a signed jwt. Formatting a
+// SignedToken with %s or %v redacts everything but the header so that tokens
+// do not leak into logs; %+v prints the full token.
+type SignedToken string
+
+// WriteTo writes the full token to w.
+func (t SignedToken) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, string(t))
+	return int64(n), err
+}
+
+// Format implements fmt.Formatter.
+func (t SignedToken) Format(f fmt.State, verb rune) {
+	value := t.redacted()
+
+	if verb == 'v' && f.Flag('+') {
+		value = string(t)
+	}
+
+	switch verb {
+	case 'q':
+		fmt.Fprintf(f, "%q", value)
+	default:
+		io.WriteString(f, value)
+	}
+}
+
+func (t SignedToken) redacted() string {
+	if i := strings.Index(string(t), "."); i >= 0 {
+		return string(t[:i+1]) + redacted
+	}
+
+	return redacted
+}
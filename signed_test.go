@@ -0,0 +1,63 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestSignedTokenFormat(t *testing.T) {
+	token := SignedToken("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.e30.UGgJ_8f7TlqazSojqRAKzMJ0SUWJCJJ_9jDHe5nrhto")
+
+	cases := []struct {
+		Format   string
+		Expected string
+	}{
+		{"%s", "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.[redacted]"},
+		{"%v", "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.[redacted]"},
+		{"%q", `"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.[redacted]"`},
+		{"%+v", string(token)},
+	}
+
+	for _, c := range cases {
+		if s := fmt.Sprintf(c.Format, token); s != c.Expected {
+			t.Errorf("Expected %s to format as %s; got %s", c.Format, c.Expected, s)
+		}
+	}
+
+	if s := fmt.Sprint(SignedToken("garbage")); s != "[redacted]" {
+		t.Errorf("Expected a token without a header to be fully redacted; got %s", s)
+	}
+}
+
+func TestSignedTokenWriteTo(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	token, err := NewEncoder(nil, v).Sign(struct{}{})
+	if err != nil {
+		t.Fatalf("Didn't expect signing to return an error: %s", err)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	n, err := token.WriteTo(buf)
+
+	expected := "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.e30.UGgJ_8f7TlqazSojqRAKzMJ0SUWJCJJ_9jDHe5nrhto"
+	if err != nil || buf.String() != expected || n != int64(len(expected)) {
+		t.Errorf("Expected WriteTo to write %s; got %s (%d, %v)", expected, buf.String(), n, err)
+	}
+}
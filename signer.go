@@ -0,0 +1,92 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/asn1"
+	"math/big"
+)
+
+// A SignerValidator is a Validator signing with a crypto.Signer, e.g. a key
+// held in an HSM and exposed as a crypto.Signer by a PKCS#11 library, so that
+// the private key never leaves the device. Tokens are verified with its public
+// key.
+type SignerValidator struct {
+	algorithm Algorithm
+	signer    crypto.Signer
+	verifier  Validator
+}
+
+// NewSignerValidator constructs a SignerValidator for a given RS or ES
+// algorithm and a signer with an RSA or ECDSA key of the matching kind.
+func NewSignerValidator(algorithm Algorithm, s crypto.Signer) (SignerValidator, error) {
+	verifier, err := validatorFor(algorithm, s.Public())
+	if err != nil {
+		return SignerValidator{}, err
+	}
+
+	return SignerValidator{algorithm: algorithm, signer: s, verifier: verifier}, nil
+}
+
+func (v SignerValidator) validate(jwt *jwt) (bool, error) {
+	return v.verifier.validate(jwt)
+}
+
+func (v SignerValidator) sign(jwt *jwt) error {
+	jwt.Header.Algorithm = v.algorithm
+	jwt.rawEncode()
+
+	hashType := signerHash(v.algorithm)
+
+	hsh := hashType.New()
+	hsh.Write([]byte(string(jwt.headerRaw) + "." + string(jwt.payloadRaw)))
+
+	signature, err := v.signer.Sign(rand.Reader, hsh.Sum(nil), hashType)
+	if err != nil {
+		return err
+	}
+
+	// ECDSA signers return ASN.1 DER signatures where tokens carry r and s
+	// padded to the size of the curve
+	if key, ok := v.signer.Public().(*ecdsa.PublicKey); ok {
+		var rs struct{ R, S *big.Int }
+		if _, err := asn1.Unmarshal(signature, &rs); err != nil {
+			return err
+		}
+
+		size := curveSize(key.Curve)
+		signature = make([]byte, 2*size)
+		rs.R.FillBytes(signature[:size])
+		rs.S.FillBytes(signature[size:])
+	}
+
+	jwt.Signature = []byte(encodeSegment(signature))
+
+	return nil
+}
+
+// signerHash returns the hash of an RS or ES algorithm
+func signerHash(algorithm Algorithm) crypto.Hash {
+	switch algorithm {
+	case RS384, ES384:
+		return crypto.SHA384
+	case RS512, ES512:
+		return crypto.SHA512
+	default:
+		return crypto.SHA256
+	}
+}
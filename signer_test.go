@@ -0,0 +1,72 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"io"
+	"testing"
+)
+
+// failingSigner is a crypto.Signer whose device is unavailable
+type failingSigner struct {
+	crypto.Signer
+}
+
+func (s failingSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return nil, errors.New("device unavailable")
+}
+
+func TestSignerValidator(t *testing.T) {
+	rs := testRSValidator(t)
+	es, _ := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+
+	cases := []struct {
+		Algorithm Algorithm
+		Signer    crypto.Signer
+		Verifier  Validator
+	}{
+		{RS256, rs.PrivateKey, rs},
+		{ES512, es, ESValidator{algorithm: ES512, hashType: crypto.SHA512, PublicKey: &es.PublicKey}},
+	}
+
+	for _, c := range cases {
+		v, err := NewSignerValidator(c.Algorithm, c.Signer)
+		if err != nil {
+			t.Fatalf("Didn't expect constructing a %s SignerValidator to return an error: %s", c.Algorithm, err)
+		}
+
+		token := signTestToken(t, v, Header{Type: "JWT"}, &Payload{Subject: "1234567890"})
+
+		for _, verifier := range []Validator{v, c.Verifier} {
+			payload := &Payload{}
+			if err := NewDecoder(nil, verifier).Verify(token, payload); err != nil || payload.Subject != "1234567890" {
+				t.Errorf("Expected a %s token signed by a crypto.Signer to verify; got %v", c.Algorithm, err)
+			}
+		}
+	}
+
+	if _, err := NewSignerValidator(HS256, es); err != ErrAlgorithmNotImplemented {
+		t.Errorf("Expected %s for an HMAC algorithm; got %v", ErrAlgorithmNotImplemented, err)
+	}
+
+	v, _ := NewSignerValidator(ES512, failingSigner{es})
+	if _, err := NewEncoder(nil, v).Sign(&Payload{}); err == nil {
+		t.Errorf("Expected the error of the signer to be returned")
+	}
+}
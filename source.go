@@ -0,0 +1,98 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxStreamTokenSize limits the size of a token read from a stream
+const maxStreamTokenSize = 1 << 20
+
+// A TokenSourceReader supplies the tokens a Decoder decodes, e.g. from a
+// string, an HTTP request or a stream of tokens.
+type TokenSourceReader interface {
+	// ReadToken returns the next token. It returns io.EOF when no tokens are
+	// left.
+	ReadToken() (string, error)
+}
+
+// StringSource returns a TokenSourceReader of a single token.
+func StringSource(token string) TokenSourceReader {
+	return &stringSource{token: token}
+}
+
+// BytesSource returns a TokenSourceReader of a single token.
+func BytesSource(token []byte) TokenSourceReader {
+	return &stringSource{token: string(token)}
+}
+
+// RequestSource returns a TokenSourceReader of the bearer token in the
+// Authorization header of a request as described by RFC 6750. It returns
+// io.EOF when the request carries no bearer token.
+func RequestSource(r *http.Request) TokenSourceReader {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return &stringSource{read: true}
+	}
+
+	return &stringSource{token: strings.TrimSpace(token)}
+}
+
+// StreamSource returns a TokenSourceReader of the white space separated tokens
+// read from a stream.
+func StreamSource(r io.Reader) TokenSourceReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxStreamTokenSize)
+	scanner.Split(bufio.ScanWords)
+
+	return &streamSource{scanner: scanner}
+}
+
+// stringSource is a TokenSourceReader of a single token
+type stringSource struct {
+	token string
+	read  bool
+}
+
+func (s *stringSource) ReadToken() (string, error) {
+	if s.read {
+		return "", io.EOF
+	}
+
+	s.read = true
+
+	return s.token, nil
+}
+
+// streamSource is a TokenSourceReader of the tokens in a stream
+type streamSource struct {
+	scanner *bufio.Scanner
+}
+
+func (s *streamSource) ReadToken() (string, error) {
+	if !s.scanner.Scan() {
+		if err := s.scanner.Err(); err != nil {
+			return "", err
+		}
+
+		return "", io.EOF
+	}
+
+	return s.scanner.Text(), nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"bytes"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStreamSource(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	var subjects []string
+	var tokens []string
+	for _, sub := range []string{"a", "b", "c"} {
+		tokens = append(tokens, signTestToken(t, v, Header{Type: "JWT"}, &Payload{Subject: sub}))
+	}
+
+	dec := NewDecoder(bytes.NewBufferString(" "+strings.Join(tokens, " \n")+"\n"), v)
+
+	for {
+		payload := &Payload{}
+		err := dec.Decode(payload)
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatalf("Didn't expect decoding a stream of tokens to return an error: %s", err)
+		}
+
+		subjects = append(subjects, payload.Subject)
+	}
+
+	if strings.Join(subjects, ",") != "a,b,c" {
+		t.Errorf("Expected every token of the stream to be decoded in order; got %v", subjects)
+	}
+}
+
+func TestTokenSources(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	token := signTestToken(t, v, Header{Type: "JWT"}, &Payload{Subject: "1234567890"})
+
+	withHeader := func(value string) TokenSourceReader {
+		r := httptest.NewRequest("GET", "https://api.example/resource", nil)
+		if value != "" {
+			r.Header.Set("Authorization", value)
+		}
+
+		return RequestSource(r)
+	}
+
+	cases := []struct {
+		ExpectedError error
+		Reason        string
+		Source        TokenSourceReader
+	}{
+		{nil, "the token is a string", StringSource(token)},
+		{nil, "the token is a byte slice", BytesSource([]byte(token))},
+		{nil, "the token is a bearer token", withHeader("Bearer " + token)},
+		{nil, "the bearer scheme is lower case", withHeader("bearer " + token)},
+		{io.EOF, "the request has no Authorization header", withHeader("")},
+		{io.EOF, "the request uses another scheme", withHeader("Basic dXNlcjpwYXNz")},
+		{ErrMalformedToken, "the bearer token is empty", withHeader("Bearer ")},
+	}
+
+	for _, c := range cases {
+		dec := NewSourceDecoder(c.Source, v)
+		payload := &Payload{}
+
+		if err := dec.Decode(payload); err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+			continue
+		}
+
+		if c.ExpectedError == nil && payload.Subject != "1234567890" {
+			t.Errorf("Expected the token to be decoded when %s; got %#v", c.Reason, payload)
+		}
+
+		if err := dec.Decode(&Payload{}); err != io.EOF {
+			t.Errorf("Expected a single token source to be exhausted when %s; got %v", c.Reason, err)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"crypto"
+	"errors"
+	"strings"
+)
+
+// ErrInvalidSPIFFEID is returned when a value is not a valid SPIFFE ID
+var ErrInvalidSPIFFEID = errors.New("invalid SPIFFE ID")
+
+// A SPIFFEID identifies a workload as described by the SPIFFE ID specification,
+// e.g. spiffe://example.org/ns/default/sa/web.
+type SPIFFEID struct {
+	TrustDomain string
+	Path        string
+}
+
+// ParseSPIFFEID parses a spiffe:// URI into its trust domain and path.
+func ParseSPIFFEID(id string) (SPIFFEID, error) {
+	const scheme = "spiffe://"
+
+	if !strings.HasPrefix(id, scheme) {
+		return SPIFFEID{}, ErrInvalidSPIFFEID
+	}
+
+	rest := id[len(scheme):]
+	trustDomain, path := rest, ""
+	if i := strings.Index(rest, "/"); i >= 0 {
+		trustDomain, path = rest[:i], rest[i:]
+	}
+
+	if trustDomain == "" {
+		return SPIFFEID{}, ErrInvalidSPIFFEID
+	}
+
+	for _, r := range trustDomain {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '.' || r == '-' || r == '_') {
+			return SPIFFEID{}, ErrInvalidSPIFFEID
+		}
+	}
+
+	if path != "" {
+		for _, segment := range strings.Split(path[1:], "/") {
+			if segment == "" || segment == "." || segment == ".." {
+				return SPIFFEID{}, ErrInvalidSPIFFEID
+			}
+
+			for _, r := range segment {
+				if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' || r == '_') {
+					return SPIFFEID{}, ErrInvalidSPIFFEID
+				}
+			}
+		}
+	}
+
+	return SPIFFEID{TrustDomain: trustDomain, Path: path}, nil
+}
+
+// String returns the spiffe:// URI form of the ID.
+func (id SPIFFEID) String() string {
+	return "spiffe://" + id.TrustDomain + id.Path
+}
+
+// A BundleSource provides the JWT authorities of a trust domain keyed by their
+// key id.
+type BundleSource interface {
+	JWTAuthorities(trustDomain string) (map[string]crypto.PublicKey, error)
+}
+
+// A StaticBundleSource is a BundleSource backed by a fixed set of authorities
+// keyed by trust domain.
+type StaticBundleSource map[string]map[string]crypto.PublicKey
+
+// JWTAuthorities returns the authorities of a trust domain.
+func (s StaticBundleSource) JWTAuthorities(trustDomain string) (map[string]crypto.PublicKey, error) {
+	authorities, ok := s[trustDomain]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	return authorities, nil
+}
+
+// SVIDClaims are the claims of a JWT-SVID.
+type SVIDClaims struct {
+	Payload
+}
+
+// An SVID is a verified JWT-SVID.
+type SVID struct {
+	ID     SPIFFEID
+	Claims SVIDClaims
+}
+
+// An SVIDValidator verifies JWT-SVIDs against the JWT authorities of the trust
+// domain named by their subject.
+type SVIDValidator struct {
+	// Bundles provides the authorities of each trust domain
+	Bundles BundleSource
+	// Audience must be a member of the aud claim of every SVID
+	Audience string
+	// TrustDomains restricts the trust domains SVIDs are accepted from. An
+	// empty list accepts any trust domain present in Bundles.
+	TrustDomains []string
+}
+
+// NewSVIDValidator constructs an SVIDValidator for the given audience.
+func NewSVIDValidator(bundles BundleSource, audience string) SVIDValidator {
+	return SVIDValidator{Bundles: bundles, Audience: audience}
+}
+
+// Validate verifies the signature, audience, expiry and subject of a JWT-SVID.
+func (v SVIDValidator) Validate(token string) (*SVID, error) {
+	svid := &SVID{}
+
+	jwt, err := parseJWT(token, &svid.Claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if svid.ID, err = ParseSPIFFEID(svid.Claims.Subject); err != nil {
+		return nil, err
+	}
+
+	if len(v.TrustDomains) > 0 && !containsString(v.TrustDomains, svid.ID.TrustDomain) {
+		return nil, ErrInvalidIssuer
+	}
+
+	authorities, err := v.Bundles.JWTAuthorities(svid.ID.TrustDomain)
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok := authorities[jwt.Header.KeyID]
+	if !ok || jwt.Header.KeyID == "" {
+		return nil, ErrUnknownKey
+	}
+
+	validator, err := validatorFor(jwt.Header.Algorithm, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if valid, err := validator.validate(jwt); !valid || err != nil {
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, ErrBadSignature
+	}
+
+	if !svid.Claims.Audience.Contains(v.Audience) {
+		return nil, ErrInvalidAudience
+	}
+
+	if exp := svid.Claims.ExpirationTime; exp == nil || !timeFunc().Before(exp.Time) {
+		return nil, ErrTokenExpired
+	}
+
+	return svid, nil
+}
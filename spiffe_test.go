@@ -0,0 +1,95 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"crypto"
+	"testing"
+	"time"
+)
+
+func TestParseSPIFFEID(t *testing.T) {
+	cases := []struct {
+		ID          string
+		TrustDomain string
+		Path        string
+		Valid       bool
+	}{
+		{"spiffe://example.org/ns/default/sa/web", "example.org", "/ns/default/sa/web", true},
+		{"spiffe://example.org", "example.org", "", true},
+		{"https://example.org/web", "", "", false},
+		{"spiffe:///web", "", "", false},
+		{"spiffe://Example.org/web", "", "", false},
+		{"spiffe://example.org:8443/web", "", "", false},
+		{"spiffe://example.org/web/", "", "", false},
+		{"spiffe://example.org/../web", "", "", false},
+	}
+
+	for _, c := range cases {
+		id, err := ParseSPIFFEID(c.ID)
+
+		if c.Valid != (err == nil) {
+			t.Errorf("Expected %s to be valid: %t; got %v", c.ID, c.Valid, err)
+			continue
+		}
+
+		if c.Valid && (id.TrustDomain != c.TrustDomain || id.Path != c.Path || id.String() != c.ID) {
+			t.Errorf("Expected %s to parse to %s and %s; got %+v", c.ID, c.TrustDomain, c.Path, id)
+		}
+	}
+}
+
+func TestSVIDValidatorValidate(t *testing.T) {
+	signer := testRSValidator(t)
+	bundles := StaticBundleSource{
+		"example.org": {"authority": crypto.PublicKey(signer.PublicKey)},
+	}
+
+	future := NewNumericDate(time.Now().Add(time.Hour))
+	past := NewNumericDate(time.Now().Add(-time.Hour))
+
+	token := func(kid, sub string, aud Audience, exp *NumericDate) string {
+		claims := SVIDClaims{Payload{Subject: sub, Audience: aud, ExpirationTime: exp}}
+		return signTestToken(t, signer, Header{Type: "JWT", KeyID: kid}, claims)
+	}
+
+	cases := []struct {
+		Validator     SVIDValidator
+		Token         string
+		ExpectedError error
+		Reason        string
+	}{
+		{NewSVIDValidator(bundles, "db"), token("authority", "spiffe://example.org/web", Audience{"db", "cache"}, future), nil, "a valid SVID should be accepted"},
+		{NewSVIDValidator(bundles, "db"), token("authority", "spiffe://example.org/web", Audience{"cache"}, future), ErrInvalidAudience, "an SVID for another audience should be rejected"},
+		{NewSVIDValidator(bundles, "db"), token("authority", "spiffe://example.org/web", Audience{"db"}, past), ErrTokenExpired, "an expired SVID should be rejected"},
+		{NewSVIDValidator(bundles, "db"), token("authority", "spiffe://example.org/web", Audience{"db"}, nil), ErrTokenExpired, "an SVID without an expiry should be rejected"},
+		{NewSVIDValidator(bundles, "db"), token("other", "spiffe://example.org/web", Audience{"db"}, future), ErrUnknownKey, "an SVID signed by an unknown authority should be rejected"},
+		{NewSVIDValidator(bundles, "db"), token("authority", "spiffe://evil.org/web", Audience{"db"}, future), ErrUnknownKey, "an SVID from a trust domain without a bundle should be rejected"},
+		{NewSVIDValidator(bundles, "db"), token("authority", "web", Audience{"db"}, future), ErrInvalidSPIFFEID, "an SVID without a SPIFFE ID subject should be rejected"},
+		{SVIDValidator{Bundles: bundles, Audience: "db", TrustDomains: []string{"corp.org"}}, token("authority", "spiffe://example.org/web", Audience{"db"}, future), ErrInvalidIssuer, "an SVID from an untrusted domain should be rejected"},
+	}
+
+	for _, c := range cases {
+		svid, err := c.Validator.Validate(c.Token)
+
+		if err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		}
+
+		if err == nil && svid.ID.Path != "/web" {
+			t.Errorf("Expected the SVID to identify /web; got %s", svid.ID)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import "sync"
+
+// DecoderStats is a snapshot of the tokens a Decoder has seen, e.g. for health
+// endpoints and debugging.
+type DecoderStats struct {
+	// Verified is the number of tokens that were accepted
+	Verified uint64
+	// Failures counts rejected tokens by the code of the error returned, so
+	// that errors with dynamic messages do not grow it without bound
+	Failures map[ErrorCode]uint64
+	// KeyIDs counts accepted tokens by the kid header that verified them
+	KeyIDs map[string]uint64
+	// CacheHits and CacheMisses count the tokens looked up in the Cache of
+	// the Decoder that were found and not found
+	CacheHits   uint64
+	CacheMisses uint64
+}
+
+// CacheHitRate returns the fraction of the tokens looked up in the Cache of
+// the Decoder that were found, or zero when none were looked up.
+func (s DecoderStats) CacheHitRate() float64 {
+	if s.CacheHits+s.CacheMisses == 0 {
+		return 0
+	}
+
+	return float64(s.CacheHits) / float64(s.CacheHits+s.CacheMisses)
+}
+
+// decoderStats accumulates the DecoderStats of a Decoder
+type decoderStats struct {
+	mu          sync.Mutex
+	verified    uint64
+	failures    map[ErrorCode]uint64
+	keyIDs      map[string]uint64
+	cacheHits   uint64
+	cacheMisses uint64
+}
+
+// Stats returns a snapshot of the tokens the Decoder has seen. It is safe to
+// call while tokens are being verified.
+func (dec *Decoder) Stats() DecoderStats {
+	return dec.stats.snapshot()
+}
+
+func (s *decoderStats) record(result *DecodeResult, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		if s.failures == nil {
+			s.failures = map[ErrorCode]uint64{}
+		}
+
+		s.failures[CodeOf(err)]++
+		return
+	}
+
+	if s.keyIDs == nil {
+		s.keyIDs = map[string]uint64{}
+	}
+
+	s.verified++
+	s.keyIDs[result.KeyID]++
+}
+
+// recordCache records a lookup of a token in the Cache of a Decoder
+func (s *decoderStats) recordCache(hit bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if hit {
+		s.cacheHits++
+	} else {
+		s.cacheMisses++
+	}
+}
+
+func (s *decoderStats) snapshot() DecoderStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := DecoderStats{
+		Verified:    s.verified,
+		Failures:    make(map[ErrorCode]uint64, len(s.failures)),
+		KeyIDs:      make(map[string]uint64, len(s.keyIDs)),
+		CacheHits:   s.cacheHits,
+		CacheMisses: s.cacheMisses,
+	}
+
+	for code, n := range s.failures {
+		stats.Failures[code] = n
+	}
+
+	for kid, n := range s.keyIDs {
+		stats.KeyIDs[kid] = n
+	}
+
+	return stats
+}
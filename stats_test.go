@@ -0,0 +1,99 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDecoderStats(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	dec := NewDecoder(nil, v)
+
+	for _, kid := range []string{"k1", "k1", "k2"} {
+		token := signTestToken(t, v, Header{Type: "JWT", KeyID: kid}, &Payload{})
+		if err := dec.Verify(token, &Payload{}); err != nil {
+			t.Fatalf("Didn't expect verifying a valid token to return an error: %s", err)
+		}
+	}
+
+	dec.Verify("abc.def", &Payload{})
+	dec.Verify("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.e30k.YQo=", &Payload{})
+	dec.Verify("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.e30k.YQo=", &Payload{})
+
+	stats := dec.Stats()
+
+	if stats.Verified != 3 || stats.KeyIDs["k1"] != 2 || stats.KeyIDs["k2"] != 1 {
+		t.Errorf("Expected 3 verified tokens, 2 by k1 and 1 by k2; got %+v", stats)
+	}
+
+	if stats.Failures[CodeMalformedToken] != 1 || stats.Failures[CodeBadSignature] != 2 {
+		t.Errorf("Expected 1 malformed token and 2 bad signatures; got %+v", stats.Failures)
+	}
+
+	stats.KeyIDs["k1"] = 100
+	if dec.Stats().KeyIDs["k1"] != 2 {
+		t.Errorf("Expected a snapshot to be independent of the Decoder")
+	}
+}
+
+func TestDecoderStatsFailureCodes(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	dec := NewDecoder(nil, v)
+	dec.AddValidation(func(claims RawClaims) error {
+		var sub string
+		claims.Decode("sub", &sub)
+
+		return fmt.Errorf("%w: unknown user %s", ErrInvalidTenant, sub)
+	})
+
+	for _, sub := range []string{"a", "b", "c"} {
+		dec.Verify(signTestToken(t, v, Header{Type: "JWT"}, &Payload{Subject: sub}), &Payload{})
+	}
+
+	if failures := dec.Stats().Failures; len(failures) != 1 || failures[CodeInvalidTenant] != 3 {
+		t.Errorf("Expected errors with distinct messages to be counted by code; got %v", failures)
+	}
+}
+
+func TestDecoderStatsCache(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	token := signTestToken(t, v, Header{Type: "JWT"}, &Payload{ExpirationTime: NewNumericDate(time.Now().Add(time.Hour))})
+
+	dec := NewDecoder(nil, v)
+	if dec.Verify(token, &Payload{}); dec.Stats().CacheMisses != 0 {
+		t.Errorf("Expected no cache lookups without a Cache; got %+v", dec.Stats())
+	}
+
+	dec.Cache = NewVerifyCache(10)
+
+	for i := 0; i < 4; i++ {
+		if err := dec.Verify(token, &Payload{}); err != nil {
+			t.Fatalf("Didn't expect verifying a token to return an error: %s", err)
+		}
+	}
+
+	if stats := dec.Stats(); stats.CacheHits != 3 || stats.CacheMisses != 1 || stats.CacheHitRate() != 0.75 {
+		t.Errorf("Expected 3 cache hits and 1 miss; got %+v", stats)
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// A taggedPayload encodes and decodes the top level claims of a struct using
+// an alternate struct tag, falling back to the json tag of fields without one.
+// Values that marshal themselves are left to do so.
+type taggedPayload struct {
+	v   interface{}
+	tag string
+}
+
+// A claimField is a field of a struct that is encoded as a claim
+type claimField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+func (p *taggedPayload) MarshalJSON() ([]byte, error) {
+	if m, ok := p.v.(json.Marshaler); ok {
+		return m.MarshalJSON()
+	}
+
+	v := reflect.ValueOf(p.v)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return json.Marshal(p.v)
+	}
+
+	buf := bytes.NewBufferString("{")
+
+	for _, f := range claimFields(v.Type(), p.tag) {
+		value, ok := fieldByIndex(v, f.index, false)
+		if !ok || !value.CanInterface() || f.omitEmpty && isEmptyValue(value) {
+			continue
+		}
+
+		name, err := json.Marshal(f.name)
+		if err != nil {
+			return nil, err
+		}
+
+		claim, err := json.Marshal(value.Interface())
+		if err != nil {
+			return nil, err
+		}
+
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(claim)
+	}
+
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+func (p *taggedPayload) UnmarshalJSON(b []byte) error {
+	if u, ok := p.v.(json.Unmarshaler); ok {
+		return u.UnmarshalJSON(b)
+	}
+
+	v := reflect.ValueOf(p.v)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return json.Unmarshal(b, p.v)
+	}
+
+	var claims map[string]json.RawMessage
+	if err := json.Unmarshal(b, &claims); err != nil {
+		return err
+	}
+
+	v = v.Elem()
+
+	for _, f := range claimFields(v.Type(), p.tag) {
+		raw, ok := claims[f.name]
+		if !ok {
+			continue
+		}
+
+		value, ok := fieldByIndex(v, f.index, true)
+		if !ok || !value.CanSet() {
+			continue
+		}
+
+		if err := json.Unmarshal(raw, value.Addr().Interface()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// claimFields lists the claims of a struct type following the rules of
+// encoding/json: embedded structs are flattened and a shallower field hides
+// deeper fields of the same name.
+func claimFields(t reflect.Type, tag string) []claimField {
+	var fields []claimField
+
+	var walk func(t reflect.Type, index []int)
+	walk = func(t reflect.Type, index []int) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+
+			value := f.Tag.Get(tag)
+			if value == "" {
+				value = f.Tag.Get("json")
+			}
+
+			if value == "-" {
+				continue
+			}
+
+			name, opts := value, ""
+			if i := strings.Index(value, ","); i >= 0 {
+				name, opts = value[:i], value[i+1:]
+			}
+
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+
+			path := append(append([]int(nil), index...), i)
+
+			if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+				walk(ft, path)
+				continue
+			}
+
+			if f.PkgPath != "" {
+				continue
+			}
+
+			if name == "" {
+				name = f.Name
+			}
+
+			fields = append(fields, claimField{
+				name:      name,
+				index:     path,
+				omitEmpty: containsString(strings.Split(opts, ","), "omitempty"),
+			})
+		}
+	}
+
+	walk(t, nil)
+
+	depth := map[string]int{}
+	count := map[string]int{}
+
+	for _, f := range fields {
+		if d, ok := depth[f.name]; !ok || len(f.index) < d {
+			depth[f.name], count[f.name] = len(f.index), 0
+		}
+
+		if len(f.index) == depth[f.name] {
+			count[f.name]++
+		}
+	}
+
+	dominant := fields[:0]
+	for _, f := range fields {
+		if len(f.index) == depth[f.name] && count[f.name] == 1 {
+			dominant = append(dominant, f)
+		}
+	}
+
+	return dominant
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex but reports false instead of
+// panicking on a nil embedded pointer, or allocates it if asked to.
+func fieldByIndex(v reflect.Value, index []int, alloc bool) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !alloc || !v.CanSet() {
+					return reflect.Value{}, false
+				}
+
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+
+			v = v.Elem()
+		}
+
+		v = v.Field(x)
+	}
+
+	return v, true
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+
+	return false
+}
@@ -0,0 +1,94 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+type taggedClaims struct {
+	Payload
+	Audience Audience `json:"audience" claim:"aud"`
+	TenantID string   `json:"tenantId" claim:"tid"`
+	Secret   string   `json:"-" claim:"sec,omitempty"`
+	Internal string   `json:"internal" claim:"-"`
+	Admin    bool     `json:"admin,omitempty"`
+}
+
+func TestTaggedPayloadJSON(t *testing.T) {
+	claims := &taggedClaims{
+		Payload:  Payload{Subject: "1234567890", Audience: Audience{"shadowed"}},
+		Audience: Audience{"api"},
+		TenantID: "t-1",
+		Internal: "hidden",
+	}
+
+	b, err := json.Marshal(&taggedPayload{v: claims, tag: "claim"})
+	if err != nil {
+		t.Fatalf("Didn't expect marshaling tagged claims to return an error: %s", err)
+	}
+
+	expected := `{"sub":"1234567890","aud":"api","tid":"t-1"}`
+	if string(b) != expected {
+		t.Errorf("Expected claims to be named by the claim tag\nwant: %s\n got: %s", expected, b)
+	}
+
+	if b, _ := json.Marshal(claims); !bytes.Contains(b, []byte(`"tenantId":"t-1"`)) {
+		t.Errorf("Expected the json tag to be unaffected; got %s", b)
+	}
+
+	decoded := &taggedClaims{}
+	input := `{"sub":"1234567890","aud":["api","web"],"tid":"t-2","sec":"s","internal":"x","admin":true}`
+
+	if err := json.Unmarshal([]byte(input), &taggedPayload{v: decoded, tag: "claim"}); err != nil {
+		t.Fatalf("Didn't expect unmarshaling tagged claims to return an error: %s", err)
+	}
+
+	if decoded.Subject != "1234567890" || decoded.TenantID != "t-2" || decoded.Secret != "s" || !decoded.Admin {
+		t.Errorf("Expected claims to be read by the claim tag; got %#v", decoded)
+	}
+
+	if len(decoded.Audience) != 2 || len(decoded.Payload.Audience) != 0 || decoded.Internal != "" {
+		t.Errorf("Expected shadowed and excluded claims to be left alone; got %#v", decoded)
+	}
+}
+
+func TestEncodeDecodeTagName(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	enc := NewEncoder(nil, v)
+	enc.TagName = "claim"
+
+	token, err := enc.Sign(&taggedClaims{TenantID: "t-1"})
+	if err != nil {
+		t.Fatalf("Didn't expect signing tagged claims to return an error: %s", err)
+	}
+
+	generic := map[string]interface{}{}
+	if err := NewDecoder(bytes.NewBufferString(string(token)), v).Decode(&generic); err != nil || generic["tid"] != "t-1" {
+		t.Errorf("Expected the token to carry a tid claim; got %v and %v", generic, err)
+	}
+
+	dec := NewDecoder(bytes.NewBufferString(string(token)), v)
+	dec.TagName = "claim"
+
+	claims := &taggedClaims{}
+	if err := dec.Decode(claims); err != nil || claims.TenantID != "t-1" {
+		t.Errorf("Expected the tid claim to decode into TenantID; got %#v and %v", claims, err)
+	}
+}
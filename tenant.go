@@ -0,0 +1,77 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import "fmt"
+
+// TenantClaims are the claims conventionally naming the tenant and the
+// organization a token was issued for in multi-tenant services. They are meant
+// to be embedded in the claims a token is decoded into.
+type TenantClaims struct {
+	TenantID       string `json:"tenant_id,omitempty"`
+	OrganizationID string `json:"org_id,omitempty"`
+}
+
+// Tenant returns the tenant claims, which makes them available from the
+// DecodeResult of any claims they are embedded in.
+func (c TenantClaims) Tenant() TenantClaims {
+	return c
+}
+
+// A Tenanted value carries TenantClaims, e.g. a struct embedding them.
+type Tenanted interface {
+	Tenant() TenantClaims
+}
+
+// Tenant returns the tenant claims of a verified token. Its Claims must embed
+// TenantClaims, otherwise the zero value is returned.
+func (r *DecodeResult) Tenant() TenantClaims {
+	if t, ok := r.Claims.(Tenanted); ok {
+		return t.Tenant()
+	}
+
+	return TenantClaims{}
+}
+
+// RequireTenant requires tokens to have a tenant_id claim, which must be one of
+// the given tenants if any are given. ErrInvalidTenant is returned otherwise.
+// Like AddValidation it must be called before the Decoder is used.
+func (dec *Decoder) RequireTenant(tenantIDs ...string) {
+	dec.AddValidation(requireTenantClaim("tenant_id", tenantIDs))
+}
+
+// RequireOrganization requires tokens to have an org_id claim, which must be
+// one of the given organizations if any are given. ErrInvalidTenant is
+// returned otherwise. Like AddValidation it must be called before the Decoder
+// is used.
+func (dec *Decoder) RequireOrganization(organizationIDs ...string) {
+	dec.AddValidation(requireTenantClaim("org_id", organizationIDs))
+}
+
+// requireTenantClaim returns a validation requiring a string claim with one of
+// the accepted values, or any value if none are accepted
+func requireTenantClaim(name string, accepted []string) func(claims RawClaims) error {
+	return func(claims RawClaims) error {
+		var value string
+		if err := claims.Decode(name, &value); err != nil || value == "" {
+			return fmt.Errorf("%w: %s", ErrInvalidTenant, name)
+		}
+
+		if len(accepted) > 0 && !containsString(accepted, value) {
+			return fmt.Errorf("%w: %s", ErrInvalidTenant, name)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTenantClaims(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	type claims struct {
+		Payload
+		TenantClaims
+	}
+
+	cases := []struct {
+		ExpectedError error
+		Reason        string
+		Claims        map[string]interface{}
+		Tenants       []string
+		Organizations []string
+	}{
+		{nil, "the tenant and organization are present", map[string]interface{}{"tenant_id": "contoso", "org_id": "sales"}, nil, nil},
+		{nil, "the tenant is accepted", map[string]interface{}{"tenant_id": "contoso", "org_id": "sales"}, []string{"fabrikam", "contoso"}, []string{"sales"}},
+		{ErrInvalidTenant, "the tenant is missing", map[string]interface{}{"org_id": "sales"}, nil, nil},
+		{ErrInvalidTenant, "the tenant is empty", map[string]interface{}{"tenant_id": "", "org_id": "sales"}, nil, nil},
+		{ErrInvalidTenant, "the tenant is not accepted", map[string]interface{}{"tenant_id": "contoso", "org_id": "sales"}, []string{"fabrikam"}, nil},
+		{ErrInvalidTenant, "the organization is missing", map[string]interface{}{"tenant_id": "contoso"}, nil, nil},
+		{ErrInvalidTenant, "the organization is not accepted", map[string]interface{}{"tenant_id": "contoso", "org_id": "sales"}, nil, []string{"support"}},
+	}
+
+	for _, c := range cases {
+		dec := NewDecoder(nil, v)
+		dec.RequireTenant(c.Tenants...)
+		dec.RequireOrganization(c.Organizations...)
+
+		result, err := dec.verify(signTestToken(t, v, Header{Type: "JWT"}, c.Claims), &claims{})
+
+		if !errors.Is(err, c.ExpectedError) {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		} else if err == nil && result.Tenant() != (TenantClaims{"contoso", "sales"}) {
+			t.Errorf("Expected the tenant claims when %s; got %#v", c.Reason, result.Tenant())
+		}
+	}
+
+	result, err := NewDecoder(nil, v).verify(signTestToken(t, v, Header{Type: "JWT"}, map[string]interface{}{"tenant_id": "contoso"}), &Payload{})
+	if err != nil {
+		t.Fatalf("Didn't expect decoding to return an error: %s", err)
+	}
+
+	if tenant := result.Tenant(); tenant != (TenantClaims{}) {
+		t.Errorf("Expected no tenant claims when the claims do not embed them; got %#v", tenant)
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// A Token is a parsed token: its header, its claims and the segments it was
+// serialized as, so it can be inspected and passed on exactly as received.
+type Token struct {
+	header    Header
+	claims    RawClaims
+	claimsRaw []byte
+	segments  []string
+}
+
+// ParseToken parses a token WITHOUT verifying its signature or claims, e.g. to
+// read its kid or iss to decide how to verify it. Nothing read from a parsed
+// token may be trusted until it is verified by VerifyToken.
+func ParseToken(token string) (*Token, error) {
+	return parseToken(token, DefaultMaxInflatedSize)
+}
+
+// PeekHeader decodes the header of a token WITHOUT verifying it, e.g. to
+// select the validator of its alg or kid. Unlike ParseToken the payload is not
+// decoded. Nothing read from the header may be trusted until the token is
+// verified.
+func PeekHeader(token string) (Header, error) {
+	fields := strings.Split(token, ".")
+	if len(fields) != 3 {
+		return Header{}, ErrMalformedToken
+	}
+
+	jwt := &jwt{Header: &Header{}}
+	if err := jwt.parseHeader(fields[0]); err != nil {
+		return Header{}, ErrMalformedToken
+	}
+
+	return *jwt.Header, nil
+}
+
+// VerifyToken verifies a given token like Verify and returns it as a Token.
+func (dec *Decoder) VerifyToken(token string) (*Token, error) {
+	if _, err := dec.verify(token, &RawClaims{}); err != nil {
+		return nil, err
+	}
+
+	if dec.AcceptArmor {
+		var err error
+		if token, err = Unarmor(token); err != nil {
+			return nil, err
+		}
+	}
+
+	return parseToken(token, dec.maxInflatedSize())
+}
+
+// parseToken parses a token whose payload inflates to at most limit bytes
+func parseToken(token string, limit int) (*Token, error) {
+	var claims RawClaims
+
+	jwt, err := parseInflatingJWT(token, &claims, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Token{
+		header:    *jwt.Header,
+		claims:    claims,
+		claimsRaw: jwt.claimsRaw,
+		segments:  strings.Split(token, "."),
+	}, nil
+}
+
+// Header returns the header of the token.
+func (t *Token) Header() Header {
+	return t.header
+}
+
+// Claims returns the claims of the token by name.
+func (t *Token) Claims() RawClaims {
+	return t.claims
+}
+
+// Decode decodes the claims of the token into v.
+func (t *Token) Decode(v interface{}) error {
+	return json.Unmarshal(t.claimsRaw, v)
+}
+
+// Signature returns the signature of the token.
+func (t *Token) Signature() ([]byte, error) {
+	return parseField(t.segments[2])
+}
+
+// SigningInput returns the encoded header and payload the signature of the
+// token is made over.
+func (t *Token) SigningInput() string {
+	return t.segments[0] + "." + t.segments[1]
+}
+
+// SignedString returns the token serialized exactly as it was parsed.
+func (t *Token) SignedString() SignedToken {
+	return SignedToken(strings.Join(t.segments, "."))
+}
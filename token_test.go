@@ -0,0 +1,120 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseToken(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	signed := signTestToken(t, v, Header{Type: "JWT", KeyID: "primary"}, &Payload{Subject: "1234567890"})
+
+	token, err := ParseToken(signed)
+	if err != nil {
+		t.Fatalf("Didn't expect parsing a token to return an error: %s", err)
+	}
+
+	if h := token.Header(); h.KeyID != "primary" || h.Algorithm != HS256 {
+		t.Errorf("Expected the header of the token; got %#v", h)
+	}
+
+	var subject string
+	if err := token.Claims().Decode("sub", &subject); err != nil || subject != "1234567890" {
+		t.Errorf("Expected the sub claim of the token; got %q and %v", subject, err)
+	}
+
+	payload := &Payload{}
+	if err := token.Decode(payload); err != nil || payload.Subject != "1234567890" {
+		t.Errorf("Expected the claims of the token; got %#v and %v", payload, err)
+	}
+
+	if string(token.SignedString()) != signed {
+		t.Errorf("Expected the token to be serialized as parsed; got %s", token.SignedString())
+	}
+
+	if input := token.SigningInput(); input+"."+strings.Split(signed, ".")[2] != signed {
+		t.Errorf("Expected the signing input to be the header and payload; got %s", input)
+	}
+
+	if signature, err := token.Signature(); err != nil || len(signature) != 32 {
+		t.Errorf("Expected a 32 byte signature; got %v and %v", signature, err)
+	}
+
+	if _, err := ParseToken("not a token"); err != ErrMalformedToken {
+		t.Errorf("Expected parsing a malformed token to return %s; got %v", ErrMalformedToken, err)
+	}
+}
+
+func TestPeekHeader(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	signed := signTestToken(t, v, Header{Type: "JWT", KeyID: "primary"}, &Payload{})
+	fields := strings.Split(signed, ".")
+
+	cases := []struct {
+		ExpectedError error
+		Reason        string
+		Token         string
+	}{
+		{nil, "the token is well formed", signed},
+		{nil, "the payload is not JSON", fields[0] + "." + encodeSegment([]byte("not json")) + "." + fields[2]},
+		{ErrMalformedToken, "the header is not JSON", encodeSegment([]byte("not json")) + "." + fields[1] + "." + fields[2]},
+		{ErrMalformedToken, "the token has two segments", fields[0] + "." + fields[1]},
+	}
+
+	for _, c := range cases {
+		h, err := PeekHeader(c.Token)
+
+		if err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		} else if err == nil && (h.KeyID != "primary" || h.Algorithm != HS256) {
+			t.Errorf("Expected the header of the token when %s; got %#v", c.Reason, h)
+		}
+	}
+}
+
+func TestVerifyToken(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	other := NewHSValidator(HS256)
+	other.Key = []byte("otherkey")
+
+	signed := signTestToken(t, v, Header{Type: "JWT"}, &Payload{Subject: "1234567890"})
+
+	cases := []struct {
+		ExpectedError error
+		Reason        string
+		Validator     Validator
+	}{
+		{nil, "the signature is valid", v},
+		{ErrBadSignature, "the token is signed with another key", other},
+		{ErrNoValidator, "no validator is configured", nil},
+	}
+
+	for _, c := range cases {
+		token, err := NewDecoder(nil, c.Validator).VerifyToken(signed)
+
+		if err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		} else if err == nil && string(token.SignedString()) != signed {
+			t.Errorf("Expected the verified token when %s; got %s", c.Reason, token.SignedString())
+		}
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// RawClaims are the claims of a verified token by name, as given to the
+// validations of a Decoder.
+type RawClaims map[string]json.RawMessage
+
+// Decode decodes the claim with a given name into v. ErrMissingClaim is
+// returned when the token has no such claim.
+func (c RawClaims) Decode(name string, v interface{}) error {
+	raw, ok := c[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrMissingClaim, name)
+	}
+
+	return json.Unmarshal(raw, v)
+}
+
+// AddValidation adds a check that is run against the claims of each token once
+// its signature is verified and its Policy is satisfied, e.g. to enforce a
+// tenant or the scopes an endpoint requires. Validations run in the order they
+// are added and the first error is returned from Decode as is. Validations
+// must be added before the Decoder is used.
+func (dec *Decoder) AddValidation(f func(claims RawClaims) error) {
+	dec.validations = append(dec.validations, f)
+}
+
+// runValidations runs the validations of the Decoder against a given payload
+func (dec *Decoder) runValidations(payload []byte) error {
+	if len(dec.validations) == 0 {
+		return nil
+	}
+
+	var claims RawClaims
+	if err := json.NewDecoder(bytes.NewReader(payload)).Decode(&claims); err != nil {
+		return ErrMalformedToken
+	}
+
+	for _, validate := range dec.validations {
+		if err := validate(claims); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
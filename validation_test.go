@@ -0,0 +1,87 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"errors"
+	"testing"
+)
+
+var ErrTestScope = errors.New("A fake scope error")
+
+func TestAddValidation(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	var order []string
+
+	dec := NewDecoder(nil, v)
+	dec.AddValidation(func(claims RawClaims) error {
+		order = append(order, "tenant")
+
+		var tenant string
+		if err := claims.Decode("tid", &tenant); err != nil {
+			return err
+		}
+
+		if tenant != "t-1" {
+			return ErrInvalidTenant
+		}
+
+		return nil
+	})
+	dec.AddValidation(func(claims RawClaims) error {
+		order = append(order, "scope")
+
+		var scopes []string
+		if err := claims.Decode("scp", &scopes); err != nil || !containsString(scopes, "read") {
+			return ErrTestScope
+		}
+
+		return nil
+	})
+
+	cases := []struct {
+		ExpectedError error
+		Reason        string
+		Claims        map[string]interface{}
+	}{
+		{nil, "every validation passes", map[string]interface{}{"tid": "t-1", "scp": []string{"read"}}},
+		{ErrInvalidTenant, "the tenant is not accepted", map[string]interface{}{"tid": "t-2", "scp": []string{"read"}}},
+		{ErrMissingClaim, "the tenant is missing", map[string]interface{}{"scp": []string{"read"}}},
+		{ErrTestScope, "the scope is missing", map[string]interface{}{"tid": "t-1", "scp": []string{"write"}}},
+	}
+
+	for _, c := range cases {
+		err := dec.Verify(signTestToken(t, v, Header{Type: "JWT"}, c.Claims), &map[string]interface{}{})
+
+		if !errors.Is(err, c.ExpectedError) {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		}
+	}
+
+	order = nil
+	dec.Verify(signTestToken(t, v, Header{Type: "JWT"}, map[string]interface{}{"tid": "t-2"}), &map[string]interface{}{})
+
+	if len(order) != 1 || order[0] != "tenant" {
+		t.Errorf("Expected validations to stop at the first error; ran %v", order)
+	}
+
+	order = nil
+	forged := signTestToken(t, NewHSValidator(HS256), Header{Type: "JWT"}, map[string]interface{}{"tid": "t-1"})
+
+	if err := dec.Verify(forged, &map[string]interface{}{}); err != ErrBadSignature || len(order) != 0 {
+		t.Errorf("Expected validations not to run for a bad signature; got %v and ran %v", err, order)
+	}
+}
@@ -0,0 +1,253 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrTokenNotFound is returned when a vault holds no token by a given name
+	// and has no source to obtain one from
+	ErrTokenNotFound = errors.New("token not found")
+	// ErrInvalidVault is returned when a vault file cannot be decrypted with
+	// the given key
+	ErrInvalidVault = errors.New("invalid token vault")
+)
+
+// A TokenSource obtains fresh tokens, e.g. by logging in again or by redeeming
+// a refresh token.
+type TokenSource interface {
+	Token() (SignedToken, error)
+}
+
+// TokenSourceFunc adapts a function to a TokenSource.
+type TokenSourceFunc func() (SignedToken, error)
+
+// Token implements TokenSource.
+func (f TokenSourceFunc) Token() (SignedToken, error) {
+	return f()
+}
+
+// A VaultEntry is a token held by a TokenVault with its claims.
+type VaultEntry struct {
+	Token  SignedToken `json:"token"`
+	Claims RawClaims   `json:"claims"`
+	// Expiry is the exp claim of the token, or zero when it has none
+	Expiry time.Time `json:"expiry"`
+}
+
+// A TokenVault holds named tokens on behalf of their bearer, e.g. the session
+// of a command line tool or the fixtures of integration tests. Tokens are held
+// as received and are not verified. It is safe for concurrent use.
+type TokenVault struct {
+	// RefreshBefore is how long before its expiry a token is refreshed from
+	// its source
+	RefreshBefore time.Duration
+
+	mu      sync.Mutex
+	entries map[string]VaultEntry
+	sources map[string]TokenSource
+}
+
+// NewTokenVault constructs an empty TokenVault.
+func NewTokenVault() *TokenVault {
+	return &TokenVault{
+		entries: map[string]VaultEntry{},
+		sources: map[string]TokenSource{},
+	}
+}
+
+// Put stores a token under a name, replacing any token stored before.
+func (v *TokenVault) Put(name string, token SignedToken) (VaultEntry, error) {
+	entry, err := newVaultEntry(token)
+	if err != nil {
+		return VaultEntry{}, err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.entries[name] = entry
+
+	return entry, nil
+}
+
+// SetSource sets the source a named token is obtained from when it is missing
+// or about to expire. Sources are called with the vault locked.
+func (v *TokenVault) SetSource(name string, s TokenSource) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.sources[name] = s
+}
+
+// Get returns a named token, refreshing it from its source when it is missing
+// or expires within RefreshBefore. A token that fails to refresh is still
+// returned until it expires.
+func (v *TokenVault) Get(name string) (VaultEntry, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	now := timeFunc()
+	entry, ok := v.entries[name]
+	fresh := ok && (entry.Expiry.IsZero() || now.Add(v.RefreshBefore).Before(entry.Expiry))
+
+	source := v.sources[name]
+	if fresh || source == nil {
+		if !ok {
+			return VaultEntry{}, ErrTokenNotFound
+		}
+
+		if !fresh && !now.Before(entry.Expiry) {
+			return VaultEntry{}, ErrTokenExpired
+		}
+
+		return entry, nil
+	}
+
+	token, err := source.Token()
+	if err == nil {
+		var refreshed VaultEntry
+		if refreshed, err = newVaultEntry(token); err == nil {
+			v.entries[name] = refreshed
+			return refreshed, nil
+		}
+	}
+
+	if ok && now.Before(entry.Expiry) {
+		return entry, nil
+	}
+
+	return VaultEntry{}, err
+}
+
+// Token returns a named token as Get does.
+func (v *TokenVault) Token(name string) (SignedToken, error) {
+	entry, err := v.Get(name)
+	return entry.Token, err
+}
+
+// Delete removes a named token and its source.
+func (v *TokenVault) Delete(name string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	delete(v.entries, name)
+	delete(v.sources, name)
+}
+
+// Names returns the sorted names of the tokens held by the vault.
+func (v *TokenVault) Names() []string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	names := make([]string, 0, len(v.entries))
+	for name := range v.entries {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Save writes the tokens of the vault to a file readable only by its owner,
+// encrypted with AES-GCM under a 16, 24 or 32 byte key. Sources are not saved.
+func (v *TokenVault) Save(path string, key []byte) error {
+	aead, err := vaultCipher(key)
+	if err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	plaintext, err := json.Marshal(v.entries)
+	v.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, aead.Seal(nonce, nonce, plaintext, nil), 0600)
+}
+
+// OpenTokenVault reads a vault written by Save with the same key.
+func OpenTokenVault(path string, key []byte) (*TokenVault, error) {
+	aead, err := vaultCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrInvalidVault
+	}
+
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrInvalidVault
+	}
+
+	v := NewTokenVault()
+	if err := json.Unmarshal(plaintext, &v.entries); err != nil {
+		return nil, ErrInvalidVault
+	}
+
+	return v, nil
+}
+
+// newVaultEntry parses the claims and expiry of a token without verifying it
+func newVaultEntry(token SignedToken) (VaultEntry, error) {
+	entry := VaultEntry{Token: token}
+
+	if _, err := parseJWT(string(token), &entry.Claims); err != nil {
+		return VaultEntry{}, err
+	}
+
+	var exp NumericDate
+	if err := entry.Claims.Decode("exp", &exp); err == nil {
+		entry.Expiry = exp.Time
+	} else if !errors.Is(err, ErrMissingClaim) {
+		return VaultEntry{}, ErrMalformedToken
+	}
+
+	return entry, nil
+}
+
+// vaultCipher returns the AES-GCM cipher of a vault key
+func vaultCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
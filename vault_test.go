@@ -0,0 +1,130 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTokenVault(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	timeFunc = func() time.Time { return now }
+	defer func() { timeFunc = time.Now }()
+
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	issue := func(expiry time.Duration) SignedToken {
+		return SignedToken(signTestToken(t, v, Header{Type: "JWT"}, &Payload{Subject: "1234567890", ExpirationTime: NewNumericDate(now.Add(expiry))}))
+	}
+
+	vault := NewTokenVault()
+	vault.RefreshBefore = time.Minute
+
+	if _, err := vault.Token("session"); err != ErrTokenNotFound {
+		t.Errorf("Expected a missing token to return %s; got %v", ErrTokenNotFound, err)
+	}
+
+	entry, err := vault.Put("session", issue(time.Hour))
+	if err != nil {
+		t.Fatalf("Didn't expect storing a token to return an error: %s", err)
+	}
+
+	var subject string
+	if err := entry.Claims.Decode("sub", &subject); err != nil || subject != "1234567890" || !entry.Expiry.Equal(now.Add(time.Hour)) {
+		t.Errorf("Expected the claims and expiry of the token; got %#v and %v", entry, err)
+	}
+
+	if _, err := vault.Put("broken", "not.a.token"); err != ErrMalformedToken {
+		t.Errorf("Expected a malformed token to return %s; got %v", ErrMalformedToken, err)
+	}
+
+	now = now.Add(time.Hour)
+
+	if _, err := vault.Token("session"); err != ErrTokenExpired {
+		t.Errorf("Expected an expired token without a source to return %s; got %v", ErrTokenExpired, err)
+	}
+
+	refreshes := 0
+	ErrTestSource := errors.New("login failed")
+	failing := false
+
+	vault.SetSource("session", TokenSourceFunc(func() (SignedToken, error) {
+		refreshes++
+		if failing {
+			return "", ErrTestSource
+		}
+
+		return issue(time.Hour), nil
+	}))
+
+	token, err := vault.Token("session")
+	if err != nil || refreshes != 1 {
+		t.Fatalf("Expected an expired token to be refreshed; got %d refreshes and %v", refreshes, err)
+	}
+
+	if again, _ := vault.Token("session"); again != token || refreshes != 1 {
+		t.Errorf("Expected a fresh token not to be refreshed; got %d refreshes", refreshes)
+	}
+
+	now = now.Add(59*time.Minute + 30*time.Second)
+	failing = true
+
+	if again, err := vault.Token("session"); again != token || err != nil || refreshes != 2 {
+		t.Errorf("Expected a token that fails to refresh to be returned until it expires; got %d refreshes and %v", refreshes, err)
+	}
+
+	now = now.Add(time.Minute)
+
+	if _, err := vault.Token("session"); err != ErrTestSource {
+		t.Errorf("Expected an expired token that fails to refresh to return %s; got %v", ErrTestSource, err)
+	}
+}
+
+func TestTokenVaultPersistence(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	key := []byte("0123456789abcdef0123456789abcdef")
+	path := filepath.Join(t.TempDir(), "vault")
+
+	vault := NewTokenVault()
+	vault.Put("admin", SignedToken(signTestToken(t, v, Header{Type: "JWT"}, &Payload{Subject: "admin"})))
+	vault.Put("user", SignedToken(signTestToken(t, v, Header{Type: "JWT"}, &Payload{Subject: "user"})))
+
+	if err := vault.Save(path, key); err != nil {
+		t.Fatalf("Didn't expect saving a vault to return an error: %s", err)
+	}
+
+	opened, err := OpenTokenVault(path, key)
+	if err != nil {
+		t.Fatalf("Didn't expect opening a vault to return an error: %s", err)
+	}
+
+	if names := opened.Names(); len(names) != 2 || names[0] != "admin" || names[1] != "user" {
+		t.Errorf("Expected the vault to hold admin and user; got %v", names)
+	}
+
+	want, _ := vault.Token("user")
+	if got, err := opened.Token("user"); got != want || err != nil {
+		t.Errorf("Expected the saved token; got %s and %v", got, err)
+	}
+
+	if _, err := OpenTokenVault(path, []byte("fedcba9876543210fedcba9876543210")); err != ErrInvalidVault {
+		t.Errorf("Expected opening a vault with another key to return %s; got %v", ErrInvalidVault, err)
+	}
+}
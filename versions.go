@@ -0,0 +1,106 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// A ClaimsSchema maps the ver claim of a token to the type its claims decode
+// into, so the shape of claims can evolve while tokens of older versions are
+// still accepted. Tokens without a ver claim use the empty version.
+type ClaimsSchema struct {
+	mu       sync.RWMutex
+	versions map[string]func() interface{}
+}
+
+// A versionedPayload decodes claims into a value chosen by their ver claim
+type versionedPayload struct {
+	schema  *ClaimsSchema
+	tag     string
+	version string
+	claims  interface{}
+}
+
+// NewClaimsSchema constructs an empty ClaimsSchema.
+func NewClaimsSchema() *ClaimsSchema {
+	return &ClaimsSchema{versions: map[string]func() interface{}{}}
+}
+
+// Register names a function returning a pointer to decode the claims of a
+// given version into. Registering a version again replaces it.
+func (s *ClaimsSchema) Register(version string, claims func() interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.versions[version] = claims
+}
+
+// Unregister stops accepting tokens of a given version, e.g. once a rollout
+// has finished.
+func (s *ClaimsSchema) Unregister(version string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.versions, version)
+}
+
+// DecodeSchema is like DecodeResult but decodes the claims of the token into
+// the type registered for its ver claim. The claims are available as the
+// Claims of the result.
+func (dec *Decoder) DecodeSchema(s *ClaimsSchema) (*DecodeResult, error) {
+	p := &versionedPayload{schema: s, tag: dec.TagName}
+
+	result, err := dec.DecodeResult(p)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Claims = p.claims
+
+	return result, nil
+}
+
+func (p *versionedPayload) UnmarshalJSON(b []byte) error {
+	var claims struct {
+		Version json.RawMessage `json:"ver"`
+	}
+
+	if err := json.Unmarshal(b, &claims); err != nil {
+		return err
+	}
+
+	if len(claims.Version) > 0 && json.Unmarshal(claims.Version, &p.version) != nil {
+		p.version = string(claims.Version)
+	}
+
+	p.schema.mu.RLock()
+	factory, ok := p.schema.versions[p.version]
+	p.schema.mu.RUnlock()
+
+	if !ok {
+		return ErrUnknownVersion
+	}
+
+	p.claims = factory()
+
+	var v interface{} = p.claims
+	if p.tag != "" {
+		v = &taggedPayload{v: p.claims, tag: p.tag}
+	}
+
+	return json.Unmarshal(b, v)
+}
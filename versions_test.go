@@ -0,0 +1,83 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"bytes"
+	"testing"
+)
+
+type claimsV1 struct {
+	Payload
+	Role string `json:"role"`
+}
+
+type claimsV2 struct {
+	Payload
+	Version string   `json:"ver"`
+	Roles   []string `json:"roles"`
+}
+
+func TestDecodeSchema(t *testing.T) {
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	schema := NewClaimsSchema()
+	schema.Register("", func() interface{} { return &claimsV1{} })
+	schema.Register("2", func() interface{} { return &claimsV2{} })
+	schema.Register("3", func() interface{} { return &claimsV1{} })
+
+	decode := func(payload interface{}) (*DecodeResult, error) {
+		token := signTestToken(t, v, Header{Type: "JWT"}, payload)
+		return NewDecoder(bytes.NewBufferString(token), v).DecodeSchema(schema)
+	}
+
+	result, err := decode(map[string]interface{}{"sub": "1234567890", "role": "admin"})
+	if err != nil {
+		t.Fatalf("Didn't expect decoding a registered version to return an error: %s", err)
+	}
+
+	if claims, ok := result.Claims.(*claimsV1); !ok || claims.Role != "admin" {
+		t.Errorf("Expected a token without ver to decode as version 1; got %#v", result.Claims)
+	}
+
+	result, err = decode(map[string]interface{}{"ver": "2", "roles": []string{"admin"}})
+	if err != nil {
+		t.Fatalf("Didn't expect decoding a registered version to return an error: %s", err)
+	}
+
+	if claims, ok := result.Claims.(*claimsV2); !ok || len(claims.Roles) != 1 {
+		t.Errorf("Expected a token with ver 2 to decode as version 2; got %#v", result.Claims)
+	}
+
+	result, err = decode(map[string]interface{}{"ver": 3, "role": "admin"})
+	if err != nil {
+		t.Fatalf("Didn't expect decoding a registered version to return an error: %s", err)
+	}
+
+	if _, ok := result.Claims.(*claimsV1); !ok {
+		t.Errorf("Expected a numeric ver to be accepted; got %#v", result.Claims)
+	}
+
+	if _, err = decode(map[string]interface{}{"ver": "4"}); err != ErrUnknownVersion {
+		t.Errorf("Expected an unregistered version to return %s; got %v", ErrUnknownVersion, err)
+	}
+
+	schema.Unregister("")
+
+	if _, err = decode(map[string]interface{}{"role": "admin"}); err != ErrUnknownVersion {
+		t.Errorf("Expected an unregistered version to return %s; got %v", ErrUnknownVersion, err)
+	}
+}
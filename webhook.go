@@ -0,0 +1,80 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import "time"
+
+// DefaultWebhookTolerance is the default age a webhook signature may have, in
+// either direction, before it is rejected.
+const DefaultWebhookTolerance = 5 * time.Minute
+
+// A WebhookSigner signs outgoing webhook bodies as detached JWS. The body is
+// not repeated in the signature, which is sent alongside it, e.g. in a request
+// header.
+type WebhookSigner struct {
+	validator Validator
+	// KeyID names the signing key so receivers can rotate keys
+	KeyID string
+}
+
+// NewWebhookSigner constructs a WebhookSigner that signs with a given validator.
+func NewWebhookSigner(v Validator) *WebhookSigner {
+	return &WebhookSigner{validator: v}
+}
+
+// Sign returns the detached signature of a given body. The time of signing is
+// protected by the iat header.
+func (s *WebhookSigner) Sign(body []byte) (string, error) {
+	return signDetached(s.validator, Header{KeyID: s.KeyID, IssuedAt: timeFunc().Unix()}, body)
+}
+
+// A WebhookVerifier verifies the detached signatures of inbound webhook bodies.
+type WebhookVerifier struct {
+	validator Validator
+	// Tolerance is how far the iat header may be from the current time
+	Tolerance time.Duration
+}
+
+// NewWebhookVerifier constructs a WebhookVerifier that verifies with a given
+// validator and accepts signatures within DefaultWebhookTolerance.
+func NewWebhookVerifier(v Validator) *WebhookVerifier {
+	return &WebhookVerifier{validator: v, Tolerance: DefaultWebhookTolerance}
+}
+
+// Verify asserts a given detached signature was made over a given body within
+// the tolerance window and returns its protected header.
+func (v *WebhookVerifier) Verify(signature string, body []byte) (*Header, error) {
+	header, err := verifyDetached(v.validator, signature, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if header.IssuedAt == 0 {
+		return nil, ErrMalformedToken
+	}
+
+	issued := time.Unix(header.IssuedAt, 0)
+	now := timeFunc()
+
+	if issued.Before(now.Add(-v.Tolerance)) {
+		return nil, ErrTokenExpired
+	}
+
+	if issued.After(now.Add(v.Tolerance)) {
+		return nil, ErrTokenNotYetValid
+	}
+
+	return header, nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWebhookSignVerify(t *testing.T) {
+	defer func() { timeFunc = time.Now }()
+
+	now := time.Unix(1700000000, 0)
+	timeFunc = func() time.Time { return now }
+
+	v := NewHSValidator(HS256)
+	v.Key = []byte("bogokey")
+
+	signer := NewWebhookSigner(v)
+	signer.KeyID = "hook-1"
+
+	body := []byte(`{"event":"push"}`)
+	signature, err := signer.Sign(body)
+	if err != nil {
+		t.Fatalf("Didn't expect signing a webhook to return an error: %s", err)
+	}
+
+	other := NewHSValidator(HS256)
+	other.Key = []byte("otherkey")
+
+	cases := []struct {
+		Validator     Validator
+		Signature     string
+		Body          []byte
+		Elapsed       time.Duration
+		ExpectedError error
+		Reason        string
+	}{
+		{v, signature, body, 0, nil, "a fresh signature should be valid"},
+		{v, signature, body, 4 * time.Minute, nil, "a signature within the tolerance should be valid"},
+		{v, signature, body, -4 * time.Minute, nil, "clock skew within the tolerance should be valid"},
+		{v, signature, body, 6 * time.Minute, ErrTokenExpired, "a stale signature should be rejected"},
+		{v, signature, body, -6 * time.Minute, ErrTokenNotYetValid, "a signature from the future should be rejected"},
+		{v, signature, []byte(`{"event":"pull"}`), 0, ErrBadSignature, "a tampered body should be rejected"},
+		{other, signature, body, 0, ErrBadSignature, "a signature by another key should be rejected"},
+		{v, "abc.def.ghi", body, 0, ErrMalformedToken, "an attached payload should be rejected"},
+	}
+
+	for _, c := range cases {
+		timeFunc = func() time.Time { return now.Add(c.Elapsed) }
+
+		h, err := NewWebhookVerifier(c.Validator).Verify(c.Signature, c.Body)
+
+		if err != c.ExpectedError {
+			t.Errorf("Expected %v; %s: got %v", c.ExpectedError, c.Reason, err)
+		}
+
+		if err == nil && (h.KeyID != "hook-1" || h.IssuedAt != now.Unix()) {
+			t.Errorf("Expected the protected header to carry kid and iat; got %#v", h)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+)
+
+// ErrUntrustedCertificate is returned when the x5c certificate chain of a
+// token does not verify against the trusted roots
+var ErrUntrustedCertificate = errors.New("untrusted certificate chain")
+
+// EncodeCertificateChain encodes certificates as the x5c header, starting with
+// the certificate of the signing key.
+func EncodeCertificateChain(certs []*x509.Certificate) []string {
+	chain := make([]string, len(certs))
+
+	for i, cert := range certs {
+		chain[i] = base64.StdEncoding.EncodeToString(cert.Raw)
+	}
+
+	return chain
+}
+
+// Certificates parses the x5c header, starting with the certificate of the
+// signing key.
+func (h Header) Certificates() ([]*x509.Certificate, error) {
+	certs := make([]*x509.Certificate, len(h.CertificateChain))
+
+	for i, encoded := range h.CertificateChain {
+		der, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, ErrMalformedToken
+		}
+
+		if certs[i], err = x509.ParseCertificate(der); err != nil {
+			return nil, ErrMalformedToken
+		}
+	}
+
+	return certs, nil
+}
+
+// An X5CKeyProvider is a KeyProvider that verifies tokens with the key of the
+// leaf certificate in their x5c header.
+type X5CKeyProvider struct {
+	// Roots are the trusted roots the chain must verify against. Tokens are
+	// rejected with ErrUntrustedCertificate when Roots is nil, unless
+	// InsecureSkipVerify is set.
+	Roots *x509.CertPool
+	// InsecureSkipVerify accepts chains without verifying them against Roots,
+	// so any token can carry a certificate of its own making. It is only
+	// safe when the tokens are trusted by other means.
+	InsecureSkipVerify bool
+	// KeyUsages, if set, are the extended key usages the leaf certificate
+	// must allow. Any usage is accepted when it is empty.
+	KeyUsages []x509.ExtKeyUsage
+}
+
+// VerificationKey implements KeyProvider.
+func (p X5CKeyProvider) VerificationKey(h Header) (interface{}, error) {
+	if len(h.CertificateChain) == 0 {
+		return nil, ErrUnknownKey
+	}
+
+	certs, err := h.Certificates()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case p.Roots != nil:
+		opts := x509.VerifyOptions{
+			Roots:         p.Roots,
+			Intermediates: x509.NewCertPool(),
+			CurrentTime:   timeFunc(),
+			KeyUsages:     p.KeyUsages,
+		}
+
+		if len(opts.KeyUsages) == 0 {
+			opts.KeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageAny}
+		}
+
+		for _, cert := range certs[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+
+		if _, err := certs[0].Verify(opts); err != nil {
+			return nil, ErrUntrustedCertificate
+		}
+	case !p.InsecureSkipVerify:
+		return nil, ErrUntrustedCertificate
+	}
+
+	if !matchesThumbprints(h, certs[0]) {
+		return nil, ErrUntrustedCertificate
+	}
+
+	return certs[0].PublicKey, nil
+}
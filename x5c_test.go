@@ -0,0 +1,117 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// testCertificateChain returns a leaf certificate for the test key pair issued
+// by a new certificate authority, and the certificate of the authority
+func testCertificateChain(t *testing.T) (*x509.Certificate, *x509.Certificate) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Recieved error when generating test key: %s", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("Didn't expect creating a test certificate to return an error: %s", err)
+	}
+
+	ca, _ := x509.ParseCertificate(der)
+
+	v := testRSValidator(t)
+	template = &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "issuer.example"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	if der, err = x509.CreateCertificate(rand.Reader, template, ca, v.PublicKey, caKey); err != nil {
+		t.Fatalf("Didn't expect creating a test certificate to return an error: %s", err)
+	}
+
+	leaf, _ := x509.ParseCertificate(der)
+
+	return leaf, ca
+}
+
+func TestX5CKeyProvider(t *testing.T) {
+	leaf, ca := testCertificateChain(t)
+	_, otherCA := testCertificateChain(t)
+
+	enc := NewEncoder(nil, testRSValidator(t))
+	enc.CertificateChain = []*x509.Certificate{leaf, ca}
+
+	token, err := enc.Sign(&Payload{Subject: "1234567890"})
+	if err != nil {
+		t.Fatalf("Didn't expect signing with a certificate chain to return an error: %s", err)
+	}
+
+	trusted := x509.NewCertPool()
+	trusted.AddCert(ca)
+
+	untrusted := x509.NewCertPool()
+	untrusted.AddCert(otherCA)
+
+	cases := []struct {
+		ExpectedError error
+		Reason        string
+		Provider      X5CKeyProvider
+		Token         string
+	}{
+		{nil, "the chain is trusted", X5CKeyProvider{Roots: trusted}, string(token)},
+		{nil, "the chain is not verified", X5CKeyProvider{InsecureSkipVerify: true}, string(token)},
+		{ErrUntrustedCertificate, "no roots are trusted", X5CKeyProvider{}, string(token)},
+		{ErrUntrustedCertificate, "the chain has another root", X5CKeyProvider{Roots: untrusted}, string(token)},
+		{ErrUntrustedCertificate, "the leaf does not allow the key usage", X5CKeyProvider{Roots: trusted, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning}}, string(token)},
+		{ErrUnknownKey, "the token has no chain", X5CKeyProvider{Roots: trusted}, signTestToken(t, testRSValidator(t), Header{Type: "JWT"}, &Payload{})},
+		{ErrMalformedToken, "the chain is not base64", X5CKeyProvider{Roots: trusted}, signTestToken(t, testRSValidator(t), Header{Type: "JWT", CertificateChain: []string{"!!"}}, &Payload{})},
+	}
+
+	for _, c := range cases {
+		dec := NewDecoder(nil, nil)
+		dec.KeyProvider = c.Provider
+
+		if err := dec.Verify(c.Token, &Payload{}); err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		}
+	}
+
+	result, _ := (&Decoder{KeyProvider: X5CKeyProvider{Roots: trusted}}).verify(string(token), &Payload{})
+	if certs, err := result.Header.Certificates(); err != nil || len(certs) != 2 || !certs[0].Equal(leaf) {
+		t.Errorf("Expected the x5c header to hold the leaf and its issuer; got %v and %v", certs, err)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"crypto/sha1"
+	"crypto/x509"
+	"sync"
+)
+
+// CertificateThumbprint returns the x5t thumbprint of a certificate.
+func CertificateThumbprint(cert *x509.Certificate) string {
+	sum := sha1.Sum(cert.Raw)
+	return encodeSegment(sum[:])
+}
+
+// matchesThumbprints reports whether the x5t and x5t#S256 headers, when
+// present, are the thumbprints of a certificate
+func matchesThumbprints(h Header, cert *x509.Certificate) bool {
+	if h.X509Thumbprint != "" && h.X509Thumbprint != CertificateThumbprint(cert) {
+		return false
+	}
+
+	return h.X509ThumbprintS256 == "" || h.X509ThumbprintS256 == CertificateThumbprintS256(cert)
+}
+
+// A CertificateStore is a KeyProvider that verifies tokens with the key of the
+// certificate named by their x5t or x5t#S256 header, as issued by Azure AD.
+// It is safe for concurrent use.
+type CertificateStore struct {
+	mu    sync.RWMutex
+	certs map[string]*x509.Certificate
+}
+
+// NewCertificateStore constructs a CertificateStore holding the given
+// certificates.
+func NewCertificateStore(certs ...*x509.Certificate) *CertificateStore {
+	s := &CertificateStore{certs: make(map[string]*x509.Certificate)}
+
+	for _, cert := range certs {
+		s.Add(cert)
+	}
+
+	return s
+}
+
+// Add adds a certificate to the store.
+func (s *CertificateStore) Add(cert *x509.Certificate) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.certs[CertificateThumbprint(cert)] = cert
+	s.certs[CertificateThumbprintS256(cert)] = cert
+}
+
+// Match returns the certificate named by the thumbprint headers of a token.
+// When both headers are present they must name the same certificate. A
+// certificate outside its validity period is untrusted.
+func (s *CertificateStore) Match(h Header) (*x509.Certificate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	thumbprint := h.X509ThumbprintS256
+	if thumbprint == "" {
+		thumbprint = h.X509Thumbprint
+	}
+
+	cert, ok := s.certs[thumbprint]
+	if thumbprint == "" || !ok || !matchesThumbprints(h, cert) {
+		return nil, ErrUnknownKey
+	}
+
+	if now := timeFunc(); now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return nil, ErrUntrustedCertificate
+	}
+
+	return cert, nil
+}
+
+// VerificationKey implements KeyProvider.
+func (s *CertificateStore) VerificationKey(h Header) (interface{}, error) {
+	cert, err := s.Match(h)
+	if err != nil {
+		return nil, err
+	}
+
+	return cert.PublicKey, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2015 Benjamin Campbell <benji@benjica.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package jwt
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+)
+
+func TestCertificateStore(t *testing.T) {
+	leaf, ca := testCertificateChain(t)
+	other, _ := testCertificateChain(t)
+
+	enc := NewEncoder(nil, testRSValidator(t))
+	enc.ThumbprintCertificate = leaf
+
+	token, err := enc.Sign(&Payload{Subject: "1234567890"})
+	if err != nil {
+		t.Fatalf("Didn't expect signing with a thumbprint certificate to return an error: %s", err)
+	}
+
+	sign := func(h Header) string { return signTestToken(t, testRSValidator(t), h, &Payload{}) }
+
+	cases := []struct {
+		ExpectedError error
+		Reason        string
+		Store         *CertificateStore
+		Token         string
+	}{
+		{nil, "both thumbprints name a stored certificate", NewCertificateStore(ca, leaf), string(token)},
+		{nil, "only x5t is given", NewCertificateStore(leaf), sign(Header{Type: "JWT", X509Thumbprint: CertificateThumbprint(leaf)})},
+		{nil, "only x5t#S256 is given", NewCertificateStore(leaf), sign(Header{Type: "JWT", X509ThumbprintS256: CertificateThumbprintS256(leaf)})},
+		{ErrUnknownKey, "the certificate is not stored", NewCertificateStore(ca), string(token)},
+		{ErrUnknownKey, "the thumbprints name different certificates", NewCertificateStore(leaf, other), sign(Header{Type: "JWT", X509Thumbprint: CertificateThumbprint(other), X509ThumbprintS256: CertificateThumbprintS256(leaf)})},
+		{ErrUnknownKey, "the token has no thumbprint", NewCertificateStore(leaf), sign(Header{Type: "JWT"})},
+		{ErrAlgorithmNotImplemented, "the certificate key does not fit the algorithm", NewCertificateStore(ca), sign(Header{Type: "JWT", X509ThumbprintS256: CertificateThumbprintS256(ca)})},
+	}
+
+	for _, c := range cases {
+		dec := NewDecoder(nil, nil)
+		dec.KeyProvider = c.Store
+
+		if err := dec.Verify(c.Token, &Payload{}); err != c.ExpectedError {
+			t.Errorf("Expected %v when %s; got %v", c.ExpectedError, c.Reason, err)
+		}
+	}
+
+	timeFunc = func() time.Time { return leaf.NotAfter.Add(time.Minute) }
+	defer func() { timeFunc = time.Now }()
+
+	if _, err := NewCertificateStore(leaf).Match(Header{X509Thumbprint: CertificateThumbprint(leaf)}); err != ErrUntrustedCertificate {
+		t.Errorf("Expected an expired certificate to return %s; got %v", ErrUntrustedCertificate, err)
+	}
+}
+
+func TestX5CThumbprintMismatch(t *testing.T) {
+	leaf, ca := testCertificateChain(t)
+
+	token := signTestToken(t, testRSValidator(t), Header{
+		Type:               "JWT",
+		CertificateChain:   EncodeCertificateChain([]*x509.Certificate{leaf}),
+		X509ThumbprintS256: CertificateThumbprintS256(ca),
+	}, &Payload{})
+
+	dec := NewDecoder(nil, nil)
+	dec.KeyProvider = X5CKeyProvider{InsecureSkipVerify: true}
+
+	if err := dec.Verify(token, &Payload{}); err != ErrUntrustedCertificate {
+		t.Errorf("Expected an x5c leaf that does not match x5t#S256 to return %s; got %v", ErrUntrustedCertificate, err)
+	}
+}